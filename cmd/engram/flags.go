@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alanbuscaglia/engram/internal/store"
+)
+
+// ─── Flag Parsing ────────────────────────────────────────────────────────────
+//
+// Each command gets its own flag.FlagSet, so `--limit=5`, `--limit 5` and
+// `-h` work everywhere. The stdlib parser stops at the first positional
+// argument; parseArgs keeps going so flags can appear before, after, or
+// between positionals (`engram search auth bug --type decision`).
+
+// newFlagSet returns a FlagSet for `engram <name>` whose -h output shows
+// usage (the arguments after the command name) followed by the flags.
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet("engram "+name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: engram %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses flags found anywhere in args and returns the positional
+// arguments in order. Everything after a literal "--" is positional.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var tail []string
+	for i, a := range args {
+		if a == "--" {
+			args, tail = args[:i], args[i+1:]
+			break
+		}
+	}
+
+	var positional []string
+	for {
+		fs.Parse(args) // ExitOnError: bad flags print usage and exit 2
+		args = fs.Args()
+		if len(args) == 0 {
+			return append(positional, tail...)
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// jsonOutput is set by the global --json flag: commands print their result
+// as JSON on stdout instead of formatted text. Errors still go to stderr.
+var jsonOutput bool
+
+// profileFlag is the global --profile flag; it takes precedence over
+// ENGRAM_PROFILE.
+var profileFlag string
+
+// takeGlobalFlags applies and removes the flags every command accepts
+// (--json, --profile NAME), wherever they appear before a literal "--".
+func takeGlobalFlags(args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			return append(out, args[i:]...)
+		}
+		switch {
+		case a == "--json" || a == "-json":
+			jsonOutput = true
+		case a == "--profile" || a == "-profile":
+			if i+1 >= len(args) {
+				fatal(fmt.Errorf("--profile needs a name"))
+			}
+			i++
+			profileFlag = args[i]
+		case strings.HasPrefix(a, "--profile=") || strings.HasPrefix(a, "-profile="):
+			_, profileFlag, _ = strings.Cut(a, "=")
+		default:
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// noJSON exits when --json is given to a command with no structured output.
+func noJSON(name string) {
+	if jsonOutput {
+		fatal(fmt.Errorf("--json is not supported by %s", name))
+	}
+}
+
+// usageError prints the FlagSet's usage and exits with status 1. Used when
+// the required positional arguments are missing.
+func usageError(fs *flag.FlagSet) {
+	fs.Usage()
+	os.Exit(1)
+}
+
+// listFlag is a repeatable string flag: --exclude a --exclude b.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// addTimeFlags registers --relative and --absolute, which override
+// cfg.TimeFormat (ENGRAM_TIME_FORMAT) for commands that print timestamps.
+func addTimeFlags(fs *flag.FlagSet, cfg *store.Config) {
+	fs.BoolFunc("relative", "show times as \"3 hours ago\"", func(string) error {
+		cfg.TimeFormat = store.TimeFormatRelative
+		return nil
+	})
+	fs.BoolFunc("absolute", "show times as stored (UTC)", func(string) error {
+		cfg.TimeFormat = store.TimeFormatAbsolute
+		return nil
+	})
+}
+
+// parseAge parses an age like "90d", "2w" or anything time.ParseDuration
+// accepts ("36h"). Days and weeks are the useful units for retention but
+// aren't understood by the standard library.
+func parseAge(v string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(v, suffix); ok {
+			f, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid age %q", v)
+			}
+			return time.Duration(f * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 90d, 2w or 36h)", v)
+	}
+	return d, nil
+}
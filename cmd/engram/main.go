@@ -2,21 +2,37 @@
 //
 // Usage:
 //
-//	engram serve          Start HTTP + MCP server
+//	engram serve          Start HTTP server (or Unix socket with --socket)
 //	engram mcp            Start MCP server only (stdio transport)
-//	engram search <query> Search memories from CLI
+//	engram search [query] Search memories from CLI
 //	engram save           Save a memory from CLI
 //	engram context        Show recent context
+//	engram tasks          Show open tasks
 //	engram stats          Show memory stats
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/alanbuscaglia/engram/internal/mcp"
 	"github.com/alanbuscaglia/engram/internal/server"
@@ -29,40 +45,249 @@ import (
 	mcpserver "github.com/mark3labs/mcp-go/server"
 )
 
+// defaultPort is where serve listens without ENGRAM_PORT or a port argument:
+// 7437 ("ENGR" on phone keypad vibes) unless the config file sets "port".
+var defaultPort = 7437
+
 // version is set via ldflags at build time by goreleaser.
 // Falls back to "dev" for local builds.
 var version = "dev"
 
 func main() {
+	os.Args = takeGlobalFlags(os.Args)
 	if len(os.Args) < 2 {
 		printUsage()
 		os.Exit(1)
 	}
 
 	cfg := store.DefaultConfig()
+	cfg.Author = engramsync.GetUsername()
+
+	// The config file goes first so env vars and flags override it
+	configPath := os.Getenv("ENGRAM_CONFIG")
+	if configPath == "" {
+		configPath = store.DefaultConfigPath()
+	}
+	fileCfg, err := store.LoadConfigFile(configPath)
+	if err != nil {
+		fatal(err)
+	}
+	fileCfg.Apply(&cfg)
+	if fileCfg.Port != nil {
+		defaultPort = *fileCfg.Port
+	}
 
 	// Allow overriding data dir via env
 	if dir := os.Getenv("ENGRAM_DATA_DIR"); dir != "" {
 		cfg.DataDir = dir
 	}
+	cfg.Profile = os.Getenv("ENGRAM_PROFILE")
+	if profileFlag != "" {
+		cfg.Profile = profileFlag
+	}
+	if v := os.Getenv("ENGRAM_BUSY_TIMEOUT_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.BusyTimeoutMs = n
+		}
+	}
+	if v := os.Getenv("ENGRAM_MAX_RETRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxRetries = n
+		}
+	}
+	if v := os.Getenv("ENGRAM_CACHE_SIZE_KB"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.CacheSizeKB = n
+		}
+	}
+	if v := os.Getenv("ENGRAM_MMAP_SIZE_MB"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MmapSizeMB = n
+		}
+	}
+	if v := os.Getenv("ENGRAM_BATCH_WINDOW_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.BatchWindow = time.Duration(n) * time.Millisecond
+		}
+	}
+	if v := os.Getenv("ENGRAM_DEDUP_WINDOW"); v != "" {
+		d, err := parseAge(v)
+		if err != nil {
+			fatal(fmt.Errorf("ENGRAM_DEDUP_WINDOW: %w", err))
+		}
+		cfg.DedupWindow = d
+	}
+	if v := os.Getenv("ENGRAM_BATCH_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.BatchSize = n
+		}
+	}
+	if v := os.Getenv("ENGRAM_SEARCH_CACHE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.SearchCache = n
+		}
+	}
+	if v := os.Getenv("ENGRAM_SEARCH_CACHE_TTL_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.SearchCacheTTL = time.Duration(n) * time.Millisecond
+		}
+	}
+	if v := os.Getenv("ENGRAM_AUTO_TITLE_WORDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.AutoTitleWords = n
+		}
+	}
+	if path := os.Getenv("ENGRAM_CONTEXT_TEMPLATE"); path != "" {
+		tmpl, err := os.ReadFile(path)
+		if err != nil {
+			fatal(fmt.Errorf("read context template: %w", err))
+		}
+		cfg.ContextTemplate = string(tmpl)
+	}
+	if v := os.Getenv("ENGRAM_MAX_CONTEXT_CHARS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxContextChars = n
+		}
+	}
+	if v := os.Getenv("ENGRAM_AUTHOR"); v != "" {
+		cfg.Author = v
+	}
+	if v := os.Getenv("ENGRAM_COMPRESS_CONTENT"); v != "" {
+		cfg.CompressContent = v == "1" || v == "true"
+	}
+	if v := os.Getenv("ENGRAM_TRACK_ACCESS"); v != "" {
+		cfg.TrackAccess = v == "1" || v == "true"
+	}
+	if v := os.Getenv("ENGRAM_STRICT_REDACTION"); v != "" {
+		cfg.StrictRedaction = v == "1" || v == "true"
+	}
+	if v := os.Getenv("ENGRAM_STRICT_TYPES"); v != "" {
+		cfg.StrictTypes = v == "1" || v == "true"
+	}
+	if path := os.Getenv("ENGRAM_REDACTION_PATTERNS"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fatal(fmt.Errorf("read redaction patterns: %w", err))
+		}
+		// One regex per line, since patterns routinely contain commas.
+		// They replace any from the config file.
+		cfg.RedactionPatterns = nil
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				cfg.RedactionPatterns = append(cfg.RedactionPatterns, line)
+			}
+		}
+	}
+	if v, ok := os.LookupEnv("ENGRAM_STOPWORDS"); ok {
+		cfg.Stopwords = splitCSV(v)
+	}
+	if v, ok := os.LookupEnv("ENGRAM_TYPE_IMPORTANCE"); ok {
+		cfg.TypeImportance = parseTypeImportance(v)
+	}
+	if v, ok := os.LookupEnv("ENGRAM_RANK_WEIGHTS"); ok {
+		cfg.RankWeights = parseRankWeights(v)
+	}
+	if v := os.Getenv("ENGRAM_HASHTAGS"); v != "" {
+		cfg.ExtractHashtags = v == "1" || v == "true"
+	}
+	if v := os.Getenv("ENGRAM_EXTRACT_REFS"); v != "" {
+		cfg.ExtractReferences = v == "1" || v == "true"
+	}
+	if v := os.Getenv("ENGRAM_TIME_FORMAT"); v != "" {
+		cfg.TimeFormat = v
+	}
+	if v := os.Getenv("ENGRAM_FTS_PREFIX"); v != "" {
+		for _, part := range splitCSV(v) {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				fatal(fmt.Errorf("ENGRAM_FTS_PREFIX: invalid length %q", part))
+			}
+			cfg.FTSPrefix = append(cfg.FTSPrefix, n)
+		}
+	}
+	if v := os.Getenv("ENGRAM_FTS_TOKENIZER"); v != "" {
+		cfg.FTSTokenizer = v
+	}
+	if v := os.Getenv("ENGRAM_MIN_TERM_LENGTH"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MinTermLength = n
+		}
+	}
 
 	switch os.Args[1] {
 	case "serve":
+		noJSON("serve")
 		cmdServe(cfg)
 	case "mcp":
+		noJSON("mcp")
 		cmdMCP(cfg)
 	case "tui":
+		noJSON("tui")
 		cmdTUI(cfg)
 	case "search":
 		cmdSearch(cfg)
+	case "search-prompts":
+		cmdSearchPrompts(cfg)
 	case "save":
 		cmdSave(cfg)
 	case "timeline":
 		cmdTimeline(cfg)
+	case "tasks":
+		cmdTasks(cfg)
+	case "task":
+		cmdTask(cfg)
+	case "delete":
+		cmdDelete(cfg)
+	case "prune":
+		cmdPrune(cfg)
+	case "archive":
+		cmdArchive(cfg, true)
+	case "unarchive":
+		cmdArchive(cfg, false)
+	case "edit":
+		cmdEdit(cfg)
+	case "pin":
+		cmdPin(cfg, true)
+	case "unpin":
+		cmdPin(cfg, false)
+	case "tag":
+		cmdTag(cfg)
+	case "link":
+		cmdLink(cfg)
+	case "links":
+		cmdLinks(cfg)
+	case "tags":
+		cmdTags(cfg)
+	case "session":
+		cmdSession(cfg)
+	case "merge":
+		cmdMerge(cfg)
+	case "project":
+		cmdProject(cfg)
+	case "types":
+		cmdTypes(cfg)
 	case "context":
 		cmdContext(cfg)
+	case "fact":
+		cmdFact(cfg)
+	case "facts":
+		cmdFacts(cfg)
+	case "audit":
+		cmdAudit(cfg)
+	case "fork":
+		cmdFork(cfg)
+	case "summary":
+		cmdSummary(cfg)
+	case "summarize":
+		cmdSummarize(cfg)
 	case "stats":
 		cmdStats(cfg)
+	case "vacuum":
+		cmdVacuum(cfg)
+	case "profiles":
+		cmdProfiles(cfg)
+	case "reindex":
+		cmdReindex(cfg)
 	case "export":
 		cmdExport(cfg)
 	case "import":
@@ -70,8 +295,13 @@ func main() {
 	case "sync":
 		cmdSync(cfg)
 	case "setup":
+		noJSON("setup")
 		cmdSetup()
 	case "version", "--version", "-v":
+		if jsonOutput {
+			printJSON(map[string]string{"version": version})
+			return
+		}
 		fmt.Printf("engram %s\n", version)
 	case "help", "--help", "-h":
 		printUsage()
@@ -85,17 +315,24 @@ func main() {
 // ─── Commands ────────────────────────────────────────────────────────────────
 
 func cmdServe(cfg store.Config) {
-	port := 7437 // "ENGR" on phone keypad vibes
+	fs := newFlagSet("serve", "[port] [flags]")
+	socketPath := fs.String("socket", os.Getenv("ENGRAM_SOCKET"), "serve a line-based JSON protocol on the Unix socket at `PATH` instead of HTTP (default $ENGRAM_SOCKET)")
+	args := parseArgs(fs, os.Args[2:])
+
+	port := defaultPort
 	if p := os.Getenv("ENGRAM_PORT"); p != "" {
 		if n, err := strconv.Atoi(p); err == nil {
 			port = n
 		}
 	}
 	// Allow: engram serve 8080
-	if len(os.Args) > 2 {
-		if n, err := strconv.Atoi(os.Args[2]); err == nil {
-			port = n
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid port %q\n", args[0])
+			os.Exit(1)
 		}
+		port = n
 	}
 
 	s, err := store.New(cfg)
@@ -105,12 +342,38 @@ func cmdServe(cfg store.Config) {
 	defer s.Close()
 
 	srv := server.New(s, port)
+	srv.SetAdminToken(os.Getenv("ENGRAM_ADMIN_TOKEN"))
+	if v := os.Getenv("ENGRAM_ADMIN"); v != "" {
+		srv.SetAdminOpen(v == "1" || v == "true")
+	}
+	srv.SetVersion(version)
+	if v := os.Getenv("ENGRAM_LOG_LEVEL"); v != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid ENGRAM_LOG_LEVEL %q (use debug, info, warn or error)\n", v)
+			os.Exit(1)
+		}
+		srv.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+	}
+
+	if *socketPath != "" {
+		// Stop cleanly on Ctrl+C / SIGTERM so the socket file is removed
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := srv.StartSocket(ctx, *socketPath); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
 	if err := srv.Start(); err != nil {
 		fatal(err)
 	}
 }
 
 func cmdMCP(cfg store.Config) {
+	parseArgs(newFlagSet("mcp", ""), os.Args[2:])
+
 	s, err := store.New(cfg)
 	if err != nil {
 		fatal(err)
@@ -124,13 +387,17 @@ func cmdMCP(cfg store.Config) {
 }
 
 func cmdTUI(cfg store.Config) {
+	fs := newFlagSet("tui", "[flags]")
+	addTimeFlags(fs, &cfg)
+	parseArgs(fs, os.Args[2:])
+
 	s, err := store.New(cfg)
 	if err != nil {
 		fatal(err)
 	}
 	defer s.Close()
 
-	model := tui.New(s)
+	model := tui.New(s, ".engram", os.Getenv("ENGRAM_SYNC_KEY"))
 	p := tea.NewProgram(model)
 	if _, err := p.Run(); err != nil {
 		fatal(err)
@@ -138,151 +405,1681 @@ func cmdTUI(cfg store.Config) {
 }
 
 func cmdSearch(cfg store.Config) {
-	if len(os.Args) < 3 {
-		fmt.Fprintln(os.Stderr, "usage: engram search <query> [--type TYPE] [--project PROJECT] [--limit N]")
+	opts := store.SearchOptions{}
+	var excludeTerms, excludeTypes, projects listFlag
+	fs := newFlagSet("search", "[query] [flags]")
+	fs.Var((*listFlag)(&opts.Types), "type", "only return observations of `TYPE` (repeatable, any may match)")
+	fs.Var(&projects, "project", "only search `PROJECT` (repeatable, any may match; default $ENGRAM_PROJECT)")
+	fs.IntVar(&opts.Limit, "limit", 10, "maximum number of results")
+	fs.IntVar(&opts.Offset, "offset", 0, "skip the first `N` results (for paging)")
+	fs.Var(&excludeTerms, "exclude", "drop results containing `TERM` (repeatable)")
+	fs.Var(&excludeTypes, "exclude-type", "drop results of `TYPE` (repeatable)")
+	fs.Var(&excludeTypes, "not-type", "same as --exclude-type")
+	fs.Var((*listFlag)(&opts.Tags), "tag", "only return observations tagged `TAG` (repeatable, all must match)")
+	fs.StringVar(&opts.Author, "author", "", "only return observations recorded by `NAME`")
+	fs.StringVar(&opts.SessionID, "session", "", "only search the session with this `ID`")
+	fs.BoolVar(&opts.Explain, "explain", false, "show per-column BM25 scores and matched terms")
+	fs.BoolVar(&opts.IncludeArchived, "archived", false, "include archived observations")
+	fs.BoolVar(&opts.Raw, "raw", false, "pass the query to FTS5 as-is (NEAR, OR, column:term)")
+	fs.BoolVar(&opts.Fuzzy, "fuzzy", false, "if nothing matches, fall back to titles similar to the query (typos)")
+	fs.Float64Var(&opts.RecencyWeight, "recency", 0, "blend relevance with recency, from 0 (pure relevance) to 1 (`WEIGHT`)")
+	exportFile := fs.String("export", "", "also write results as a re-importable JSON export to `FILE`")
+	includePrompts := fs.Bool("include-prompts", false, "also search user prompts, interleaved by rank")
+	watch := fs.Bool("watch", false, "rerun the search every --interval and redraw, marking new hits, until Ctrl-C")
+	interval := fs.Duration("interval", 2*time.Second, "how often --watch reruns the search (`DURATION`)")
+	addTimeFlags(fs, &cfg)
+	args := parseArgs(fs, os.Args[2:])
+	opts.ExcludeTerms = excludeTerms
+	opts.ExcludeTypes = excludeTypes
+	opts.Projects = projects
+	if len(projects) == 0 {
+		opts.Project = defaultProject()
+	}
+
+	// With no query, search lists the most recent observations that pass
+	// the filters, so "engram search --type decision" browses decisions.
+	query := strings.Join(args, " ")
+
+	if *includePrompts && strings.TrimSpace(query) == "" {
+		fmt.Fprintln(os.Stderr, "error: --include-prompts needs a query")
+		os.Exit(1)
+	}
+	if *includePrompts && *exportFile != "" {
+		fmt.Fprintln(os.Stderr, "error: --export works without --include-prompts only")
+		os.Exit(1)
+	}
+	if *watch && (jsonOutput || *exportFile != "" || *includePrompts) {
+		fmt.Fprintln(os.Stderr, "error: --watch works without --json, --export and --include-prompts only")
+		os.Exit(1)
+	}
+	if *watch && *interval <= 0 {
+		fmt.Fprintln(os.Stderr, "error: --interval must be positive")
 		os.Exit(1)
 	}
 
-	// Collect the query (everything that's not a flag)
-	var queryParts []string
-	opts := store.SearchOptions{Limit: 10}
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	if *includePrompts {
+		searchAll(s, query, opts)
+		return
+	}
+	if *watch {
+		watchSearch(s, query, opts, *interval)
+		return
+	}
+
+	resp, err := s.SearchPage(query, opts)
+	if err != nil {
+		fatal(err)
+	}
+	results := resp.Results
+
+	if jsonOutput {
+		if *exportFile != "" {
+			exportSearchResults(s, results, *exportFile)
+		}
+		printJSON(orEmpty(results))
+		return
+	}
+
+	printSearchPage(s, query, opts, resp, nil)
+
+	if *exportFile != "" {
+		n := exportSearchResults(s, results, *exportFile)
+		fmt.Printf("Exported %d memories to %s (re-import with: engram import %s)\n",
+			n, *exportFile, *exportFile)
+	}
+}
+
+// printSearchPage prints a page of search results as text. Results whose ID
+// is in fresh are marked new (search --watch).
+func printSearchPage(s *store.Store, query string, opts store.SearchOptions, resp *store.SearchResponse, fresh map[int64]bool) {
+	results := resp.Results
+	if len(results) == 0 {
+		if resp.Fallback {
+			fmt.Println("No memories match those filters.")
+		} else {
+			fmt.Printf("No memories found for: %q\n", query)
+		}
+		return
+	}
 
-	for i := 2; i < len(os.Args); i++ {
-		switch os.Args[i] {
-		case "--type":
-			if i+1 < len(os.Args) {
-				opts.Type = os.Args[i+1]
-				i++
+	if resp.HasMore {
+		fmt.Printf("Showing %d memories, more available (raise --limit, refine the query, or use --offset %d):\n\n", resp.Returned, opts.Offset+resp.Returned)
+	} else {
+		fmt.Printf("Found %d memories:\n\n", len(results))
+	}
+	if resp.Fallback {
+		fmt.Printf("(no query: most recent memories matching the filters)\n\n")
+	}
+	if resp.PrefixMatch {
+		fmt.Printf("(prefix matching: words also match longer words that start with them)\n\n")
+	}
+	if resp.Fuzzy {
+		fmt.Printf("(no exact matches: these titles are similar to the query)\n\n")
+	}
+	for i, r := range results {
+		project := ""
+		if r.Project != nil {
+			project = fmt.Sprintf(" | project: %s", *r.Project)
+		}
+		if r.Author != nil {
+			project += " | by " + *r.Author
+		}
+		if r.Archived {
+			project += " | archived"
+		}
+		if r.Pinned {
+			project += " | pinned"
+		}
+		marker := ""
+		if fresh[r.ID] {
+			marker = "  ← new"
+		}
+		fmt.Printf("[%d] #%d (%s) — %s%s\n    %s\n    %s%s\n\n",
+			opts.Offset+i+1, r.ID, r.Type, r.Title, marker,
+			truncate(r.Content, 300),
+			s.FormatTime(r.OccurredAt), project)
+		if r.Explain != nil {
+			fmt.Printf("    rank: %.4f\n", r.Rank)
+			for _, c := range r.Explain.Columns {
+				terms := ""
+				if len(c.Terms) > 0 {
+					terms = " matched: " + strings.Join(c.Terms, ", ")
+				}
+				fmt.Printf("      %-10s bm25=%.4f%s\n", c.Column, c.BM25, terms)
 			}
-		case "--project":
-			if i+1 < len(os.Args) {
-				opts.Project = os.Args[i+1]
-				i++
+			fmt.Println()
+		}
+	}
+}
+
+// watchSearch is search --watch: it reruns the search every interval and
+// redraws the screen, marking hits that weren't there on the previous poll,
+// until Ctrl-C.
+func watchSearch(s *store.Store, query string, opts store.SearchOptions, interval time.Duration) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var seen map[int64]bool // nil until the first poll, so nothing is new on it
+	for {
+		resp, err := s.SearchPage(query, opts)
+		if err != nil {
+			fatal(err)
+		}
+		fresh := make(map[int64]bool)
+		current := make(map[int64]bool, len(resp.Results))
+		for _, r := range resp.Results {
+			current[r.ID] = true
+			if seen != nil && !seen[r.ID] {
+				fresh[r.ID] = true
 			}
-		case "--limit":
-			if i+1 < len(os.Args) {
-				if n, err := strconv.Atoi(os.Args[i+1]); err == nil {
-					opts.Limit = n
-				}
-				i++
+		}
+		seen = current
+
+		// Clear the screen and move the cursor home
+		fmt.Print("\033[H\033[2J")
+		fmt.Printf("Every %s: engram search %s    %s (Ctrl-C to stop)\n\n", interval, query, time.Now().Format("15:04:05"))
+		printSearchPage(s, query, opts, resp, fresh)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// exportSearchResults writes search results as a re-importable export and
+// returns how many observations it holds.
+func exportSearchResults(s *store.Store, results []store.SearchResult, path string) int {
+	observations := make([]store.Observation, len(results))
+	for i, r := range results {
+		observations[i] = r.Observation
+	}
+	data, err := s.ExportObservations(observations)
+	if err != nil {
+		fatal(err)
+	}
+	if err := writeJSONFile(path, data); err != nil {
+		fatal(err)
+	}
+	return len(data.Observations)
+}
+
+// searchAll is search --include-prompts: observations and prompts in one
+// list.
+func searchAll(s *store.Store, query string, opts store.SearchOptions) {
+	results, err := s.SearchAll(query, opts)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(orEmpty(results))
+		return
+	}
+	if len(results) == 0 {
+		fmt.Printf("No memories or prompts found for: %q\n", query)
+		return
+	}
+
+	fmt.Printf("Found %d results:\n\n", len(results))
+	for i, r := range results {
+		n := opts.Offset + i + 1
+		if r.Prompt != nil {
+			project := ""
+			if r.Prompt.Project != "" {
+				project = fmt.Sprintf(" | project: %s", r.Prompt.Project)
 			}
-		default:
-			queryParts = append(queryParts, os.Args[i])
+			fmt.Printf("[%d] prompt #%d — session %s\n    %s\n    %s%s\n\n",
+				n, r.Prompt.ID, r.Prompt.SessionID,
+				truncate(r.Prompt.Content, 300),
+				s.FormatTime(r.Prompt.CreatedAt), project)
+			continue
+		}
+		o := r.Observation
+		project := ""
+		if o.Project != nil {
+			project = fmt.Sprintf(" | project: %s", *o.Project)
+		}
+		fmt.Printf("[%d] #%d (%s) — %s\n    %s\n    %s%s\n\n",
+			n, o.ID, o.Type, o.Title,
+			truncate(o.Content, 300),
+			s.FormatTime(o.OccurredAt), project)
+	}
+}
+
+func cmdSearchPrompts(cfg store.Config) {
+	fs := newFlagSet("search-prompts", "<query> [flags]")
+	project := fs.String("project", defaultProject(), "only search `PROJECT` (default $ENGRAM_PROJECT)")
+	limit := fs.Int("limit", 10, "maximum number of results")
+	addTimeFlags(fs, &cfg)
+	args := parseArgs(fs, os.Args[2:])
+	query := strings.Join(args, " ")
+	if strings.TrimSpace(query) == "" {
+		usageError(fs)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	prompts, err := s.SearchPrompts(query, *project, *limit)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(orEmpty(prompts))
+		return
+	}
+	if len(prompts) == 0 {
+		fmt.Printf("No prompts found for: %q\n", query)
+		return
+	}
+
+	fmt.Printf("Found %d prompts:\n\n", len(prompts))
+	for i, p := range prompts {
+		project := ""
+		if p.Project != "" {
+			project = fmt.Sprintf(" | project: %s", p.Project)
+		}
+		fmt.Printf("[%d] prompt #%d — session %s\n    %s\n    %s%s\n\n",
+			i+1, p.ID, p.SessionID,
+			truncate(p.Content, 300),
+			s.FormatTime(p.CreatedAt), project)
+	}
+}
+
+func cmdSave(cfg store.Config) {
+	fs := newFlagSet("save", "<title> <content> [flags]")
+	typ := fs.String("type", "manual", "observation `TYPE`")
+	project := fs.String("project", detectedProject(), "`PROJECT` to save under (default $ENGRAM_PROJECT, then the git repo)")
+	status := fs.String("status", "", "track as a task: pending, in-progress, done")
+	importance := fs.Int("importance", 0, "importance from 0 to 5 (default: the type's default)")
+	author := fs.String("author", "", "record `NAME` as the author (default $ENGRAM_AUTHOR, then your login name)")
+	occurredAt := fs.String("occurred-at", "", "when it happened, as `TIME` (RFC 3339 or YYYY-MM-DD; default now)")
+	var tags, refs listFlag
+	fs.Var(&tags, "tag", "tag the memory with `TAG` (repeatable)")
+	fs.Var(&refs, "ref", "link the memory to a `URL` or issue ID (repeatable)")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) != 2 {
+		usageError(fs)
+	}
+	title, content := args[0], args[1]
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	p := store.AddObservationParams{
+		SessionID:  "manual-save",
+		Type:       *typ,
+		Title:      title,
+		Content:    content,
+		Project:    *project,
+		Status:     *status,
+		Author:     *author,
+		OccurredAt: *occurredAt,
+		Tags:       tags,
+		References: refs,
+	}
+	// Only an --importance given on the command line overrides the type's
+	// default, so --importance 0 is kept
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "importance" {
+			p.Importance = importance
+		}
+	})
+
+	s.CreateSession("manual-save", *project, "")
+	res, err := s.SaveObservation(p)
+	if err != nil {
+		fatal(err)
+	}
+
+	if res.RedactionCount > 0 {
+		fmt.Fprintf(os.Stderr, "warning: redacted %d likely secret(s) before saving\n", res.RedactionCount)
+	}
+	if jsonOutput {
+		printJSON(res)
+		return
+	}
+	if res.Duplicate {
+		fmt.Printf("Already saved: #%d %q (%s) — identical memory within ENGRAM_DEDUP_WINDOW, nothing written\n", res.ID, res.Title, *typ)
+		return
+	}
+	fmt.Printf("Memory saved: #%d %q (%s)\n", res.ID, res.Title, *typ)
+}
+
+func cmdTasks(cfg store.Config) {
+	fs := newFlagSet("tasks", "[flags]")
+	project := fs.String("project", defaultProject(), "only list tasks in `PROJECT` (default $ENGRAM_PROJECT)")
+	addTimeFlags(fs, &cfg)
+	parseArgs(fs, os.Args[2:])
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	tasks, err := s.OpenTasks(*project, 0)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(orEmpty(tasks))
+		return
+	}
+
+	if len(tasks) == 0 {
+		fmt.Println("No open tasks.")
+		return
+	}
+
+	fmt.Printf("Open tasks (%d):\n\n", len(tasks))
+	for _, t := range tasks {
+		proj := ""
+		if t.Project != nil {
+			proj = fmt.Sprintf(" | project: %s", *t.Project)
+		}
+		fmt.Printf("  #%d [%s] %s\n    %s%s\n\n",
+			t.ID, *t.Status, t.Title, s.FormatTime(t.OccurredAt), proj)
+	}
+}
+
+func cmdTask(cfg store.Config) {
+	fs := newFlagSet("task", "<observation_id> <pending|in-progress|done>")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) != 2 {
+		usageError(fs)
+	}
+
+	obsID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid observation id %q\n", args[0])
+		os.Exit(1)
+	}
+	status := args[1]
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.SetStatus(obsID, status); err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(map[string]any{"id": obsID, "status": status})
+		return
+	}
+
+	fmt.Printf("Task #%d marked %s\n", obsID, status)
+}
+
+func cmdDelete(cfg store.Config) {
+	fs := newFlagSet("delete", "<observation_id> | --session <session_id>")
+	session := fs.String("session", "", "delete every observation in `SESSION_ID` instead")
+	args := parseArgs(fs, os.Args[2:])
+	if (*session == "") == (len(args) == 0) || len(args) > 1 {
+		usageError(fs)
+	}
+
+	var obsID int64
+	if *session == "" {
+		var err error
+		obsID, err = strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid observation id %q\n", args[0])
+			os.Exit(1)
 		}
 	}
 
-	query := strings.Join(queryParts, " ")
-	if query == "" {
-		fmt.Fprintln(os.Stderr, "error: search query is required")
-		os.Exit(1)
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	if *session != "" {
+		n, err := s.DeleteSessionObservations(*session)
+		if err != nil {
+			fatal(err)
+		}
+		if jsonOutput {
+			printJSON(map[string]any{"session_id": *session, "deleted": n})
+			return
+		}
+		fmt.Printf("Deleted %d observations from session %s\n", n, *session)
+		return
+	}
+
+	if err := s.DeleteObservation(obsID); err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(map[string]any{"id": obsID, "deleted": true})
+		return
+	}
+	fmt.Printf("Observation #%d deleted\n", obsID)
+}
+
+func cmdTimeline(cfg store.Config) {
+	fs := newFlagSet("timeline", "<observation_id> [flags]")
+	before := fs.Int("before", 5, "observations to show before the focus")
+	after := fs.Int("after", 5, "observations to show after the focus")
+	addTimeFlags(fs, &cfg)
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) != 1 {
+		usageError(fs)
+	}
+
+	obsID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid observation id %q\n", args[0])
+		os.Exit(1)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	result, err := s.Timeline(obsID, *before, *after)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(result)
+		return
+	}
+
+	// Session header
+	if result.SessionInfo != nil {
+		summary := ""
+		if result.SessionInfo.Summary != nil {
+			summary = fmt.Sprintf(" — %s", truncate(*result.SessionInfo.Summary, 100))
+		}
+		fmt.Printf("Session: %s (%s)%s\n", result.SessionInfo.Project, s.FormatTime(result.SessionInfo.StartedAt), summary)
+		fmt.Printf("Total observations in session: %d\n\n", result.TotalInRange)
+	}
+
+	// Before
+	if len(result.Before) > 0 {
+		fmt.Println("─── Before ───")
+		for _, e := range result.Before {
+			fmt.Printf("  #%d [%s] %s — %s\n", e.ID, e.Type, e.Title, truncate(e.Content, 150))
+		}
+		fmt.Println()
+	}
+
+	// Focus
+	fmt.Printf(">>> #%d [%s] %s <<<\n", result.Focus.ID, result.Focus.Type, result.Focus.Title)
+	fmt.Printf("    %s\n", truncate(result.Focus.Content, 500))
+	fmt.Printf("    %s\n\n", s.FormatTime(result.Focus.OccurredAt))
+
+	// After
+	if len(result.After) > 0 {
+		fmt.Println("─── After ───")
+		for _, e := range result.After {
+			fmt.Printf("  #%d [%s] %s — %s\n", e.ID, e.Type, e.Title, truncate(e.Content, 150))
+		}
+	}
+
+	if len(result.Links) > 0 {
+		fmt.Println("\n─── Links ───")
+		printLinks(result.Focus.ID, result.Links)
+	}
+}
+
+func cmdLink(cfg store.Config) {
+	fs := newFlagSet("link", "<from_id> <to_id> [relation]")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) < 2 || len(args) > 3 {
+		usageError(fs)
+	}
+
+	var ids [2]int64
+	for i, arg := range args[:2] {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid observation id %q\n", arg)
+			os.Exit(1)
+		}
+		ids[i] = id
+	}
+	relation := ""
+	if len(args) == 3 {
+		relation = args[2]
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.LinkObservations(ids[0], ids[1], relation); err != nil {
+		fatal(err)
+	}
+	links, err := s.GetLinks(ids[0])
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(orEmpty(links))
+		return
+	}
+	fmt.Printf("Observation #%d links:\n", ids[0])
+	printLinks(ids[0], links)
+}
+
+func cmdLinks(cfg store.Config) {
+	fs := newFlagSet("links", "<observation_id>")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) != 1 {
+		usageError(fs)
+	}
+
+	obsID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid observation id %q\n", args[0])
+		os.Exit(1)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	links, err := s.GetLinks(obsID)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(orEmpty(links))
+		return
+	}
+	if len(links) == 0 {
+		fmt.Printf("Observation #%d has no links\n", obsID)
+		return
+	}
+	fmt.Printf("Observation #%d links:\n", obsID)
+	printLinks(obsID, links)
+}
+
+// printLinks lists links from the point of view of observation id, with
+// arrows pointing the way the relation reads.
+func printLinks(id int64, links []store.Link) {
+	for _, l := range links {
+		if l.FromID == id {
+			fmt.Printf("  this —%s→ #%d %s\n", l.Relation, l.ToID, l.ToTitle)
+		} else {
+			fmt.Printf("  #%d %s —%s→ this\n", l.FromID, l.FromTitle, l.Relation)
+		}
+	}
+}
+
+func cmdSession(cfg store.Config) {
+	fs := newFlagSet("session", "<show|delete> <session_id> [flags]")
+	cascade := fs.Bool("cascade", false, "delete: also delete the session's observations and prompts")
+	addTimeFlags(fs, &cfg)
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) != 2 {
+		usageError(fs)
+	}
+	sessionID := args[1]
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	switch args[0] {
+	case "show":
+		showSession(s, sessionID)
+	case "delete":
+		if err := s.DeleteSession(sessionID, *cascade); err != nil {
+			fatal(err)
+		}
+		if jsonOutput {
+			printJSON(map[string]any{"session_id": sessionID, "deleted": true})
+			return
+		}
+		fmt.Printf("Session %s deleted\n", sessionID)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown session command: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+func cmdMerge(cfg store.Config) {
+	fs := newFlagSet("merge", "<target_session> <source_session>...")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) < 2 {
+		usageError(fs)
+	}
+	target, sources := args[0], args[1:]
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.MergeSessions(target, sources...); err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(map[string]any{"target": target, "merged": sources})
+		return
+	}
+	fmt.Printf("Merged %s into session %s\n", strings.Join(sources, ", "), target)
+}
+
+func cmdProject(cfg store.Config) {
+	fs := newFlagSet("project", "<alias <from> <to> | unalias <name> | aliases>")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) == 0 {
+		usageError(fs)
+	}
+
+	want := map[string]int{"alias": 3, "unalias": 2, "aliases": 1}
+	if n, ok := want[args[0]]; !ok {
+		fmt.Fprintf(os.Stderr, "unknown project command: %s\n", args[0])
+		os.Exit(1)
+	} else if len(args) != n {
+		usageError(fs)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	switch args[0] {
+	case "alias":
+		res, err := s.AddProjectAlias(args[1], args[2])
+		if err != nil {
+			fatal(err)
+		}
+		if jsonOutput {
+			printJSON(res)
+			return
+		}
+		fmt.Printf("%q is now an alias of %q\n", res.Alias, res.Canonical)
+		if res.Sessions+res.Observations+res.Prompts+res.Facts > 0 {
+			fmt.Printf("Moved %d sessions, %d observations, %d prompts and %d facts to %q\n",
+				res.Sessions, res.Observations, res.Prompts, res.Facts, res.Canonical)
+		}
+	case "unalias":
+		if err := s.RemoveProjectAlias(args[1]); err != nil {
+			fatal(err)
+		}
+		if jsonOutput {
+			printJSON(map[string]any{"alias": args[1], "removed": true})
+			return
+		}
+		fmt.Printf("Alias %q removed\n", args[1])
+	case "aliases":
+		aliases, err := s.ProjectAliases()
+		if err != nil {
+			fatal(err)
+		}
+		if jsonOutput {
+			printJSON(orEmpty(aliases))
+			return
+		}
+		if len(aliases) == 0 {
+			fmt.Println("No project aliases.")
+			return
+		}
+		for _, a := range aliases {
+			fmt.Printf("%-24s -> %s\n", a.Alias, a.Canonical)
+		}
+	}
+}
+
+// cmdTypes lists the known observation types one per line, so it can feed
+// shell completion: complete -W "$(engram types)" ... With --used it shows
+// the types actually in the database instead, flagging unknown ones.
+func cmdTypes(cfg store.Config) {
+	fs := newFlagSet("types", "[--used]")
+	used := fs.Bool("used", false, "list the types in the database with counts, flagging unknown ones")
+	if args := parseArgs(fs, os.Args[2:]); len(args) > 0 {
+		usageError(fs)
+	}
+
+	known := store.KnownTypes()
+	if !*used {
+		if jsonOutput {
+			printJSON(known)
+			return
+		}
+		for _, typ := range known {
+			fmt.Println(typ)
+		}
+		return
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	counts, err := s.CountObservations("")
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		type typeCount struct {
+			Type  string `json:"type"`
+			Count int    `json:"count"`
+			Known bool   `json:"known"`
+		}
+		out := []typeCount{}
+		for _, typ := range slices.Sorted(maps.Keys(counts)) {
+			out = append(out, typeCount{typ, counts[typ], slices.Contains(known, typ)})
+		}
+		printJSON(out)
+		return
+	}
+	if len(counts) == 0 {
+		fmt.Println("No observations yet.")
+		return
+	}
+	for _, typ := range slices.Sorted(maps.Keys(counts)) {
+		note := ""
+		if !slices.Contains(known, typ) {
+			note = "  (not a known type)"
+		}
+		fmt.Printf("%-16s %6d%s\n", typ, counts[typ], note)
+	}
+}
+
+func showSession(s *store.Store, sessionID string) {
+	result, err := s.SessionTimeline(sessionID)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(result)
+		return
+	}
+
+	sess := result.Session
+	fmt.Printf("Session: %s\n", sess.ID)
+	fmt.Printf("Project: %s\n", sess.Project)
+	if sess.Directory != "" {
+		fmt.Printf("Directory: %s\n", sess.Directory)
+	}
+	ended := "in progress"
+	if sess.EndedAt != nil {
+		ended = s.FormatTime(*sess.EndedAt)
+	}
+	fmt.Printf("Started: %s — Ended: %s\n", s.FormatTime(sess.StartedAt), ended)
+	if sess.Summary != nil {
+		fmt.Printf("Summary: %s\n", *sess.Summary)
+	}
+	fmt.Printf("Observations: %d\n\n", result.Total)
+
+	// Observations attributed to a prompt are shown under it, the first
+	// time one of them comes up in the replay
+	prompts := make(map[int64]store.Prompt, len(result.Prompts))
+	for _, p := range result.Prompts {
+		prompts[p.ID] = p
+	}
+	var current int64
+	for _, e := range result.Observations {
+		indent := "  "
+		if e.PromptID != nil {
+			if p, ok := prompts[*e.PromptID]; ok && p.ID != current {
+				fmt.Printf("  > Prompt #%d — %s\n    %s\n", p.ID, s.FormatTime(p.CreatedAt), truncate(p.Content, 300))
+				current = p.ID
+			}
+			indent = "      "
+		} else {
+			current = 0
+		}
+		fmt.Printf("%s#%d [%s] %s — %s\n%s  %s\n", indent, e.ID, e.Type, e.Title, s.FormatTime(e.OccurredAt), indent, truncate(e.Content, 300))
+	}
+}
+
+func cmdContext(cfg store.Config) {
+	fs := newFlagSet("context", "[project] [flags]")
+	sessionID := fs.String("session", "", "show one session (summary, prompts, observations) instead of recent memory, by `ID`")
+	addTimeFlags(fs, &cfg)
+	args := parseArgs(fs, os.Args[2:])
+	if *sessionID != "" && len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "error: --session can't be combined with a project")
+		os.Exit(1)
+	}
+	project := detectedProject()
+	if len(args) > 0 {
+		project = args[0]
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	if *sessionID != "" {
+		ctx, err := s.FormatSessionContext(*sessionID)
+		if err != nil {
+			fatal(err)
+		}
+		if jsonOutput {
+			printJSON(map[string]string{"session": *sessionID, "context": ctx})
+			return
+		}
+		fmt.Print(ctx)
+		return
+	}
+
+	ctx, err := s.FormatContext(project)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(map[string]string{"project": project, "context": ctx})
+		return
+	}
+
+	if ctx == "" {
+		fmt.Println("No previous session memories found.")
+		return
+	}
+
+	fmt.Print(ctx)
+}
+
+func cmdFact(cfg store.Config) {
+	fs := newFlagSet("fact", "<key> <content> [flags]")
+	project := fs.String("project", defaultProject(), "`PROJECT` the fact belongs to (default $ENGRAM_PROJECT, empty = everywhere)")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) != 2 {
+		usageError(fs)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	f, err := s.RecordFact(args[0], args[1], *project)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(f)
+		return
+	}
+
+	if f.SeenCount > 1 {
+		fmt.Printf("Fact %q updated (seen %d times since %s)\n", f.Key, f.SeenCount, s.FormatTime(f.FirstSeen))
+	} else {
+		fmt.Printf("Fact %q recorded\n", f.Key)
+	}
+}
+
+func cmdFacts(cfg store.Config) {
+	fs := newFlagSet("facts", "[flags]")
+	project := fs.String("project", defaultProject(), "show facts for `PROJECT` plus global ones (default $ENGRAM_PROJECT, empty = all)")
+	limit := fs.Int("limit", 50, "maximum number of facts")
+	addTimeFlags(fs, &cfg)
+	parseArgs(fs, os.Args[2:])
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	facts, err := s.Facts(*project, *limit)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(orEmpty(facts))
+		return
+	}
+	if len(facts) == 0 {
+		fmt.Println("No facts recorded.")
+		return
+	}
+
+	fmt.Printf("Known facts (%d):\n\n", len(facts))
+	for _, f := range facts {
+		proj := ""
+		if f.Project != "" {
+			proj = fmt.Sprintf(" | project: %s", f.Project)
+		}
+		fmt.Printf("  %s: %s\n    seen %dx, %s → %s%s\n\n",
+			f.Key, truncate(f.Content, 300), f.SeenCount, s.FormatTime(f.FirstSeen), s.FormatTime(f.LastSeen), proj)
+	}
+}
+
+func cmdAudit(cfg store.Config) {
+	fs := newFlagSet("audit", "[flags]")
+	limit := fs.Int("limit", 50, "maximum number of entries")
+	addTimeFlags(fs, &cfg)
+	parseArgs(fs, os.Args[2:])
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	entries, err := s.AuditLog(*limit)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(orEmpty(entries))
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Println("Audit log is empty.")
+		return
+	}
+
+	for _, e := range entries {
+		target := ""
+		if e.TargetID != "" {
+			target = " " + e.TargetID
+		}
+		fmt.Printf("%s  %s%s by %s\n", s.FormatTime(e.CreatedAt), e.Operation, target, cmp.Or(e.Actor, "unknown"))
+		if e.Detail != "" {
+			fmt.Printf("    %s\n", truncate(e.Detail, 200))
+		}
+	}
+}
+
+func cmdPrune(cfg store.Config) {
+	fs := newFlagSet("prune", "--older-than AGE [flags]")
+	olderThan := fs.String("older-than", "", "delete observations older than `AGE` (e.g. 90d, 2w, 36h)")
+	project := fs.String("project", "", "only prune `PROJECT`")
+	dryRun := fs.Bool("dry-run", false, "report what would be deleted without deleting it")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) != 0 || *olderThan == "" {
+		usageError(fs)
+	}
+	age, err := parseAge(*olderThan)
+	if err != nil {
+		fatal(err)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	scope := ""
+	if *project != "" {
+		scope = fmt.Sprintf(" in project %q", *project)
+	}
+
+	if *dryRun {
+		obs, sessions, err := s.PrunePreview(age, *project)
+		if err != nil {
+			fatal(err)
+		}
+		if jsonOutput {
+			printJSON(map[string]any{"dry_run": true, "observations": obs, "sessions": sessions})
+			return
+		}
+		fmt.Printf("Would delete %d observations older than %s%s, and %d sessions left empty (dry run, nothing deleted)\n",
+			obs, *olderThan, scope, sessions)
+		return
+	}
+
+	n, err := s.Prune(age, *project)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(map[string]any{"observations": n})
+		return
+	}
+	fmt.Printf("Deleted %d observations older than %s%s\n", n, *olderThan, scope)
+}
+
+// cmdArchive handles both "archive" and "unarchive".
+func cmdArchive(cfg store.Config, archive bool) {
+	name := "unarchive"
+	if archive {
+		name = "archive"
+	}
+	fs := newFlagSet(name, "<observation_id>...")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) == 0 {
+		usageError(fs)
+	}
+
+	ids := make([]int64, len(args))
+	for i, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid observation id %q\n", arg)
+			os.Exit(1)
+		}
+		ids[i] = id
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	for _, id := range ids {
+		if archive {
+			err = s.ArchiveObservation(id)
+		} else {
+			err = s.UnarchiveObservation(id)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		if !jsonOutput {
+			fmt.Printf("Observation #%d %sd\n", id, name)
+		}
+	}
+	if jsonOutput {
+		printJSON(map[string]any{"ids": ids, "archived": archive})
+	}
+}
+
+// cmdPin handles both "pin" and "unpin".
+func cmdPin(cfg store.Config, pin bool) {
+	name := "unpin"
+	if pin {
+		name = "pin"
+	}
+	fs := newFlagSet(name, "<observation_id>...")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) == 0 {
+		usageError(fs)
+	}
+
+	ids := make([]int64, len(args))
+	for i, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid observation id %q\n", arg)
+			os.Exit(1)
+		}
+		ids[i] = id
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	for _, id := range ids {
+		if err := s.PinObservation(id, pin); err != nil {
+			fatal(err)
+		}
+		if !jsonOutput {
+			fmt.Printf("Observation #%d %sned\n", id, name)
+		}
+	}
+	if jsonOutput {
+		printJSON(map[string]any{"ids": ids, "pinned": pin})
+	}
+}
+
+func cmdEdit(cfg store.Config) {
+	fs := newFlagSet("edit", "<observation_id> [flags]")
+	title := fs.String("title", "", "new `TITLE`")
+	content := fs.String("content", "", "new `CONTENT`")
+	typ := fs.String("type", "", "new observation `TYPE`")
+	importance := fs.Int("importance", 0, "new importance from 0 to 5")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) != 1 {
+		usageError(fs)
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid observation id %q\n", args[0])
+		os.Exit(1)
+	}
+
+	// Only flags given on the command line change anything
+	var p store.UpdateObservationParams
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "title":
+			p.Title = title
+		case "content":
+			p.Content = content
+		case "type":
+			p.Type = typ
+		case "importance":
+			p.Importance = importance
+		}
+	})
+	if p.Title == nil && p.Content == nil && p.Type == nil && p.Importance == nil {
+		fmt.Fprintln(os.Stderr, "error: give at least one of --title, --content, --type or --importance")
+		os.Exit(1)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	obs, err := s.UpdateObservation(id, p)
+	if errors.Is(err, sql.ErrNoRows) {
+		fatal(fmt.Errorf("observation #%d not found", id))
+	}
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(obs)
+		return
+	}
+	fmt.Printf("Observation #%d updated: %s (%s)\n", obs.ID, obs.Title, obs.Type)
+}
+
+func cmdTag(cfg store.Config) {
+	fs := newFlagSet("tag", "<observation_id> <tag>...")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) < 2 {
+		usageError(fs)
+	}
+
+	obsID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid observation id %q\n", args[0])
+		os.Exit(1)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.AddTags(obsID, args[1:]); err != nil {
+		fatal(err)
+	}
+	tags, err := s.ObservationTags(obsID)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(map[string]any{"id": obsID, "tags": orEmpty(tags)})
+		return
+	}
+	fmt.Printf("Observation #%d tags: %s\n", obsID, strings.Join(tags, ", "))
+}
+
+func cmdTags(cfg store.Config) {
+	fs := newFlagSet("tags", "[flags]")
+	project := fs.String("project", defaultProject(), "only count tags in `PROJECT` (default $ENGRAM_PROJECT, empty = all)")
+	parseArgs(fs, os.Args[2:])
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	counts, err := s.TagCounts(*project)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(orEmpty(counts))
+		return
+	}
+	if len(counts) == 0 {
+		fmt.Println("No tags yet.")
+		return
+	}
+
+	fmt.Printf("Tags (%d):\n", len(counts))
+	for _, c := range counts {
+		fmt.Printf("  %-24s %d\n", c.Tag, c.Count)
+	}
+}
+
+func cmdFork(cfg store.Config) {
+	var p store.ForkParams
+	fs := newFlagSet("fork", "--from PROJECT --to PROJECT [flags]")
+	fs.StringVar(&p.From, "from", "", "source `PROJECT`")
+	fs.StringVar(&p.To, "to", "", "destination `PROJECT`")
+	fs.StringVar(&p.Query, "query", "", "only copy memories matching `QUERY`")
+	fs.BoolVar(&p.KeepImportance, "keep-importance", false, "keep importance scores instead of resetting them to 0")
+	parseArgs(fs, os.Args[2:])
+	if p.From == "" || p.To == "" {
+		usageError(fs)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	n, err := s.Fork(p)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(map[string]any{"from": p.From, "to": p.To, "forked": n})
+		return
+	}
+
+	fmt.Printf("Forked %d memories from %q to %q\n", n, p.From, p.To)
+}
+
+func cmdSummarize(cfg store.Config) {
+	fs := newFlagSet("summarize", "<session_id> [--save]")
+	save := fs.Bool("save", false, "store the result as the session's summary")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) != 1 {
+		usageError(fs)
+	}
+	sessionID := args[0]
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	summary, err := s.SummarizeSession(sessionID)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		saved := *save && summary != ""
+		if saved {
+			if err := s.SetSessionSummary(sessionID, summary); err != nil {
+				fatal(err)
+			}
+		}
+		printJSON(map[string]any{"session_id": sessionID, "summary": summary, "saved": saved})
+		return
+	}
+	if summary == "" {
+		fmt.Printf("Session %s has no observations to summarize\n", sessionID)
+		return
+	}
+	fmt.Println(summary)
+
+	if *save {
+		if err := s.SetSessionSummary(sessionID, summary); err != nil {
+			fatal(err)
+		}
+		fmt.Printf("\nSaved as the summary of session %s\n", sessionID)
+	}
+}
+
+func cmdSummary(cfg store.Config) {
+	fs := newFlagSet("summary", "[project] [flags]")
+	addTimeFlags(fs, &cfg)
+	args := parseArgs(fs, os.Args[2:])
+	project := defaultProject()
+	if len(args) > 0 {
+		project = args[0]
+	}
+	if project == "" {
+		usageError(fs)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	sum, err := s.ProjectSummary(project)
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		printJSON(sum)
+		return
+	}
+
+	fmt.Printf("Project: %s\n", sum.Project)
+	if sum.FirstAt != "" {
+		fmt.Printf("  Active:       %s → %s\n", s.FormatTime(sum.FirstAt), s.FormatTime(sum.LastAt))
+	}
+	fmt.Printf("  Sessions:     %d\n", sum.Sessions)
+	fmt.Printf("  Observations: %d\n", sum.Observations)
+	fmt.Printf("  Prompts:      %d\n", sum.Prompts)
+	fmt.Printf("  Open tasks:   %d\n", sum.OpenTasks)
+
+	if len(sum.TopTypes) > 0 {
+		types := make([]string, len(sum.TopTypes))
+		for i, tc := range sum.TopTypes {
+			types[i] = fmt.Sprintf("%s (%d)", tc.Type, tc.Count)
+		}
+		fmt.Printf("  Top types:    %s\n", strings.Join(types, ", "))
+	}
+
+	if len(sum.KeyDecisions) > 0 {
+		fmt.Println("\n─── Key Decisions ───")
+		for _, o := range sum.KeyDecisions {
+			fmt.Printf("  #%d [%s] %s — %s\n", o.ID, o.Type, o.Title, truncate(o.Content, 150))
+		}
+	}
+
+	if len(sum.RecentSessions) > 0 {
+		fmt.Println("\n─── Recent Sessions ───")
+		for _, sess := range sum.RecentSessions {
+			summary := ""
+			if sess.Summary != nil {
+				summary = " — " + truncate(*sess.Summary, 100)
+			}
+			fmt.Printf("  %s (%d observations)%s\n", s.FormatTime(sess.StartedAt), sess.ObservationCount, summary)
+		}
+	}
+
+	if len(sum.RecentActivity) > 0 {
+		fmt.Println("\n─── Recent Activity ───")
+		for _, o := range sum.RecentActivity {
+			fmt.Printf("  #%d [%s] %s — %s\n", o.ID, o.Type, o.Title, s.FormatTime(o.OccurredAt))
+		}
+	}
+}
+
+func cmdStats(cfg store.Config) {
+	fs := newFlagSet("stats", "[project]")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) > 1 {
+		usageError(fs)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	var stats *store.Stats
+	if len(args) == 1 {
+		stats, err = s.ProjectStats(args[0])
+	} else {
+		stats, err = s.Stats()
+	}
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		stats.Projects = orEmpty(stats.Projects)
+		printJSON(stats)
+		return
+	}
+
+	if stats.Project != "" {
+		fmt.Printf("Engram Memory Stats: %s\n", stats.Project)
+		fmt.Printf("  Sessions:     %d\n", stats.TotalSessions)
+		fmt.Printf("  Observations: %d\n", stats.TotalObservations)
+		fmt.Printf("  Prompts:      %d\n", stats.TotalPrompts)
+		fmt.Printf("  Active:       %s — %s\n", s.FormatTime(stats.FirstAt), s.FormatTime(stats.LastAt))
+	} else {
+		projects := "none yet"
+		if len(stats.Projects) > 0 {
+			projects = strings.Join(stats.Projects, ", ")
+		}
+
+		fmt.Printf("Engram Memory Stats\n")
+		fmt.Printf("  Sessions:     %d\n", stats.TotalSessions)
+		fmt.Printf("  Observations: %d\n", stats.TotalObservations)
+		fmt.Printf("  Prompts:      %d\n", stats.TotalPrompts)
+		fmt.Printf("  Projects:     %s\n", projects)
+		fmt.Printf("  Database:     %s\n", cfg.DBPath())
+	}
+
+	if len(stats.ByType) > 0 {
+		types := make([]string, 0, len(stats.ByType))
+		for t := range stats.ByType {
+			types = append(types, t)
+		}
+		// Biggest first: that's where memory is accumulating
+		sort.Slice(types, func(i, j int) bool {
+			if stats.ByType[types[i]] != stats.ByType[types[j]] {
+				return stats.ByType[types[i]] > stats.ByType[types[j]]
+			}
+			return types[i] < types[j]
+		})
+		fmt.Printf("\nObservations by type:\n")
+		for _, t := range types {
+			name := t
+			if name == "" {
+				name = "(none)"
+			}
+			fmt.Printf("  %-16s %d\n", name, stats.ByType[t])
+		}
+	}
+}
+
+func cmdReindex(cfg store.Config) {
+	fs := newFlagSet("reindex", "[flags]")
+	inPlace := fs.Bool("in-place", false, "refill the indexes without recreating them, keeping their prefix and tokenizer settings")
+	if args := parseArgs(fs, os.Args[2:]); len(args) != 0 {
+		usageError(fs)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	if *inPlace {
+		err = s.RebuildFTS()
+	} else {
+		err = s.Reindex()
+	}
+	if err != nil {
+		fatal(err)
+	}
+	stats, err := s.Stats()
+	if err != nil {
+		fatal(err)
+	}
+	if jsonOutput {
+		out := map[string]any{
+			"reindexed":    true,
+			"in_place":     *inPlace,
+			"observations": stats.TotalObservations,
+			"prompts":      stats.TotalPrompts,
+		}
+		if !*inPlace {
+			out["fts_prefix"] = orEmpty(cfg.FTSPrefix)
+			out["fts_tokenizer"] = cfg.FTSTokenizer
+		}
+		printJSON(out)
+		return
+	}
+
+	var with []string
+	if !*inPlace && len(cfg.FTSPrefix) > 0 {
+		with = append(with, fmt.Sprintf("prefix lengths %v", cfg.FTSPrefix))
+	}
+	if !*inPlace && cfg.FTSTokenizer != "" {
+		with = append(with, fmt.Sprintf("tokenizer %q", cfg.FTSTokenizer))
+	}
+	msg := "Rebuilt search indexes"
+	if len(with) > 0 {
+		msg += " with " + strings.Join(with, " and ")
+	}
+	fmt.Printf("%s: %d observations, %d prompts reindexed\n", msg, stats.TotalObservations, stats.TotalPrompts)
+}
+
+func cmdVacuum(cfg store.Config) {
+	fs := newFlagSet("vacuum", "")
+	if args := parseArgs(fs, os.Args[2:]); len(args) != 0 {
+		usageError(fs)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer s.Close()
+
+	dbPath := cfg.DBPath()
+	before := dbFileSize(dbPath)
+	if err := s.Vacuum(); err != nil {
+		fatal(err)
+	}
+	after := dbFileSize(dbPath)
+
+	if jsonOutput {
+		printJSON(map[string]any{"path": dbPath, "size_before": before, "size_after": after})
+		return
+	}
+	fmt.Printf("Vacuumed %s: %s → %s (%s reclaimed)\n",
+		dbPath, formatSize(before), formatSize(after), formatSize(max(before-after, 0)))
+}
+
+func cmdProfiles(cfg store.Config) {
+	fs := newFlagSet("profiles", "")
+	if args := parseArgs(fs, os.Args[2:]); len(args) != 0 {
+		usageError(fs)
 	}
 
-	s, err := store.New(cfg)
+	names, err := store.Profiles(cfg.DataDir)
 	if err != nil {
 		fatal(err)
 	}
-	defer s.Close()
+	active := cfg.Profile
+	if active == "" {
+		active = store.DefaultProfile
+	}
 
-	results, err := s.Search(query, opts)
-	if err != nil {
-		fatal(err)
+	type profile struct {
+		Name   string `json:"name"`
+		Path   string `json:"path"`
+		Size   int64  `json:"size"`
+		Active bool   `json:"active"`
+	}
+	var profiles []profile
+	for _, name := range append([]string{store.DefaultProfile}, names...) {
+		pcfg := cfg
+		pcfg.Profile = name
+		profiles = append(profiles, profile{
+			Name:   name,
+			Path:   pcfg.DBPath(),
+			Size:   dbFileSize(pcfg.DBPath()),
+			Active: name == active,
+		})
 	}
 
-	if len(results) == 0 {
-		fmt.Printf("No memories found for: %q\n", query)
+	if jsonOutput {
+		printJSON(profiles)
 		return
 	}
-
-	fmt.Printf("Found %d memories:\n\n", len(results))
-	for i, r := range results {
-		project := ""
-		if r.Project != nil {
-			project = fmt.Sprintf(" | project: %s", *r.Project)
+	for _, p := range profiles {
+		mark := " "
+		if p.Active {
+			mark = "*"
 		}
-		fmt.Printf("[%d] #%d (%s) — %s\n    %s\n    %s%s\n\n",
-			i+1, r.ID, r.Type, r.Title,
-			truncate(r.Content, 300),
-			r.CreatedAt, project)
+		fmt.Printf("%s %-16s %9s  %s\n", mark, p.Name, formatSize(p.Size), p.Path)
+	}
+	if active != store.DefaultProfile && !slices.Contains(names, active) {
+		fmt.Printf("\n(profile %q is selected but has no database yet; it's created on first use)\n", active)
 	}
 }
 
-func cmdSave(cfg store.Config) {
-	if len(os.Args) < 4 {
-		fmt.Fprintln(os.Stderr, "usage: engram save <title> <content> [--type TYPE] [--project PROJECT]")
-		os.Exit(1)
+// dbFileSize is the on-disk size of a SQLite database including its WAL.
+func dbFileSize(dbPath string) int64 {
+	var total int64
+	for _, p := range []string{dbPath, dbPath + "-wal"} {
+		if info, err := os.Stat(p); err == nil {
+			total += info.Size()
+		}
 	}
+	return total
+}
 
-	title := os.Args[2]
-	content := os.Args[3]
-	typ := "manual"
-	project := ""
-
-	for i := 4; i < len(os.Args); i++ {
-		switch os.Args[i] {
-		case "--type":
-			if i+1 < len(os.Args) {
-				typ = os.Args[i+1]
-				i++
-			}
-		case "--project":
-			if i+1 < len(os.Args) {
-				project = os.Args[i+1]
-				i++
-			}
+// formatSize renders a byte count as B, KB, MB or GB.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	v := float64(n) / unit
+	for _, suffix := range []string{"KB", "MB"} {
+		if v < unit {
+			return fmt.Sprintf("%.1f %s", v, suffix)
 		}
+		v /= unit
 	}
+	return fmt.Sprintf("%.1f GB", v)
+}
 
-	s, err := store.New(cfg)
-	if err != nil {
-		fatal(err)
+func cmdExport(cfg store.Config) {
+	fs := newFlagSet("export", "[flags] [path]")
+	format := fs.String("format", "json", "output format: json, grouped-json, md or md-dir")
+	incremental := fs.Bool("incremental", false, "only export rows added since the last incremental export")
+	project := fs.String("project", "", "only export `PROJECT`'s memories")
+	since := fs.String("since", "", "only export memories that happened at or after `TIME` (RFC 3339 or YYYY-MM-DD)")
+	until := fs.String("until", "", "only export memories that happened before `TIME`")
+	var types listFlag
+	fs.Var(&types, "type", "only export observations of `TYPE` (repeatable; leaves prompts out)")
+	args := parseArgs(fs, os.Args[2:])
+	outFile := ""
+	if len(args) > 0 {
+		outFile = args[0]
 	}
-	defer s.Close()
 
-	s.CreateSession("manual-save", project, "")
-	id, err := s.AddObservation(store.AddObservationParams{
-		SessionID: "manual-save",
-		Type:      typ,
-		Title:     title,
-		Content:   content,
-		Project:   project,
-	})
-	if err != nil {
-		fatal(err)
+	switch *format {
+	case "json", "grouped-json", "md", "md-dir":
+	default:
+		fmt.Fprintf(os.Stderr, "error: unknown export format %q (use json, grouped-json, md or md-dir)\n", *format)
+		os.Exit(1)
 	}
-
-	fmt.Printf("Memory saved: #%d %q (%s)\n", id, title, typ)
-}
-
-func cmdTimeline(cfg store.Config) {
-	if len(os.Args) < 3 {
-		fmt.Fprintln(os.Stderr, "usage: engram timeline <observation_id> [--before N] [--after N]")
+	if *incremental && (*format == "md" || *format == "md-dir") {
+		fmt.Fprintln(os.Stderr, "error: --incremental works with json and grouped-json only")
 		os.Exit(1)
 	}
-
-	obsID, err := strconv.ParseInt(os.Args[2], 10, 64)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: invalid observation id %q\n", os.Args[2])
+	opts := store.ExportOptions{Project: *project, Types: types, Since: *since, Until: *until}
+	filtered := *project != "" || len(types) > 0 || *since != "" || *until != ""
+	if *project != "" && *format == "md-dir" {
+		fmt.Fprintln(os.Stderr, "error: --project doesn't work with --format md-dir")
 		os.Exit(1)
 	}
-
-	before, after := 5, 5
-	for i := 3; i < len(os.Args); i++ {
-		switch os.Args[i] {
-		case "--before":
-			if i+1 < len(os.Args) {
-				if n, err := strconv.Atoi(os.Args[i+1]); err == nil {
-					before = n
-				}
-				i++
-			}
-		case "--after":
-			if i+1 < len(os.Args) {
-				if n, err := strconv.Atoi(os.Args[i+1]); err == nil {
-					after = n
-				}
-				i++
-			}
-		}
+	if (len(types) > 0 || *since != "" || *until != "") && (*format == "md" || *format == "md-dir") {
+		fmt.Fprintln(os.Stderr, "error: --type, --since and --until work with json and grouped-json only")
+		os.Exit(1)
+	}
+	if *incremental && filtered {
+		fmt.Fprintln(os.Stderr, "error: --incremental can't be combined with --project, --type, --since or --until")
+		os.Exit(1)
 	}
 
 	s, err := store.New(cfg)
@@ -291,141 +2088,207 @@ func cmdTimeline(cfg store.Config) {
 	}
 	defer s.Close()
 
-	result, err := s.Timeline(obsID, before, after)
-	if err != nil {
-		fatal(err)
-	}
-
-	// Session header
-	if result.SessionInfo != nil {
-		summary := ""
-		if result.SessionInfo.Summary != nil {
-			summary = fmt.Sprintf(" — %s", truncate(*result.SessionInfo.Summary, 100))
+	if *format == "md" {
+		if outFile == "" {
+			outFile = "engram-export.md"
 		}
-		fmt.Printf("Session: %s (%s)%s\n", result.SessionInfo.Project, result.SessionInfo.StartedAt, summary)
-		fmt.Printf("Total observations in session: %d\n\n", result.TotalInRange)
+		md, err := s.ExportMarkdown(*project)
+		if err != nil {
+			fatal(err)
+		}
+		if err := os.WriteFile(outFile, []byte(md), 0644); err != nil {
+			fatal(fmt.Errorf("write %s: %w", outFile, err))
+		}
+		if jsonOutput {
+			printJSON(map[string]any{"path": outFile, "format": *format})
+			return
+		}
+		fmt.Printf("Exported markdown to %s\n", outFile)
+		return
 	}
 
-	// Before
-	if len(result.Before) > 0 {
-		fmt.Println("─── Before ───")
-		for _, e := range result.Before {
-			fmt.Printf("  #%d [%s] %s — %s\n", e.ID, e.Type, e.Title, truncate(e.Content, 150))
+	if *format == "md-dir" {
+		if outFile == "" {
+			outFile = "engram-export"
 		}
-		fmt.Println()
+		n, err := s.ExportMarkdownDir(outFile)
+		if err != nil {
+			fatal(err)
+		}
+		if jsonOutput {
+			printJSON(map[string]any{"path": outFile, "format": *format, "sessions": n})
+			return
+		}
+		fmt.Printf("Exported %d sessions as markdown to %s/\n", n, outFile)
+		return
 	}
 
-	// Focus
-	fmt.Printf(">>> #%d [%s] %s <<<\n", result.Focus.ID, result.Focus.Type, result.Focus.Title)
-	fmt.Printf("    %s\n", truncate(result.Focus.Content, 500))
-	fmt.Printf("    %s\n\n", result.Focus.CreatedAt)
+	if outFile == "" {
+		outFile = "engram-export.json"
+	}
 
-	// After
-	if len(result.After) > 0 {
-		fmt.Println("─── After ───")
-		for _, e := range result.After {
-			fmt.Printf("  #%d [%s] %s — %s\n", e.ID, e.Type, e.Title, truncate(e.Content, 150))
+	// Incremental: start where the last incremental export stopped
+	var watermark store.ExportWatermark
+	if *incremental {
+		if watermark, err = s.LastExportWatermark(); err != nil {
+			fatal(err)
 		}
 	}
-}
 
-func cmdContext(cfg store.Config) {
-	project := ""
-	if len(os.Args) > 2 {
-		project = os.Args[2]
+	// grouped-json needs every row in hand to nest them; plain json streams
+	if *format == "grouped-json" {
+		var data *store.ExportData
+		if filtered {
+			data, err = s.ExportFiltered(opts)
+		} else {
+			data, err = s.ExportSince(watermark)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		if *incremental && len(data.Sessions)+len(data.Observations)+len(data.Prompts) == 0 {
+			printNothingNew(watermark)
+			return
+		}
+		if err := writeJSONFile(outFile, data.Grouped()); err != nil {
+			fatal(err)
+		}
+		printExportSummary(outFile, *format, &store.ExportSummary{
+			Sessions:     len(data.Sessions),
+			Observations: len(data.Observations),
+			Prompts:      len(data.Prompts),
+		})
+		if *incremental {
+			if err := s.SaveExportWatermark(data.Watermark(watermark)); err != nil {
+				fatal(err)
+			}
+		}
+		return
 	}
 
-	s, err := store.New(cfg)
+	// Stream into a temp file next to the target and rename it into place,
+	// so an interrupted export never leaves a truncated file behind
+	tmp, err := os.CreateTemp(filepath.Dir(outFile), ".engram-export-*")
 	if err != nil {
-		fatal(err)
+		fatal(fmt.Errorf("write %s: %w", outFile, err))
 	}
-	defer s.Close()
-
-	ctx, err := s.FormatContext(project)
-	if err != nil {
-		fatal(err)
+	var sum *store.ExportSummary
+	if filtered {
+		sum, err = s.ExportFilteredTo(tmp, opts)
+	} else {
+		sum, err = s.ExportSinceTo(tmp, watermark)
 	}
-
-	if ctx == "" {
-		fmt.Println("No previous session memories found.")
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && *incremental && sum.Empty() {
+		os.Remove(tmp.Name())
+		printNothingNew(watermark)
 		return
 	}
-
-	fmt.Print(ctx)
-}
-
-func cmdStats(cfg store.Config) {
-	s, err := store.New(cfg)
-	if err != nil {
-		fatal(err)
+	if err == nil {
+		err = os.Rename(tmp.Name(), outFile)
 	}
-	defer s.Close()
-
-	stats, err := s.Stats()
 	if err != nil {
+		os.Remove(tmp.Name())
 		fatal(err)
 	}
+	printExportSummary(outFile, *format, sum)
 
-	projects := "none yet"
-	if len(stats.Projects) > 0 {
-		projects = strings.Join(stats.Projects, ", ")
+	// Only advance the watermark once the file is safely written
+	if *incremental {
+		if err := s.SaveExportWatermark(sum.Watermark); err != nil {
+			fatal(err)
+		}
 	}
-
-	fmt.Printf("Engram Memory Stats\n")
-	fmt.Printf("  Sessions:     %d\n", stats.TotalSessions)
-	fmt.Printf("  Observations: %d\n", stats.TotalObservations)
-	fmt.Printf("  Prompts:      %d\n", stats.TotalPrompts)
-	fmt.Printf("  Projects:     %s\n", projects)
-	fmt.Printf("  Database:     %s/engram.db\n", cfg.DataDir)
 }
 
-func cmdExport(cfg store.Config) {
-	outFile := "engram-export.json"
-	if len(os.Args) > 2 {
-		outFile = os.Args[2]
+func printExportSummary(outFile, format string, sum *store.ExportSummary) {
+	if jsonOutput {
+		printJSON(map[string]any{
+			"path":         outFile,
+			"format":       format,
+			"sessions":     sum.Sessions,
+			"observations": sum.Observations,
+			"prompts":      sum.Prompts,
+		})
+		return
 	}
+	fmt.Printf("Exported to %s\n", outFile)
+	fmt.Printf("  Sessions:     %d\n", sum.Sessions)
+	fmt.Printf("  Observations: %d\n", sum.Observations)
+	fmt.Printf("  Prompts:      %d\n", sum.Prompts)
+}
 
-	s, err := store.New(cfg)
-	if err != nil {
-		fatal(err)
+// printNothingNew reports an incremental export that found no new rows.
+func printNothingNew(since store.ExportWatermark) {
+	if jsonOutput {
+		printJSON(map[string]any{"written": false, "since": since.ExportedAt})
+		return
 	}
-	defer s.Close()
+	fmt.Printf("Nothing new since the last incremental export (%s) — no file written.\n", since.ExportedAt)
+}
 
-	data, err := s.Export()
-	if err != nil {
-		fatal(err)
+// confirmRestore asks before import --replace deletes the current memories,
+// and exits unless the answer is yes. The export itself may be on stdin, so
+// then there's no one to ask and --yes is required.
+func confirmRestore(s *store.Store, data *store.ExportData, from string) {
+	if from == "stdin" {
+		fmt.Fprintln(os.Stderr, "error: --replace with the export on stdin needs --yes, since there's no way to confirm")
+		os.Exit(1)
 	}
-
-	out, err := json.MarshalIndent(data, "", "  ")
+	stats, err := s.Stats()
 	if err != nil {
 		fatal(err)
 	}
-
-	if err := os.WriteFile(outFile, out, 0644); err != nil {
-		fatal(err)
+	fmt.Fprintf(os.Stderr, "This deletes all %d sessions, %d observations and %d prompts in the database\n",
+		stats.TotalSessions, stats.TotalObservations, stats.TotalPrompts)
+	fmt.Fprintf(os.Stderr, "and replaces them with the %d sessions, %d observations and %d prompts in %s.\n",
+		len(data.Sessions), len(data.Observations), len(data.Prompts), from)
+	fmt.Fprint(os.Stderr, "Continue? [y/N] ")
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return
 	}
-
-	fmt.Printf("Exported to %s\n", outFile)
-	fmt.Printf("  Sessions:     %d\n", len(data.Sessions))
-	fmt.Printf("  Observations: %d\n", len(data.Observations))
-	fmt.Printf("  Prompts:      %d\n", len(data.Prompts))
+	fmt.Fprintln(os.Stderr, "Aborted, nothing was changed.")
+	os.Exit(1)
 }
 
 func cmdImport(cfg store.Config) {
-	if len(os.Args) < 3 {
-		fmt.Fprintln(os.Stderr, "usage: engram import <file.json>")
-		os.Exit(1)
+	fs := newFlagSet("import", "<file.json | ->")
+	replace := fs.Bool("replace", false, "delete every session, observation and prompt first, restoring the database to the export")
+	yes := fs.Bool("yes", false, "don't ask for confirmation before --replace")
+	args := parseArgs(fs, os.Args[2:])
+	if len(args) != 1 {
+		usageError(fs)
 	}
 
-	inFile := os.Args[2]
-	raw, err := os.ReadFile(inFile)
+	// "-" reads the export from stdin: curl .../export | engram import -
+	inFile := args[0]
+	var raw []byte
+	var err error
+	if inFile == "-" {
+		inFile = "stdin"
+		raw, err = readLimited(os.Stdin, maxImportSize)
+	} else {
+		raw, err = os.ReadFile(inFile)
+	}
 	if err != nil {
 		fatal(fmt.Errorf("read %s: %w", inFile, err))
 	}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		fatal(fmt.Errorf("read %s: no data", inFile))
+	}
 
-	var data store.ExportData
-	if err := json.Unmarshal(raw, &data); err != nil {
-		fatal(fmt.Errorf("parse %s: %w", inFile, err))
+	// Accepts both the flat and the grouped-json export layouts
+	data, err := store.DecodeExport(raw)
+	if err != nil {
+		fatal(fmt.Errorf("parse %s: %w", inFile, jsonErrorContext(raw, err)))
 	}
 
 	s, err := store.New(cfg)
@@ -434,46 +2297,61 @@ func cmdImport(cfg store.Config) {
 	}
 	defer s.Close()
 
-	result, err := s.Import(&data)
+	if *replace {
+		if !*yes {
+			confirmRestore(s, data, inFile)
+		}
+		result, err := s.RestoreFrom(data)
+		if err != nil {
+			fatal(err)
+		}
+		if jsonOutput {
+			printJSON(result)
+			return
+		}
+		fmt.Printf("Restored from %s\n", inFile)
+		fmt.Printf("  Sessions:     %d\n", result.SessionsImported)
+		fmt.Printf("  Observations: %d imported, %d duplicates skipped\n", result.ObservationsImported, result.ObservationsSkipped)
+		fmt.Printf("  Prompts:      %d\n", result.PromptsImported)
+		return
+	}
+
+	result, err := s.Import(data)
 	if err != nil {
 		fatal(err)
 	}
+	if jsonOutput {
+		printJSON(result)
+		return
+	}
 
 	fmt.Printf("Imported from %s\n", inFile)
 	fmt.Printf("  Sessions:     %d\n", result.SessionsImported)
-	fmt.Printf("  Observations: %d\n", result.ObservationsImported)
+	fmt.Printf("  Observations: %d imported, %d duplicates skipped\n", result.ObservationsImported, result.ObservationsSkipped)
 	fmt.Printf("  Prompts:      %d\n", result.PromptsImported)
 }
 
 func cmdSync(cfg store.Config) {
-	// Parse flags
-	doImport := false
-	doStatus := false
-	doAll := false
-	project := ""
-	for i := 2; i < len(os.Args); i++ {
-		switch os.Args[i] {
-		case "--import":
-			doImport = true
-		case "--status":
-			doStatus = true
-		case "--all":
-			doAll = true
-		case "--project":
-			if i+1 < len(os.Args) {
-				project = os.Args[i+1]
-				i++
-			}
-		}
+	fs := newFlagSet("sync", "[flags]")
+	doImport := fs.Bool("import", false, "import new chunks from .engram/ into the local DB")
+	doStatus := fs.Bool("status", false, "show sync status (local vs remote chunks)")
+	doPreview := fs.Bool("preview", false, "summarize chunks pending import without importing")
+	doAll := fs.Bool("all", false, "export ALL projects (ignore directory-based filter)")
+	dryRun := fs.Bool("dry-run", false, "show what the next export would contain without writing a chunk")
+	project := fs.String("project", "", "filter export to `PROJECT` (default $ENGRAM_PROJECT, then the git repo)")
+	key := fs.String("key", "", "encrypt exported chunks and decrypt imported ones with `PASSPHRASE` (default $ENGRAM_SYNC_KEY)")
+	parseArgs(fs, os.Args[2:])
+
+	if *key == "" {
+		*key = os.Getenv("ENGRAM_SYNC_KEY")
 	}
 
-	// Default project to current directory name (so sync only exports
-	// memories for THIS project, not everything in the global DB).
+	// Default project to ENGRAM_PROJECT, falling back to the git repo (so
+	// sync only exports memories for THIS project, not everything in the
+	// global DB).
 	// --all skips project filtering entirely — exports everything.
-	if !doAll && project == "" {
-		if cwd, err := os.Getwd(); err == nil {
-			project = filepath.Base(cwd)
-		}
+	if !*doAll && *project == "" {
+		*project = detectedProject()
 	}
 
 	syncDir := ".engram"
@@ -484,13 +2362,17 @@ func cmdSync(cfg store.Config) {
 	}
 	defer s.Close()
 
-	sy := engramsync.New(s, syncDir)
+	sy := engramsync.New(s, syncDir).WithKey(*key)
 
-	if doStatus {
+	if *doStatus {
 		local, remote, pending, err := sy.Status()
 		if err != nil {
 			fatal(err)
 		}
+		if jsonOutput {
+			printJSON(map[string]int{"local_chunks": local, "remote_chunks": remote, "pending_import": pending})
+			return
+		}
 		fmt.Printf("Sync status:\n")
 		fmt.Printf("  Local chunks:    %d\n", local)
 		fmt.Printf("  Remote chunks:   %d\n", remote)
@@ -498,11 +2380,56 @@ func cmdSync(cfg store.Config) {
 		return
 	}
 
-	if doImport {
+	if *doPreview {
+		previews, err := sy.Preview()
+		if err != nil {
+			fatal(err)
+		}
+		if jsonOutput {
+			printJSON(orEmpty(previews))
+			return
+		}
+		if len(previews) == 0 {
+			fmt.Println("Nothing pending — all chunks in .engram/ are already imported.")
+			return
+		}
+
+		fmt.Printf("%d chunk(s) pending import:\n\n", len(previews))
+		for _, p := range previews {
+			fmt.Printf("Chunk %s — by %s at %s\n", p.ID, p.CreatedBy, p.CreatedAt)
+			if p.Missing {
+				fmt.Println("  (chunk file missing — not pulled yet?)")
+				fmt.Println()
+				continue
+			}
+			if p.Locked {
+				fmt.Println("  (encrypted — set ENGRAM_SYNC_KEY or pass --key to preview)")
+				fmt.Println()
+				continue
+			}
+			fmt.Printf("  Projects:     %s\n", strings.Join(p.Projects, ", "))
+			fmt.Printf("  Date range:   %s → %s\n", p.FirstAt, p.LastAt)
+			fmt.Printf("  Sessions:     %d\n", p.Sessions)
+			fmt.Printf("  Observations: %d\n", p.Observations)
+			fmt.Printf("  Prompts:      %d\n", p.Prompts)
+			for _, t := range p.SampleTitles {
+				fmt.Printf("    - %s\n", t)
+			}
+			fmt.Println()
+		}
+		fmt.Println("Import with: engram sync --import")
+		return
+	}
+
+	if *doImport {
 		result, err := sy.Import()
 		if err != nil {
 			fatal(err)
 		}
+		if jsonOutput {
+			printJSON(result)
+			return
+		}
 
 		if result.ChunksImported == 0 {
 			fmt.Println("Already up to date — no new chunks to import.")
@@ -514,7 +2441,7 @@ func cmdSync(cfg store.Config) {
 
 		fmt.Printf("Imported %d new chunk(s) from .engram/\n", result.ChunksImported)
 		fmt.Printf("  Sessions:     %d\n", result.SessionsImported)
-		fmt.Printf("  Observations: %d\n", result.ObservationsImported)
+		fmt.Printf("  Observations: %d imported, %d duplicates skipped\n", result.ObservationsImported, result.ObservationsSkipped)
 		fmt.Printf("  Prompts:      %d\n", result.PromptsImported)
 		if result.ChunksSkipped > 0 {
 			fmt.Printf("  Skipped:      %d (already imported)\n", result.ChunksSkipped)
@@ -524,21 +2451,60 @@ func cmdSync(cfg store.Config) {
 
 	// Export: DB → new chunk
 	username := engramsync.GetUsername()
-	if doAll {
-		fmt.Println("Exporting ALL memories (all projects)...")
-	} else {
-		fmt.Printf("Exporting memories for project %q...\n", project)
+	if *dryRun {
+		p, err := sy.PreviewExport(username, *project)
+		if err != nil {
+			fatal(err)
+		}
+		if jsonOutput {
+			printJSON(p)
+			return
+		}
+		if p.Sessions+p.Observations+p.Prompts == 0 {
+			if *doAll {
+				fmt.Println("Nothing new to sync — all memories already exported.")
+			} else {
+				fmt.Printf("Nothing new to sync for project %q — all memories already exported.\n", *project)
+			}
+			return
+		}
+		if *doAll {
+			fmt.Printf("Dry run: would export chunk %s (all projects)\n", p.ID)
+		} else {
+			fmt.Printf("Dry run: would export chunk %s for project %q\n", p.ID, *project)
+		}
+		fmt.Printf("  Projects:     %s\n", strings.Join(p.Projects, ", "))
+		fmt.Printf("  Date range:   %s → %s\n", p.FirstAt, p.LastAt)
+		fmt.Printf("  Sessions:     %d\n", p.Sessions)
+		fmt.Printf("  Observations: %d\n", p.Observations)
+		fmt.Printf("  Prompts:      %d\n", p.Prompts)
+		for _, t := range p.SampleTitles {
+			fmt.Printf("    - %s\n", t)
+		}
+		fmt.Println("\nNothing was written. Run without --dry-run to create the chunk.")
+		return
+	}
+	if !jsonOutput {
+		if *doAll {
+			fmt.Println("Exporting ALL memories (all projects)...")
+		} else {
+			fmt.Printf("Exporting memories for project %q...\n", *project)
+		}
 	}
-	result, err := sy.Export(username, project)
+	result, err := sy.Export(username, *project)
 	if err != nil {
 		fatal(err)
 	}
+	if jsonOutput {
+		printJSON(result)
+		return
+	}
 
 	if result.IsEmpty {
-		if doAll {
+		if *doAll {
 			fmt.Println("Nothing new to sync — all memories already exported.")
 		} else {
-			fmt.Printf("Nothing new to sync for project %q — all memories already exported.\n", project)
+			fmt.Printf("Nothing new to sync for project %q — all memories already exported.\n", *project)
 		}
 		return
 	}
@@ -553,11 +2519,13 @@ func cmdSync(cfg store.Config) {
 }
 
 func cmdSetup() {
+	fs := newFlagSet("setup", "[agent]")
+	args := parseArgs(fs, os.Args[2:])
 	agents := setup.SupportedAgents()
 
 	// If agent name given directly: engram setup opencode
-	if len(os.Args) > 2 && !strings.HasPrefix(os.Args[2], "-") {
-		result, err := setup.Install(os.Args[2])
+	if len(args) > 0 {
+		result, err := setup.Install(args[0])
 		if err != nil {
 			fatal(err)
 		}
@@ -568,8 +2536,8 @@ func cmdSetup() {
 	}
 
 	// Interactive selection
-	fmt.Println("engram setup — Install agent plugin\n")
-	fmt.Println("Which agent do you want to set up?\n")
+	fmt.Print("engram setup — Install agent plugin\n\n")
+	fmt.Print("Which agent do you want to set up?\n\n")
 
 	for i, a := range agents {
 		fmt.Printf("  [%d] %s\n", i+1, a.Description)
@@ -619,30 +2587,134 @@ func printUsage() {
 
 Usage:
   engram <command> [arguments]
+  engram <command> -h    Show a command's flags
+  engram <command> --json  Print the result as JSON instead of text (any command except serve, mcp, tui, setup)
+  engram <command> --profile NAME
+                         Use the NAME profile's database (~/.engram/profiles/NAME/engram.db)
 
 Commands:
-  serve [port]       Start HTTP API server (default: 7437)
+  serve [port]       Start HTTP API server (default: 7437) [--socket PATH for a Unix socket instead]
   mcp                Start MCP server (stdio transport, for any AI agent)
   tui                Launch interactive terminal UI
-  search <query>     Search memories [--type TYPE] [--project PROJECT] [--limit N]
-  save <title> <msg> Save a memory  [--type TYPE] [--project PROJECT]
+  search [query]     Search memories; no query lists recent ones [--type TYPE]... [--project PROJECT]... [--tag TAG] [--limit N]
+                       --exclude TERM   Drop results containing TERM (repeatable)
+                       --exclude-type TYPE
+                                        Drop results of TYPE (repeatable; --not-type works too)
+                       --author NAME    Only memories recorded by NAME
+                       --session ID     Only search one session (then drill in with timeline)
+                       --explain        Show per-column BM25 scores and matched terms
+                       --archived       Include archived memories
+                       --raw            Pass the query to FTS5 untouched (NEAR, OR, column:term)
+                       --fuzzy          If nothing matches, fall back to similar titles (typos)
+                       --recency W      Favor recent matches, 0 (off) to 1
+                       --export FILE    Also write results as a re-importable JSON export
+                       --include-prompts Also search user prompts, interleaved by rank
+                       --watch [--interval 2s] Rerun every interval, marking new hits
+  search-prompts <query>
+                     Search past user prompts [--project PROJECT] [--limit N]
+  save <title> <msg> Save a memory  [--type TYPE] [--project PROJECT] [--author NAME] [--occurred-at TIME] [--status STATUS] [--importance 0-5] [--tag TAG] [--ref URL]
   timeline <obs_id>  Show chronological context around an observation [--before N] [--after N]
-  context [project]  Show recent context from previous sessions
-  stats              Show memory system statistics
+  session show <id> Show every observation in a session, in order
+  session delete <id> [--cascade]
+                     Delete a session (--cascade also deletes its observations and prompts)
+  merge <target> <source>...
+                     Move the source sessions' memories and prompts into target and delete the sources
+  project alias <from> <to>
+                     Treat project <from> as <to> from now on and move its memories over
+  project unalias <name>
+                     Stop treating <name> as an alias
+  project aliases    List project aliases
+  types              List known observation types, one per line (for --type completion) [--used: types in the DB]
+  context [project]  Show recent context from previous sessions [--session ID for one session in full]
+  fact <key> <text>  Record a recurring fact; same key updates it [--project PROJECT]
+  facts              List known facts, most seen first [--project PROJECT]
+  audit              Show who deleted, edited, pruned or imported what, newest first [--limit N]
+  tasks              List open tasks (pending/in-progress) [--project PROJECT]
+  task <id> <status> Set task status: pending, in-progress, done
+  tag <id> <tag>...  Add tags to an existing memory
+  tags               List tags with how many memories carry each [--project PROJECT]
+  link <from> <to> [relation]
+                     Link two memories, e.g. "link 42 57 caused" (default relation: related)
+  links <obs_id>     List a memory's links in both directions
+  delete <obs_id>    Permanently delete a memory [--session ID deletes all of a session's memories]
+  prune --older-than AGE
+                     Delete memories older than AGE (90d, 2w, 36h) and sessions left empty [--project P] [--dry-run]
+  archive <obs_id>...
+                     Hide memories from search and context without deleting them
+  unarchive <obs_id>...
+                     Bring archived memories back
+  edit <obs_id>      Change a memory in place [--title T] [--content C] [--type TYPE] [--importance 0-5]
+  pin <obs_id>...    Keep memories through prune and list them first in context
+  unpin <obs_id>...  Undo pin
+  fork               Copy memories into a new project --from P --to P [--query Q] [--keep-importance]
+  summary [project]  Overview of a project: activity, top types, key decisions
+  summarize <session_id>
+                     Summarize a session from its observation titles [--save to store it]
+  stats [project]    Show memory system statistics, or one project's counts, types and activity range
+  profiles           List profiles (independent databases) and their sizes; * marks the active one
+  vacuum             Compact the database file and report reclaimed space
+  reindex            Rebuild full-text indexes (apply ENGRAM_FTS_PREFIX or ENGRAM_FTS_TOKENIZER to an existing DB, or
+                     repair search after the index drifted) [--in-place to keep the existing index settings]
   export [file]      Export all memories to JSON (default: engram-export.json)
-  import <file>      Import memories from a JSON export file
+                       --project P            Only one project's memories (also with --format md)
+                       --since T --until T    Only memories that happened in [T, T) (RFC 3339 or YYYY-MM-DD)
+                       --type TYPE            Only observations of TYPE (repeatable; leaves prompts out)
+                       --format grouped-json  Nest observations and prompts under each session
+                       --format md            One readable markdown document (default: engram-export.md)
+                       --format md-dir        Write one markdown file per session into a directory
+                       --incremental          Only rows added since the last --incremental export
+  import <file|->    Import memories from a JSON export file (flat or grouped-json), - reads stdin
+                       --replace  Delete all sessions, observations and prompts first (asks; --yes skips)
   setup [agent]      Install agent plugin (interactive or: engram setup opencode)
   sync               Export new memories as compressed chunk to .engram/
                        --import   Import new chunks from .engram/ into local DB
                        --status   Show sync status (local vs remote chunks)
+                       --preview  Summarize chunks pending import without importing
                        --project  Filter export to a specific project
                        --all      Export ALL projects (ignore directory-based filter)
+                       --dry-run  Show what the next export would contain, without writing it
+                       --key      Encrypt new chunks / decrypt encrypted ones (default: $ENGRAM_SYNC_KEY)
   version            Print version
   help               Show this help
 
 Environment:
+  ENGRAM_CONFIG      JSON config file read before env vars and flags (default: ~/.engram/config.json)
   ENGRAM_DATA_DIR    Override data directory (default: ~/.engram)
+  ENGRAM_PROFILE     Profile to use when --profile is not given (default: the database in ENGRAM_DATA_DIR)
+  ENGRAM_SOCKET      Serve a line-based JSON protocol on this Unix socket instead of HTTP
   ENGRAM_PORT        Override HTTP server port (default: 7437)
+  ENGRAM_LOG_LEVEL   Access log level for serve: debug, info, warn or error (default: warn)
+  ENGRAM_ADMIN_TOKEN Require "Authorization: Bearer <token>" for HTTP /export and /import
+  ENGRAM_ADMIN       Set to 1 to allow HTTP /export and /import without a token (default: disabled)
+  ENGRAM_PROJECT     Default project when --project is not given
+  ENGRAM_SYNC_KEY    Passphrase that encrypts sync chunks (default: plaintext)
+  ENGRAM_BUSY_TIMEOUT_MS  SQLite busy_timeout per connection in ms (default: 5000)
+  ENGRAM_MAX_RETRIES      Retries for writes that still hit SQLITE_BUSY (default: 3)
+  ENGRAM_CACHE_SIZE_KB  SQLite page cache size in KB (default: 65536)
+  ENGRAM_MMAP_SIZE_MB   SQLite memory-mapped I/O size in MB (default: 256)
+  ENGRAM_BATCH_WINDOW_MS  Buffer observation writes and commit them in batches (default: off)
+  ENGRAM_BATCH_SIZE       Max observations per batch (default: 100)
+  ENGRAM_DEDUP_WINDOW     Skip saves identical to one in the same session this recent, e.g. 10m, 1h, 1d (default: off)
+  ENGRAM_SEARCH_CACHE     Cache up to this many search results in memory, flushed on any write (default: off)
+  ENGRAM_SEARCH_CACHE_TTL_MS  Max age of a cached search in ms (default: 30000)
+  ENGRAM_AUTO_TITLE_WORDS Words of content used as title for untitled saves, 0 disables (default: 8)
+  ENGRAM_CONTEXT_TEMPLATE Path to a Go text/template used to render context
+  ENGRAM_MAX_CONTEXT_CHARS Cap context output at this many characters, leaving out the least important items (default: off)
+  ENGRAM_AUTHOR           Name recorded on saved memories (default: $USER)
+  ENGRAM_COMPRESS_CONTENT Keep over-long content in full, compressed, instead of truncating it (default: off)
+  ENGRAM_TRACK_ACCESS     Count reads per memory and rank often-used ones higher (default: off)
+  ENGRAM_STRICT_REDACTION Refuse saves that look like they contain secrets (default: redact)
+  ENGRAM_REDACTION_PATTERNS File of extra secret regexes, one per line (# comments)
+  ENGRAM_STRICT_TYPES     Refuse saves whose --type isn't a known type (see engram types)
+  ENGRAM_STOPWORDS        Comma-separated words ignored in search, empty disables (default: common English)
+  ENGRAM_MIN_TERM_LENGTH  Ignore search words shorter than this unless quoted (default: 2)
+  ENGRAM_TYPE_IMPORTANCE  Default importance per type, e.g. decision=3,bugfix=2 (merged over built-ins, empty disables)
+  ENGRAM_RANK_WEIGHTS     Search weights for title,content,tool_name,type,project (default: 10,2,1,1,1; empty = equal)
+  ENGRAM_HASHTAGS         Record #hashtags in saved content as tags (default: off)
+  ENGRAM_EXTRACT_REFS     Record URLs and owner/repo#N issue IDs in saved content as references (default: off)
+  ENGRAM_FTS_PREFIX       Prefix index lengths, e.g. 2,3 — search words match as prefixes (default: off)
+  ENGRAM_FTS_TOKENIZER    FTS5 tokenizer, e.g. "porter unicode61" to match word stems; run engram reindex after changing it (default: unicode61)
+  ENGRAM_TIME_FORMAT      Timestamp display: absolute, relative, or a Go layout (default: absolute)
 
 MCP Configuration (add to your agent's config):
   {
@@ -657,11 +2729,144 @@ MCP Configuration (add to your agent's config):
 `, version)
 }
 
+// defaultProject returns the project to use when no --project flag is given.
+func defaultProject() string {
+	return os.Getenv("ENGRAM_PROJECT")
+}
+
+// detectedProject is defaultProject falling back to the git repo the command
+// runs in (see store.DetectProject), for commands that always work on a
+// project: save, context and sync.
+func detectedProject() string {
+	if project := defaultProject(); project != "" {
+		return project
+	}
+	return store.DetectProject()
+}
+
+// maxImportSize caps how much `engram import -` reads from stdin, so a
+// runaway pipe fails loudly instead of exhausting memory.
+const maxImportSize = 1 << 30 // 1 GB
+
+// readLimited reads all of r, failing if it holds more than limit bytes.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) > limit {
+		return nil, fmt.Errorf("input exceeds %d MB", limit>>20)
+	}
+	return raw, nil
+}
+
+// jsonErrorContext turns a JSON syntax/type error's byte offset into a line
+// and column with a snippet of the offending input, e.g.
+// `line 3, column 14: invalid character '}' ... near "\"id\": }"`.
+func jsonErrorContext(raw []byte, err error) error {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err
+	}
+	if offset > int64(len(raw)) {
+		offset = int64(len(raw))
+	}
+
+	before := raw[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+
+	start := max(int(offset)-20, 0)
+	end := min(int(offset)+20, len(raw))
+	snippet := strings.Join(strings.Fields(string(raw[start:end])), " ")
+	return fmt.Errorf("line %d, column %d: %w (near %q)", line, col, err, snippet)
+}
+
+// writeJSONFile writes v as indented JSON to path.
+func writeJSONFile(path string, v any) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0644)
+}
+
+// printJSON writes v to stdout as indented JSON, for --json.
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fatal(err)
+	}
+}
+
+// orEmpty keeps an empty list as [] instead of null in --json output.
+func orEmpty[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
 func fatal(err error) {
 	fmt.Fprintf(os.Stderr, "engram: %s\n", err)
 	os.Exit(1)
 }
 
+// splitCSV splits a comma-separated list, trimming spaces and dropping empties.
+func splitCSV(v string) []string {
+	var out []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// parseRankWeights reads ENGRAM_RANK_WEIGHTS ("10,2,1,1,1"). Empty weights
+// all columns equally; the count is checked by store.New.
+func parseRankWeights(v string) []float64 {
+	var weights []float64
+	for _, part := range splitCSV(v) {
+		w, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			fatal(fmt.Errorf("ENGRAM_RANK_WEIGHTS: invalid weight %q", part))
+		}
+		weights = append(weights, w)
+	}
+	return weights
+}
+
+// parseTypeImportance reads ENGRAM_TYPE_IMPORTANCE ("decision=3,bugfix=2")
+// on top of store.DefaultTypeImportance. An empty value turns type-based
+// importance off.
+func parseTypeImportance(v string) map[string]int {
+	if strings.TrimSpace(v) == "" {
+		return nil
+	}
+	m := make(map[string]int, len(store.DefaultTypeImportance))
+	for t, n := range store.DefaultTypeImportance {
+		m[t] = n
+	}
+	for _, part := range splitCSV(v) {
+		typ, num, ok := strings.Cut(part, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(num))
+		if !ok || err != nil || n < 0 || n > 5 {
+			fatal(fmt.Errorf("ENGRAM_TYPE_IMPORTANCE: invalid entry %q (expected type=0..5)", part))
+		}
+		m[strings.TrimSpace(typ)] = n
+	}
+	return m
+}
+
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
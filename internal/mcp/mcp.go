@@ -32,18 +32,38 @@ func registerTools(srv *server.MCPServer, s *store.Store) {
 		mcp.NewTool("mem_search",
 			mcp.WithDescription("Search your persistent memory across all sessions. Use this to find past decisions, bugs fixed, patterns used, files changed, or any context from previous coding sessions."),
 			mcp.WithString("query",
-				mcp.Required(),
-				mcp.Description("Search query — natural language or keywords"),
+				mcp.Description("Search query — natural language or keywords. Leave empty to list the most recent memories matching the filters"),
 			),
 			mcp.WithString("type",
-				mcp.Description("Filter by type: tool_use, file_change, command, file_read, search, manual, decision, architecture, bugfix, pattern"),
+				mcp.Description("Filter by type: tool_use, file_change, command, file_read, search, manual, decision, architecture, bugfix, pattern; comma-separate several to match any of them (e.g. 'file_change,command')"),
 			),
 			mcp.WithString("project",
-				mcp.Description("Filter by project name"),
+				mcp.Description("Filter by project name; comma-separate several to search them together (e.g. 'api,web')"),
 			),
 			mcp.WithNumber("limit",
 				mcp.Description("Max results (default: 10, max: 20)"),
 			),
+			mcp.WithNumber("offset",
+				mcp.Description("Skip this many results — use with limit to page through when more are available"),
+			),
+			mcp.WithString("exclude",
+				mcp.Description("Comma-separated terms — drop results containing any of them (e.g. 'test,mock')"),
+			),
+			mcp.WithString("exclude_types",
+				mcp.Description("Comma-separated types to drop (e.g. 'command,file_read')"),
+			),
+			mcp.WithString("tag",
+				mcp.Description("Only return memories with this tag (e.g. 'decision'); comma-separate several to require all of them"),
+			),
+			mcp.WithString("author",
+				mcp.Description("Only return memories recorded by this author (on a shared database)"),
+			),
+			mcp.WithString("session_id",
+				mcp.Description("Only search the session with this ID; follow up with mem_timeline on a result to see the work around it"),
+			),
+			mcp.WithBoolean("fuzzy",
+				mcp.Description("If nothing matches, return memories whose titles are close to the query instead — useful when the query may be misspelled"),
+			),
 		),
 		handleSearch(s),
 	)
@@ -94,10 +114,66 @@ Examples:
 			mcp.WithString("project",
 				mcp.Description("Project name"),
 			),
+			mcp.WithString("status",
+				mcp.Description("Track this memory as a task: pending, in-progress, done (type 'task' or 'todo' defaults to pending)"),
+			),
+			mcp.WithNumber("importance",
+				mcp.Description("Importance from 0 to 5 (omit to use the type's default, e.g. 2 for decisions). Project-less memories with importance >= 3 become global insights shown in every project's context"),
+			),
+			mcp.WithString("tags",
+				mcp.Description("Comma-separated tags (e.g. 'auth,decision')"),
+			),
+			mcp.WithString("references",
+				mcp.Description("Comma-separated URLs or issue IDs this memory relates to (e.g. 'https://github.com/org/repo/issues/42,org/repo#43')"),
+			),
+			mcp.WithString("content_format",
+				mcp.Description("How the content should be displayed: text, json, diff, or code (detected automatically if omitted)"),
+			),
+			mcp.WithNumber("prompt_id",
+				mcp.Description("ID of the user prompt (from mem_save_prompt) that led to this memory; must be in the same session"),
+			),
+			mcp.WithString("occurred_at",
+				mcp.Description("When the event happened, if not now (RFC 3339 or YYYY-MM-DD); timelines are ordered by it"),
+			),
 		),
 		handleSave(s),
 	)
 
+	// ─── mem_task_update ─────────────────────────────────────────────
+	srv.AddTool(
+		mcp.NewTool("mem_task_update",
+			mcp.WithDescription("Update the status of a task observation. Open tasks (pending/in-progress) are shown at the top of mem_context, so mark them done when finished."),
+			mcp.WithNumber("id",
+				mcp.Required(),
+				mcp.Description("The task observation ID"),
+			),
+			mcp.WithString("status",
+				mcp.Required(),
+				mcp.Description("New status: pending, in-progress, or done"),
+			),
+		),
+		handleTaskUpdate(s),
+	)
+
+	// ─── mem_fact ───────────────────────────────────────────────────
+	srv.AddTool(
+		mcp.NewTool("mem_fact",
+			mcp.WithDescription("Record a stable fact about the environment (hosts, URLs, versions, conventions) under a short key. Recording the same key again updates it instead of duplicating, so call this whenever you re-confirm a fact. Known facts are shown in mem_context."),
+			mcp.WithString("key",
+				mcp.Required(),
+				mcp.Description("Short identifier for the fact, e.g. 'staging db host'. Case and punctuation don't matter"),
+			),
+			mcp.WithString("content",
+				mcp.Required(),
+				mcp.Description("The fact itself, e.g. 'staging Postgres is at db-staging.internal:5432'"),
+			),
+			mcp.WithString("project",
+				mcp.Description("Project the fact belongs to (omit for facts that apply everywhere)"),
+			),
+		),
+		handleFact(s),
+	)
+
 	// ─── mem_save_prompt ────────────────────────────────────────────
 	srv.AddTool(
 		mcp.NewTool("mem_save_prompt",
@@ -116,6 +192,48 @@ Examples:
 		handleSavePrompt(s),
 	)
 
+	// ─── mem_search_prompts ─────────────────────────────────────────
+	srv.AddTool(
+		mcp.NewTool("mem_search_prompts",
+			mcp.WithDescription("Search past user prompts. Prompts hold the user's original intent — what they asked for and why — which the saved memories often leave out. Use mem_search for the memories themselves."),
+			mcp.WithString("query",
+				mcp.Required(),
+				mcp.Description("Search query — natural language or keywords"),
+			),
+			mcp.WithString("project",
+				mcp.Description("Filter by project name"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Max results (default: 10)"),
+			),
+		),
+		handleSearchPrompts(s),
+	)
+
+	// ─── mem_recall ──────────────────────────────────────────────────
+	srv.AddTool(
+		mcp.NewTool("mem_recall",
+			mcp.WithDescription("Search memories and past user prompts together, best matches first. One call recalls both what was done and what was asked; use mem_search for type or tag filters."),
+			mcp.WithString("query",
+				mcp.Required(),
+				mcp.Description("Search query — natural language or keywords"),
+			),
+			mcp.WithString("project",
+				mcp.Description("Filter by project name"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Max results (default: 10, max: 20)"),
+			),
+			mcp.WithNumber("offset",
+				mcp.Description("Skip this many results — use with limit to page"),
+			),
+			mcp.WithString("exclude",
+				mcp.Description("Comma-separated terms — drop results containing any of them (e.g. 'test,mock')"),
+			),
+		),
+		handleRecall(s),
+	)
+
 	// ─── mem_context ─────────────────────────────────────────────────
 	srv.AddTool(
 		mcp.NewTool("mem_context",
@@ -168,6 +286,18 @@ Examples:
 		handleGetObservation(s),
 	)
 
+	// ─── mem_get_links ──────────────────────────────────────────────
+	srv.AddTool(
+		mcp.NewTool("mem_get_links",
+			mcp.WithDescription("List the observations linked to a given one (e.g. a bug that caused a fix, a decision that superseded another), in both directions. Call it again on a linked ID to follow a causal chain instead of just chronological neighbors."),
+			mcp.WithNumber("id",
+				mcp.Required(),
+				mcp.Description("The observation ID whose links to list"),
+			),
+		),
+		handleGetLinks(s),
+	)
+
 	// ─── mem_session_summary ────────────────────────────────────────
 	srv.AddTool(
 		mcp.NewTool("mem_session_summary",
@@ -238,13 +368,13 @@ GUIDELINES:
 	// ─── mem_session_end ─────────────────────────────────────────────
 	srv.AddTool(
 		mcp.NewTool("mem_session_end",
-			mcp.WithDescription("Mark a coding session as completed with an optional summary."),
+			mcp.WithDescription("Mark a coding session as completed with an optional summary. The summary shows up in future sessions' context. Fails if the session doesn't exist or was already ended."),
 			mcp.WithString("id",
 				mcp.Required(),
 				mcp.Description("Session identifier to close"),
 			),
 			mcp.WithString("summary",
-				mcp.Description("Summary of what was accomplished"),
+				mcp.Description("Summary of what was accomplished (generated from the session's observations if omitted)"),
 			),
 		),
 		handleSessionEnd(s),
@@ -259,31 +389,65 @@ func handleSearch(s *store.Store) server.ToolHandlerFunc {
 		typ, _ := req.GetArguments()["type"].(string)
 		project, _ := req.GetArguments()["project"].(string)
 		limit := intArg(req, "limit", 10)
+		offset := intArg(req, "offset", 0)
+		exclude, _ := req.GetArguments()["exclude"].(string)
+		excludeTypes, _ := req.GetArguments()["exclude_types"].(string)
+		tag, _ := req.GetArguments()["tag"].(string)
+		fuzzy, _ := req.GetArguments()["fuzzy"].(bool)
+		author, _ := req.GetArguments()["author"].(string)
+		sessionID, _ := req.GetArguments()["session_id"].(string)
 
-		results, err := s.Search(query, store.SearchOptions{
-			Type:    typ,
-			Project: project,
-			Limit:   limit,
+		resp, err := s.SearchPage(query, store.SearchOptions{
+			Types:        splitList(typ),
+			Projects:     splitList(project),
+			Limit:        limit,
+			Offset:       offset,
+			ExcludeTerms: splitList(exclude),
+			ExcludeTypes: splitList(excludeTypes),
+			Tags:         splitList(tag),
+			Fuzzy:        fuzzy,
+			Author:       author,
+			SessionID:    sessionID,
 		})
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Search error: %s. Try simpler keywords.", err)), nil
 		}
+		results := resp.Results
 
 		if len(results) == 0 {
+			if resp.Fallback {
+				return mcp.NewToolResultText("No memories match those filters."), nil
+			}
 			return mcp.NewToolResultText(fmt.Sprintf("No memories found for: %q", query)), nil
 		}
 
 		var b strings.Builder
-		fmt.Fprintf(&b, "Found %d memories:\n\n", len(results))
+		if resp.HasMore {
+			fmt.Fprintf(&b, "Showing %d memories, more available (narrow with type/project, refine the query, or page with offset=%d):\n\n", resp.Returned, offset+resp.Returned)
+		} else {
+			fmt.Fprintf(&b, "Found %d memories:\n\n", len(results))
+		}
+		if resp.Fallback {
+			b.WriteString("(No query given: these are the most recent memories matching the filters, not search matches.)\n\n")
+		}
+		if resp.PrefixMatch {
+			b.WriteString("(Prefix matching: query words also matched longer words starting with them. Quote a word for an exact match.)\n\n")
+		}
+		if resp.Fuzzy {
+			b.WriteString("(No exact matches: these memories have titles similar to the query, which may be misspelled.)\n\n")
+		}
 		for i, r := range results {
 			project := ""
 			if r.Project != nil {
 				project = fmt.Sprintf(" | project: %s", *r.Project)
 			}
+			if r.Author != nil {
+				project += " | by " + *r.Author
+			}
 			fmt.Fprintf(&b, "[%d] #%d (%s) — %s\n    %s\n    %s%s\n\n",
 				i+1, r.ID, r.Type, r.Title,
 				truncate(r.Content, 300),
-				r.CreatedAt, project)
+				r.OccurredAt, project)
 		}
 
 		return mcp.NewToolResultText(b.String()), nil
@@ -297,6 +461,17 @@ func handleSave(s *store.Store) server.ToolHandlerFunc {
 		typ, _ := req.GetArguments()["type"].(string)
 		sessionID, _ := req.GetArguments()["session_id"].(string)
 		project, _ := req.GetArguments()["project"].(string)
+		status, _ := req.GetArguments()["status"].(string)
+		var importance *int
+		if v, ok := req.GetArguments()["importance"].(float64); ok {
+			n := int(v)
+			importance = &n
+		}
+		format, _ := req.GetArguments()["content_format"].(string)
+		tags, _ := req.GetArguments()["tags"].(string)
+		refs, _ := req.GetArguments()["references"].(string)
+		promptID := int64(intArg(req, "prompt_id", 0))
+		occurredAt, _ := req.GetArguments()["occurred_at"].(string)
 
 		if typ == "" {
 			typ = "manual"
@@ -308,18 +483,66 @@ func handleSave(s *store.Store) server.ToolHandlerFunc {
 		// Ensure the session exists
 		s.CreateSession(sessionID, project, "")
 
-		_, err := s.AddObservation(store.AddObservationParams{
-			SessionID: sessionID,
-			Type:      typ,
-			Title:     title,
-			Content:   content,
-			Project:   project,
+		res, err := s.SaveObservation(store.AddObservationParams{
+			SessionID:     sessionID,
+			Type:          typ,
+			Title:         title,
+			Content:       content,
+			Project:       project,
+			Status:        status,
+			Importance:    importance,
+			ContentFormat: format,
+			Tags:          splitList(tags),
+			References:    splitList(refs),
+			PromptID:      promptID,
+			OccurredAt:    occurredAt,
 		})
 		if err != nil {
 			return mcp.NewToolResultError("Failed to save: " + err.Error()), nil
 		}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Memory saved: %q (%s)", title, typ)), nil
+		msg := fmt.Sprintf("Memory saved: %q (%s)", res.Title, typ)
+		if res.Duplicate {
+			msg = fmt.Sprintf("Already saved: %q (%s) is identical to #%d, saved recently — nothing new written", res.Title, typ, res.ID)
+		}
+		if res.RedactionCount > 0 {
+			msg += fmt.Sprintf("\nWarning: %d likely secret(s) were redacted. Do not save credentials to memory.", res.RedactionCount)
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+func handleTaskUpdate(s *store.Store) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id := int64(intArg(req, "id", 0))
+		if id == 0 {
+			return mcp.NewToolResultError("id is required"), nil
+		}
+		status, _ := req.GetArguments()["status"].(string)
+
+		if err := s.SetStatus(id, status); err != nil {
+			return mcp.NewToolResultError("Failed to update task: " + err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Task #%d marked %s", id, status)), nil
+	}
+}
+
+func handleFact(s *store.Store) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		key, _ := req.GetArguments()["key"].(string)
+		content, _ := req.GetArguments()["content"].(string)
+		project, _ := req.GetArguments()["project"].(string)
+
+		f, err := s.RecordFact(key, content, project)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to record fact: " + err.Error()), nil
+		}
+
+		if f.SeenCount > 1 {
+			return mcp.NewToolResultText(fmt.Sprintf("Fact %q updated (seen %d times, first %s)", f.Key, f.SeenCount, f.FirstSeen)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Fact %q recorded", f.Key)), nil
 	}
 }
 
@@ -336,7 +559,7 @@ func handleSavePrompt(s *store.Store) server.ToolHandlerFunc {
 		// Ensure the session exists
 		s.CreateSession(sessionID, project, "")
 
-		_, err := s.AddPrompt(store.AddPromptParams{
+		id, err := s.AddPrompt(store.AddPromptParams{
 			SessionID: sessionID,
 			Content:   content,
 			Project:   project,
@@ -345,7 +568,96 @@ func handleSavePrompt(s *store.Store) server.ToolHandlerFunc {
 			return mcp.NewToolResultError("Failed to save prompt: " + err.Error()), nil
 		}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Prompt saved: %q", truncate(content, 80))), nil
+		return mcp.NewToolResultText(fmt.Sprintf("Prompt #%d saved: %q\nPass prompt_id=%d to mem_save to link the memories it leads to.", id, truncate(content, 80), id)), nil
+	}
+}
+
+func handleSearchPrompts(s *store.Store) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query, _ := req.GetArguments()["query"].(string)
+		project, _ := req.GetArguments()["project"].(string)
+		limit := intArg(req, "limit", 10)
+
+		if strings.TrimSpace(query) == "" {
+			return mcp.NewToolResultError("query is required"), nil
+		}
+
+		prompts, err := s.SearchPrompts(query, project, limit)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Search error: %s. Try simpler keywords.", err)), nil
+		}
+		if len(prompts) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No prompts found for: %q", query)), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Found %d prompts:\n\n", len(prompts))
+		for i, p := range prompts {
+			project := ""
+			if p.Project != "" {
+				project = fmt.Sprintf(" | project: %s", p.Project)
+			}
+			fmt.Fprintf(&b, "[%d] prompt #%d — session %s\n    %s\n    %s%s\n\n",
+				i+1, p.ID, p.SessionID,
+				truncate(p.Content, 300),
+				p.CreatedAt, project)
+		}
+
+		return mcp.NewToolResultText(b.String()), nil
+	}
+}
+
+func handleRecall(s *store.Store) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query, _ := req.GetArguments()["query"].(string)
+		project, _ := req.GetArguments()["project"].(string)
+		limit := intArg(req, "limit", 10)
+		offset := intArg(req, "offset", 0)
+		exclude, _ := req.GetArguments()["exclude"].(string)
+
+		if strings.TrimSpace(query) == "" {
+			return mcp.NewToolResultError("query is required"), nil
+		}
+
+		results, err := s.SearchAll(query, store.SearchOptions{
+			Project:      project,
+			Limit:        limit,
+			Offset:       offset,
+			ExcludeTerms: splitList(exclude),
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Search error: %s. Try simpler keywords.", err)), nil
+		}
+		if len(results) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No memories or prompts found for: %q", query)), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Found %d results:\n\n", len(results))
+		for i, r := range results {
+			if p := r.Prompt; p != nil {
+				project := ""
+				if p.Project != "" {
+					project = fmt.Sprintf(" | project: %s", p.Project)
+				}
+				fmt.Fprintf(&b, "[%d] prompt #%d — session %s\n    %s\n    %s%s\n\n",
+					offset+i+1, p.ID, p.SessionID,
+					truncate(p.Content, 300),
+					p.CreatedAt, project)
+				continue
+			}
+			o := r.Observation
+			project := ""
+			if o.Project != nil {
+				project = fmt.Sprintf(" | project: %s", *o.Project)
+			}
+			fmt.Fprintf(&b, "[%d] #%d (%s) — %s\n    %s\n    %s%s\n\n",
+				offset+i+1, o.ID, o.Type, o.Title,
+				truncate(o.Content, 300),
+				o.OccurredAt, project)
+		}
+
+		return mcp.NewToolResultText(b.String()), nil
 	}
 }
 
@@ -362,7 +674,12 @@ func handleContext(s *store.Store) server.ToolHandlerFunc {
 			return mcp.NewToolResultText("No previous session memories found."), nil
 		}
 
-		stats, _ := s.Stats()
+		// The stats footer is extra; the context is still worth returning
+		// without it
+		stats, err := s.Stats()
+		if err != nil {
+			return mcp.NewToolResultText(context), nil
+		}
 		var projects string
 		if len(stats.Projects) > 0 {
 			projects = strings.Join(stats.Projects, ", ")
@@ -436,7 +753,7 @@ func handleTimeline(s *store.Store) server.ToolHandlerFunc {
 		// Focus observation (highlighted)
 		fmt.Fprintf(&b, ">>> #%d [%s] %s <<<\n", result.Focus.ID, result.Focus.Type, result.Focus.Title)
 		fmt.Fprintf(&b, "    %s\n", truncate(result.Focus.Content, 500))
-		fmt.Fprintf(&b, "    %s\n\n", result.Focus.CreatedAt)
+		fmt.Fprintf(&b, "    %s\n\n", result.Focus.OccurredAt)
 
 		// After entries
 		if len(result.After) > 0 {
@@ -446,10 +763,49 @@ func handleTimeline(s *store.Store) server.ToolHandlerFunc {
 			}
 		}
 
+		if len(result.Links) > 0 {
+			b.WriteString("\n─── Links ───\n")
+			writeLinks(&b, result.Focus.ID, result.Links)
+		}
+
 		return mcp.NewToolResultText(b.String()), nil
 	}
 }
 
+func handleGetLinks(s *store.Store) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id := int64(intArg(req, "id", 0))
+		if id == 0 {
+			return mcp.NewToolResultError("id is required"), nil
+		}
+
+		links, err := s.GetLinks(id)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(links) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("Observation #%d has no links.", id)), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Links for observation #%d:\n", id)
+		writeLinks(&b, id, links)
+		return mcp.NewToolResultText(b.String()), nil
+	}
+}
+
+// writeLinks lists links from the point of view of observation id, one per
+// line, with arrows pointing the way the relation reads.
+func writeLinks(b *strings.Builder, id int64, links []store.Link) {
+	for _, l := range links {
+		if l.FromID == id {
+			fmt.Fprintf(b, "  this —%s→ #%d %s\n", l.Relation, l.ToID, l.ToTitle)
+		} else {
+			fmt.Fprintf(b, "  #%d %s —%s→ this\n", l.FromID, l.FromTitle, l.Relation)
+		}
+	}
+}
+
 func handleGetObservation(s *store.Store) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		id := int64(intArg(req, "id", 0))
@@ -470,13 +826,26 @@ func handleGetObservation(s *store.Store) server.ToolHandlerFunc {
 		if obs.ToolName != nil {
 			toolName = fmt.Sprintf("\nTool: %s", *obs.ToolName)
 		}
+		tags := ""
+		if len(obs.Tags) > 0 {
+			tags = "\nTags: " + strings.Join(obs.Tags, ", ")
+		}
+		if len(obs.References) > 0 {
+			tags += "\nReferences:\n  " + strings.Join(obs.References, "\n  ")
+		}
+		if obs.PromptID != nil {
+			tags += fmt.Sprintf("\nPrompt: #%d", *obs.PromptID)
+		}
 
-		result := fmt.Sprintf("#%d [%s] %s\n%s\nSession: %s%s%s\nCreated: %s",
+		result := fmt.Sprintf("#%d [%s] %s\n%s\nSession: %s%s%s%s\nCreated: %s",
 			obs.ID, obs.Type, obs.Title,
 			obs.Content,
-			obs.SessionID, project, toolName,
+			obs.SessionID, project, toolName, tags,
 			obs.CreatedAt,
 		)
+		if obs.OccurredAt != obs.CreatedAt {
+			result += "\nOccurred: " + obs.OccurredAt
+		}
 
 		return mcp.NewToolResultText(result), nil
 	}
@@ -547,6 +916,17 @@ func intArg(req mcp.CallToolRequest, key string, defaultVal int) int {
 	return int(v)
 }
 
+// splitList turns a comma-separated argument into its non-empty parts.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alanbuscaglia/engram/internal/store"
+)
+
+// ─── Access Log & Metrics ────────────────────────────────────────────────────
+//
+// Every HTTP request is logged (method, path, status, duration) through the
+// server's slog.Logger: 5xx at error, 4xx at warn, the rest at info. The
+// default logger only shows warnings and up, so a local server stays quiet;
+// `engram serve` picks the level from ENGRAM_LOG_LEVEL.
+//
+// GET /metrics serves counters in the Prometheus text format. There's no
+// client library: the handful of series is written by hand.
+
+// searchBuckets are the upper bounds, in seconds, of the search latency
+// histogram.
+var searchBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+type metrics struct {
+	mu sync.Mutex
+
+	requests          map[requestKey]uint64
+	observationsAdded uint64
+	searches          uint64
+	searchCounts      []uint64 // per searchBuckets entry, not cumulative
+	searchSum         float64
+}
+
+type requestKey struct {
+	method string
+	route  string
+	status int
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:     make(map[requestKey]uint64),
+		searchCounts: make([]uint64, len(searchBuckets)),
+	}
+}
+
+func (m *metrics) request(method, route string, status int) {
+	m.mu.Lock()
+	m.requests[requestKey{method, route, status}]++
+	m.mu.Unlock()
+}
+
+func (m *metrics) observationAdded() {
+	m.mu.Lock()
+	m.observationsAdded++
+	m.mu.Unlock()
+}
+
+func (m *metrics) search(d time.Duration) {
+	seconds := d.Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.searches++
+	m.searchSum += seconds
+	for i, le := range searchBuckets {
+		if seconds <= le {
+			m.searchCounts[i]++
+			break
+		}
+	}
+}
+
+// write renders every series in the Prometheus text exposition format.
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP engram_http_requests_total HTTP requests served, by method, route and status.")
+	fmt.Fprintln(w, "# TYPE engram_http_requests_total counter")
+	keys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b requestKey) int {
+		return cmp.Or(cmp.Compare(a.route, b.route), cmp.Compare(a.method, b.method), cmp.Compare(a.status, b.status))
+	})
+	for _, k := range keys {
+		fmt.Fprintf(w, "engram_http_requests_total{method=%q,route=%q,status=\"%d\"} %d\n", k.method, k.route, k.status, m.requests[k])
+	}
+
+	fmt.Fprintln(w, "# HELP engram_observations_added_total Observations saved through this server.")
+	fmt.Fprintln(w, "# TYPE engram_observations_added_total counter")
+	fmt.Fprintf(w, "engram_observations_added_total %d\n", m.observationsAdded)
+
+	fmt.Fprintln(w, "# HELP engram_searches_total Searches run through this server.")
+	fmt.Fprintln(w, "# TYPE engram_searches_total counter")
+	fmt.Fprintf(w, "engram_searches_total %d\n", m.searches)
+
+	fmt.Fprintln(w, "# HELP engram_search_duration_seconds Search latency.")
+	fmt.Fprintln(w, "# TYPE engram_search_duration_seconds histogram")
+	var cumulative uint64
+	for i, le := range searchBuckets {
+		cumulative += m.searchCounts[i]
+		fmt.Fprintf(w, "engram_search_duration_seconds_bucket{le=\"%s\"} %d\n", strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(w, "engram_search_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.searches)
+	fmt.Fprintf(w, "engram_search_duration_seconds_sum %s\n", strconv.FormatFloat(m.searchSum, 'g', -1, 64))
+	fmt.Fprintf(w, "engram_search_duration_seconds_count %d\n", m.searches)
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.metrics.write(w)
+}
+
+// searchPage runs a search and records its latency.
+func (s *Server) searchPage(query string, opts store.SearchOptions) (*store.SearchResponse, error) {
+	start := time.Now()
+	resp, err := s.store.SearchPage(query, opts)
+	s.metrics.search(time.Since(start))
+	return resp, err
+}
+
+// statusRecorder remembers the status code a handler wrote. It passes
+// Flush through so the observation stream keeps working behind it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// observe wraps next with the access log and request counter.
+func (s *Server) observe(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		status := cmp.Or(rec.status, http.StatusOK)
+
+		// r.Pattern is the matched route ("GET /observations/{id}"), which
+		// keeps the counter's label set small; unmatched paths share one
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		s.metrics.request(r.Method, route, status)
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		s.logger.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
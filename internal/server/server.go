@@ -5,25 +5,55 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alanbuscaglia/engram/internal/store"
 )
 
 type Server struct {
-	store *store.Store
-	mux   *http.ServeMux
-	port  int
+	store      *store.Store
+	mux        *http.ServeMux
+	port       int
+	adminToken string
+	adminOpen  bool
+	version    string
+	started    time.Time
+	hub        *hub
+	metrics    *metrics
+	logger     *slog.Logger
 }
 
 func New(s *store.Store, port int) *Server {
-	srv := &Server{store: s, port: port}
+	srv := &Server{
+		store:   s,
+		port:    port,
+		version: "0.1.0",
+		started: time.Now(),
+		hub:     newHub(),
+		metrics: newMetrics(),
+		logger:  slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+	}
+	s.On(store.EventObservationCreated, func(payload any) {
+		srv.metrics.observationAdded()
+		if o, ok := payload.(*store.Observation); ok {
+			srv.hub.publish(*o)
+		}
+	})
 	srv.mux = http.NewServeMux()
 	srv.routes()
 	return srv
@@ -36,24 +66,55 @@ func (s *Server) Start() error {
 		return fmt.Errorf("engram server: listen %s: %w", addr, err)
 	}
 	log.Printf("[engram] HTTP server listening on %s", addr)
-	return http.Serve(ln, s.mux)
+	return http.Serve(ln, s.Handler())
 }
 
 func (s *Server) Handler() http.Handler {
-	return s.mux
+	return s.observe(s.mux)
+}
+
+// SetAdminToken protects the admin endpoints (export/import) with a bearer
+// token. Without a token they're disabled unless SetAdminOpen allows them.
+func (s *Server) SetAdminToken(token string) {
+	s.adminToken = token
+}
+
+// SetAdminOpen enables the admin endpoints without a token, for a server
+// only trusted local processes can reach.
+func (s *Server) SetAdminOpen(open bool) {
+	s.adminOpen = open
+}
+
+// SetLogger replaces the access logger, which by default writes warnings
+// and errors to stderr.
+func (s *Server) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// SetVersion sets the version reported by /health and /healthz.
+func (s *Server) SetVersion(version string) {
+	s.version = version
 }
 
 func (s *Server) routes() {
 	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.HandleFunc("GET /healthz", s.handleHealthz)
+	s.mux.HandleFunc("GET /readyz", s.handleReadyz)
+	s.mux.HandleFunc("GET /metrics", s.handleMetrics)
 
 	// Sessions
 	s.mux.HandleFunc("POST /sessions", s.handleCreateSession)
 	s.mux.HandleFunc("POST /sessions/{id}/end", s.handleEndSession)
 	s.mux.HandleFunc("GET /sessions/recent", s.handleRecentSessions)
+	s.mux.HandleFunc("GET /sessions/{id}/timeline", s.handleSessionTimeline)
 
 	// Observations
 	s.mux.HandleFunc("POST /observations", s.handleAddObservation)
+	s.mux.HandleFunc("POST /observations/bulk", s.handleAddObservations)
+	s.mux.HandleFunc("GET /observations", s.handleListObservations)
 	s.mux.HandleFunc("GET /observations/recent", s.handleRecentObservations)
+	s.mux.HandleFunc("GET /observations/stream", s.handleObservationStream)
+	s.mux.HandleFunc("POST /observations/{id}/references", s.handleAddReference)
 
 	// Search
 	s.mux.HandleFunc("GET /search", s.handleSearch)
@@ -61,18 +122,21 @@ func (s *Server) routes() {
 	// Timeline
 	s.mux.HandleFunc("GET /timeline", s.handleTimeline)
 	s.mux.HandleFunc("GET /observations/{id}", s.handleGetObservation)
+	s.mux.HandleFunc("GET /observations/{id}/timeline", s.handleObservationTimeline)
+	s.mux.HandleFunc("PATCH /observations/{id}", s.handleUpdateObservation)
 
 	// Prompts
 	s.mux.HandleFunc("POST /prompts", s.handleAddPrompt)
 	s.mux.HandleFunc("GET /prompts/recent", s.handleRecentPrompts)
 	s.mux.HandleFunc("GET /prompts/search", s.handleSearchPrompts)
+	s.mux.HandleFunc("GET /prompts/{id}/observations", s.handlePromptObservations)
 
 	// Context
 	s.mux.HandleFunc("GET /context", s.handleContext)
 
 	// Export / Import
-	s.mux.HandleFunc("GET /export", s.handleExport)
-	s.mux.HandleFunc("POST /import", s.handleImport)
+	s.mux.HandleFunc("GET /export", s.requireAdmin(s.handleExport))
+	s.mux.HandleFunc("POST /import", s.requireAdmin(s.handleImport))
 
 	// Stats
 	s.mux.HandleFunc("GET /stats", s.handleStats)
@@ -84,10 +148,52 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, map[string]any{
 		"status":  "ok",
 		"service": "engram",
-		"version": "0.1.0",
+		"version": s.version,
 	})
 }
 
+// healthCheckTimeout bounds the database ping behind /healthz and /readyz,
+// so a wedged database fails the probe instead of hanging it.
+const healthCheckTimeout = 2 * time.Second
+
+// handleHealthz is the liveness probe: 200 while the database answers, 503
+// when it doesn't. The body says what's deployed and for how long.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	uptime := time.Since(s.started)
+	body := map[string]any{
+		"status":         "ok",
+		"service":        "engram",
+		"version":        s.version,
+		"uptime":         uptime.Truncate(time.Second).String(),
+		"uptime_seconds": int64(uptime.Seconds()),
+		"database":       "ok",
+	}
+	status := http.StatusOK
+	if err := s.store.Ping(ctx); err != nil {
+		body["status"] = "unavailable"
+		body["database"] = err.Error()
+		status = http.StatusServiceUnavailable
+	}
+	jsonResponse(w, status, body)
+}
+
+// handleReadyz is the readiness probe. The store is migrated before the
+// server is built, so the server is ready to take traffic whenever the
+// database answers.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	if err := s.store.Ping(ctx); err != nil {
+		jsonError(w, http.StatusServiceUnavailable, "not ready: "+err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
 func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 	var body struct {
 		ID        string `json:"id"`
@@ -119,7 +225,16 @@ func (s *Server) handleEndSession(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewDecoder(r.Body).Decode(&body)
 
-	if err := s.store.EndSession(id, body.Summary); err != nil {
+	err := s.store.EndSession(id, body.Summary)
+	if errors.Is(err, store.ErrSessionNotFound) {
+		jsonError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, store.ErrSessionEnded) {
+		jsonError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -140,31 +255,145 @@ func (s *Server) handleRecentSessions(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, sessions)
 }
 
+func (s *Server) handleSessionTimeline(w http.ResponseWriter, r *http.Request) {
+	result, err := s.store.SessionTimeline(r.PathValue("id"))
+	if errors.Is(err, store.ErrSessionNotFound) {
+		jsonError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, result)
+}
+
+// handleAddObservation saves one observation for clients that don't speak
+// MCP, such as CI jobs and webhooks. The session is created if it doesn't
+// exist yet, so a job can pick any ID for its run.
 func (s *Server) handleAddObservation(w http.ResponseWriter, r *http.Request) {
 	var body store.AddObservationParams
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		jsonError(w, http.StatusBadRequest, "invalid json: "+err.Error())
 		return
 	}
-	if body.SessionID == "" || body.Title == "" || body.Content == "" {
-		jsonError(w, http.StatusBadRequest, "session_id, title, and content are required")
+	if missing := missingFields(body); len(missing) > 0 {
+		jsonError(w, http.StatusBadRequest, "missing required fields: "+strings.Join(missing, ", "))
 		return
 	}
 
-	id, err := s.store.AddObservation(body)
+	// Validate before creating the session, so a rejected body leaves none
+	if err := s.store.ValidateObservation(body); err != nil {
+		saveError(w, err)
+		return
+	}
+	if err := s.store.CreateSession(body.SessionID, body.Project, ""); err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	res, err := s.store.SaveObservation(body)
 	if err != nil {
+		saveError(w, err)
+		return
+	}
+
+	code, status := http.StatusCreated, "saved"
+	if res.Duplicate {
+		code, status = http.StatusOK, "duplicate"
+	}
+	jsonResponse(w, code, map[string]any{
+		"id":              res.ID,
+		"title":           res.Title,
+		"status":          status,
+		"redaction_count": res.RedactionCount,
+	})
+}
+
+// missingFields lists the required fields p leaves blank.
+func missingFields(p store.AddObservationParams) []string {
+	var missing []string
+	for _, f := range []struct{ name, value string }{
+		{"session_id", p.SessionID},
+		{"title", p.Title},
+		{"content", p.Content},
+	} {
+		if strings.TrimSpace(f.value) == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	return missing
+}
+
+// saveError reports a failed save: 400 for an invalid observation, 422 for
+// one the store's policy refuses, 500 for anything else.
+func saveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrInvalidObservation):
+		jsonError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, store.ErrSecretDetected) || errors.Is(err, store.ErrUnknownType):
+		jsonError(w, http.StatusUnprocessableEntity, err.Error())
+	default:
 		jsonError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// handleAddObservations saves a JSON array of POST /observations bodies in
+// one transaction. Either all of them are saved or none.
+func (s *Server) handleAddObservations(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 50<<20)
+	var body []store.AddObservationParams
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid json: "+err.Error())
 		return
 	}
+	// The same checks as POST /observations, all before any session is
+	// created, so a rejected batch leaves nothing behind
+	for i, p := range body {
+		if missing := missingFields(p); len(missing) > 0 {
+			jsonError(w, http.StatusBadRequest, fmt.Sprintf("observation %d: missing required fields: %s", i, strings.Join(missing, ", ")))
+			return
+		}
+		if err := s.store.ValidateObservation(p); err != nil {
+			saveError(w, fmt.Errorf("observation %d: %w", i, err))
+			return
+		}
+	}
+	created := make(map[string]bool)
+	for _, p := range body {
+		if created[p.SessionID] {
+			continue
+		}
+		if err := s.store.CreateSession(p.SessionID, p.Project, ""); err != nil {
+			jsonError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		created[p.SessionID] = true
+	}
+
+	ids, err := s.store.AddObservations(body)
+	if err != nil {
+		saveError(w, err)
+		return
+	}
+	if ids == nil {
+		ids = []int64{}
+	}
 
-	jsonResponse(w, http.StatusCreated, map[string]any{"id": id, "status": "saved"})
+	jsonResponse(w, http.StatusCreated, map[string]any{"ids": ids, "count": len(ids)})
 }
 
 func (s *Server) handleRecentObservations(w http.ResponseWriter, r *http.Request) {
 	project := r.URL.Query().Get("project")
 	limit := queryInt(r, "limit", 20)
 
-	obs, err := s.store.RecentObservations(project, limit)
+	order := store.ObservationOrder(r.URL.Query().Get("order"))
+	if order != "" && order != store.OrderCreated && order != store.OrderUpdated {
+		jsonError(w, http.StatusBadRequest, "order must be created or updated")
+		return
+	}
+
+	obs, err := s.store.RecentObservations(project, limit, queryInt(r, "offset", 0), order)
 	if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -173,24 +402,86 @@ func (s *Server) handleRecentObservations(w http.ResponseWriter, r *http.Request
 	jsonResponse(w, http.StatusOK, obs)
 }
 
-func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		jsonError(w, http.StatusBadRequest, "q parameter is required")
+// handleListObservations pages through observations by keyset: pass the
+// previous response's next_cursor as after_id. next_cursor is null on the
+// last page.
+func (s *Server) handleListObservations(w http.ResponseWriter, r *http.Request) {
+	var afterID int64
+	if v := r.URL.Query().Get("after_id"); v != "" {
+		var err error
+		if afterID, err = strconv.ParseInt(v, 10, 64); err != nil || afterID < 0 {
+			jsonError(w, http.StatusBadRequest, "invalid after_id")
+			return
+		}
+	}
+	limit := queryInt(r, "limit", 20)
+	if limit <= 0 {
+		limit = 20
+	}
+
+	// One extra row tells us whether another page exists
+	obs, err := s.store.ObservationsAfter(afterID, r.URL.Query().Get("project"), limit+1)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	results, err := s.store.Search(query, store.SearchOptions{
-		Type:    r.URL.Query().Get("type"),
-		Project: r.URL.Query().Get("project"),
-		Limit:   queryInt(r, "limit", 10),
+	var next *int64
+	if len(obs) > limit {
+		obs = obs[:limit]
+		next = &obs[limit-1].ID
+	}
+	if obs == nil {
+		obs = []store.Observation{}
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]any{
+		"observations": obs,
+		"next_cursor":  next,
 	})
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	// An empty or missing q lists recent observations matching the filters.
+	query := r.URL.Query().Get("q")
+
+	opts := store.SearchOptions{
+		Types:           r.URL.Query()["type"],
+		Projects:        r.URL.Query()["project"],
+		Author:          r.URL.Query().Get("author"),
+		SessionID:       r.URL.Query().Get("session_id"),
+		Limit:           queryInt(r, "limit", 10),
+		Offset:          queryInt(r, "offset", 0),
+		ExcludeTerms:    r.URL.Query()["exclude"],
+		ExcludeTypes:    r.URL.Query()["not_type"],
+		Tags:            r.URL.Query()["tag"],
+		Explain:         r.URL.Query().Get("explain") != "",
+		IncludeArchived: r.URL.Query().Get("archived") != "",
+		Raw:             r.URL.Query().Get("raw") != "",
+		Fuzzy:           r.URL.Query().Get("fuzzy") != "",
+		RecencyWeight:   queryFloat(r, "recency", 0),
+	}
+	resp, err := s.searchPage(query, opts)
 	if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-
-	jsonResponse(w, http.StatusOK, results)
+	// FTS matched nothing when the fuzzy fallback ran, so count its matches
+	total := resp.FuzzyTotal
+	if !resp.Fuzzy {
+		if total, err = s.store.SearchCount(query, opts); err != nil {
+			jsonError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	// The body stays a plain array; limit feedback travels in a header.
+	w.Header().Set("X-Has-More", strconv.FormatBool(resp.HasMore))
+	w.Header().Set("X-Prefix-Match", strconv.FormatBool(resp.PrefixMatch))
+	w.Header().Set("X-Search-Fallback", strconv.FormatBool(resp.Fallback))
+	w.Header().Set("X-Fuzzy-Match", strconv.FormatBool(resp.Fuzzy))
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	jsonResponse(w, http.StatusOK, orEmpty(resp.Results))
 }
 
 func (s *Server) handleGetObservation(w http.ResponseWriter, r *http.Request) {
@@ -202,14 +493,85 @@ func (s *Server) handleGetObservation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	obs, err := s.store.GetObservation(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonError(w, http.StatusNotFound, "observation not found")
+		return
+	}
 	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, obs)
+}
+
+// handleUpdateObservation edits an observation; fields left out of the body
+// keep their values.
+func (s *Server) handleUpdateObservation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid observation id")
+		return
+	}
+	var body store.UpdateObservationParams
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+		return
+	}
+	if body.Type == nil && body.Title == nil && body.Content == nil && body.Importance == nil {
+		jsonError(w, http.StatusBadRequest, "one of type, title, content or importance is required")
+		return
+	}
+
+	obs, err := s.store.UpdateObservation(id, body)
+	if errors.Is(err, sql.ErrNoRows) {
 		jsonError(w, http.StatusNotFound, "observation not found")
 		return
 	}
+	if errors.Is(err, store.ErrSecretDetected) || errors.Is(err, store.ErrUnknownType) {
+		jsonError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	jsonResponse(w, http.StatusOK, obs)
 }
 
+func (s *Server) handleAddReference(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid observation id")
+		return
+	}
+
+	var body struct {
+		Ref string `json:"ref"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+		return
+	}
+	if body.Ref == "" {
+		jsonError(w, http.StatusBadRequest, "ref is required")
+		return
+	}
+
+	if err := s.store.AddReference(id, body.Ref); err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	refs, err := s.store.ObservationReferences(id)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]any{"id": id, "references": refs})
+}
+
 func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Query().Get("observation_id")
 	if idStr == "" {
@@ -222,16 +584,39 @@ func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
 		jsonError(w, http.StatusBadRequest, "invalid observation_id")
 		return
 	}
+	s.writeTimeline(w, r, id)
+}
+
+// handleObservationTimeline is GET /timeline with the observation in the
+// path, for UIs linking from a search hit to the memories around it.
+func (s *Server) handleObservationTimeline(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid observation id")
+		return
+	}
+	s.writeTimeline(w, r, id)
+}
 
-	before := queryInt(r, "before", 5)
-	after := queryInt(r, "after", 5)
+// maxTimelineWindow caps before and after on the timeline endpoints, so one
+// request can't pull a whole session.
+const maxTimelineWindow = 50
+
+func (s *Server) writeTimeline(w http.ResponseWriter, r *http.Request, id int64) {
+	before := min(queryInt(r, "before", 5), maxTimelineWindow)
+	after := min(queryInt(r, "after", 5), maxTimelineWindow)
 
 	result, err := s.store.Timeline(id, before, after)
+	if errors.Is(err, sql.ErrNoRows) {
+		jsonError(w, http.StatusNotFound, "observation not found")
+		return
+	}
 	if err != nil {
-		jsonError(w, http.StatusNotFound, err.Error())
+		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	result.Before, result.After = orEmpty(result.Before), orEmpty(result.After)
 	jsonResponse(w, http.StatusOK, result)
 }
 
@@ -270,6 +655,26 @@ func (s *Server) handleRecentPrompts(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, prompts)
 }
 
+func (s *Server) handlePromptObservations(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid prompt id")
+		return
+	}
+
+	obs, err := s.store.ObservationsForPrompt(id)
+	if errors.Is(err, store.ErrPromptNotFound) {
+		jsonError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, obs)
+}
+
 func (s *Server) handleSearchPrompts(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	if query == "" {
@@ -292,35 +697,92 @@ func (s *Server) handleSearchPrompts(w http.ResponseWriter, r *http.Request) {
 
 // ─── Export / Import ─────────────────────────────────────────────────────────
 
+// handleExport streams the export, so memory stays flat however large the
+// database is. grouped-json is the exception: grouping needs every row.
 func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
-	data, err := s.store.Export()
-	if err != nil {
+	format := r.URL.Query().Get("format")
+
+	// grouped-json is the CLI's name for it; grouped is kept for older clients
+	if format == "grouped-json" || format == "grouped" {
+		data, err := s.store.Export()
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Disposition", "attachment; filename=engram-export.json")
+		jsonResponse(w, http.StatusOK, data.Grouped())
+		return
+	}
+
+	sw := &sentWriter{ResponseWriter: w}
+	var err error
+	if format == "ndjson" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", "attachment; filename=engram-export.ndjson")
+		_, err = s.store.ExportNDJSONTo(sw)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", "attachment; filename=engram-export.json")
+		err = s.store.ExportTo(sw)
+	}
+	if err == nil {
+		return
+	}
+	if !sw.sent {
+		w.Header().Del("Content-Disposition")
 		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	// The 200 is already out, so all that's left is to cut the body short
+	s.logger.Error("export failed mid-stream", slog.String("error", err.Error()))
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Content-Disposition", "attachment; filename=engram-export.json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(data)
+// sentWriter records whether any of the body reached the client, after
+// which the status can no longer change.
+type sentWriter struct {
+	http.ResponseWriter
+	sent bool
+}
+
+func (w *sentWriter) Write(b []byte) (int, error) {
+	w.sent = true
+	return w.ResponseWriter.Write(b)
 }
 
 func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	// A browser can POST text/plain or a form cross-origin without asking,
+	// but not JSON
+	ct := r.Header.Get("Content-Type")
+	if !strings.HasPrefix(ct, "application/json") && !strings.HasPrefix(ct, "application/x-ndjson") {
+		jsonError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json or application/x-ndjson")
+		return
+	}
+
 	// Limit body to 50MB
 	r.Body = http.MaxBytesReader(w, r.Body, 50<<20)
 	body, err := io.ReadAll(r.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		jsonError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("body over %d bytes", tooLarge.Limit))
+		return
+	}
 	if err != nil {
 		jsonError(w, http.StatusBadRequest, "failed to read body: "+err.Error())
 		return
 	}
 
-	var data store.ExportData
-	if err := json.Unmarshal(body, &data); err != nil {
+	data := &store.ExportData{}
+	if strings.HasPrefix(ct, "application/x-ndjson") {
+		if err := decodeNDJSON(body, data); err != nil {
+			jsonError(w, http.StatusBadRequest, "invalid ndjson: "+err.Error())
+			return
+		}
+	} else if data, err = store.DecodeExport(body); err != nil {
 		jsonError(w, http.StatusBadRequest, "invalid json: "+err.Error())
 		return
 	}
 
-	result, err := s.store.Import(&data)
+	result, err := s.store.Import(data)
 	if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -329,11 +791,50 @@ func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, result)
 }
 
+// decodeNDJSON collects store.ExportLine records back into an ExportData.
+func decodeNDJSON(body []byte, data *store.ExportData) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for {
+		var line store.ExportLine
+		if err := dec.Decode(&line); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		switch {
+		case line.Session != nil:
+			data.Sessions = append(data.Sessions, *line.Session)
+		case line.Observation != nil:
+			data.Observations = append(data.Observations, *line.Observation)
+		case line.Prompt != nil:
+			data.Prompts = append(data.Prompts, *line.Prompt)
+		}
+	}
+}
+
 // ─── Context ─────────────────────────────────────────────────────────────────
 
 func (s *Server) handleContext(w http.ResponseWriter, r *http.Request) {
 	project := r.URL.Query().Get("project")
 
+	// format=json returns the sections as data, for dashboards
+	if r.URL.Query().Get("format") == "json" {
+		data, err := s.store.Context(project)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		data.Pinned = orEmpty(data.Pinned)
+		data.Insights = orEmpty(data.Insights)
+		data.Facts = orEmpty(data.Facts)
+		data.Tasks = orEmpty(data.Tasks)
+		data.Sessions = orEmpty(data.Sessions)
+		data.Prompts = orEmpty(data.Prompts)
+		data.Observations = orEmpty(data.Observations)
+		jsonResponse(w, http.StatusOK, data)
+		return
+	}
+
 	context, err := s.store.FormatContext(project)
 	if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
@@ -343,7 +844,23 @@ func (s *Server) handleContext(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, map[string]string{"context": context})
 }
 
+// handleStats reports totals for the whole store, or for one project with
+// ?project=.
 func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if project := r.URL.Query().Get("project"); project != "" {
+		stats, err := s.store.ProjectStats(project)
+		if errors.Is(err, store.ErrProjectNotFound) {
+			jsonError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		jsonResponse(w, http.StatusOK, stats)
+		return
+	}
+
 	stats, err := s.store.Stats()
 	if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
@@ -355,6 +872,14 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 
 // ─── Helpers ─────────────────────────────────────────────────────────────────
 
+// orEmpty keeps an empty list as [] instead of null in responses.
+func orEmpty[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
 func jsonResponse(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -365,6 +890,38 @@ func jsonError(w http.ResponseWriter, status int, msg string) {
 	jsonResponse(w, status, map[string]string{"error": msg})
 }
 
+// requireAdmin rejects requests without the admin bearer token, when one
+// is configured, and every request when neither a token nor SetAdminOpen
+// enabled the admin endpoints.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case s.adminToken != "":
+			got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(s.adminToken)) != 1 {
+				jsonError(w, http.StatusUnauthorized, "admin token required")
+				return
+			}
+		case !s.adminOpen:
+			jsonError(w, http.StatusForbidden, "admin endpoints are disabled; set ENGRAM_ADMIN_TOKEN or ENGRAM_ADMIN=1")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func queryFloat(r *http.Request, key string, defaultVal float64) float64 {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return defaultVal
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return defaultVal
+	}
+	return f
+}
+
 func queryInt(r *http.Request, key string, defaultVal int) int {
 	v := r.URL.Query().Get(key)
 	if v == "" {
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alanbuscaglia/engram/internal/store"
+)
+
+func newTestServer(t *testing.T) (*Server, *store.Store) {
+	t.Helper()
+	cfg := store.DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	st, err := store.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return New(st, 0), st
+}
+
+func TestBulkObservationsValidateThenCreateSessions(t *testing.T) {
+	srv, st := newTestServer(t)
+	post := func(body string) int {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/observations/bulk", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	for _, body := range []string{
+		`[{"session_id":"a","content":"no title"}]`,
+		`[{"session_id":"a","title":"t","content":"c"},{"session_id":"b","title":"t","content":"c","importance":9}]`,
+	} {
+		if code := post(body); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, code)
+		}
+	}
+	if sessions, err := st.RecentSessions("", 10); err != nil || len(sessions) != 0 {
+		t.Fatalf("rejected batches left sessions %v (%v)", sessions, err)
+	}
+
+	if code := post(`[{"session_id":"a","title":"t","content":"c"},{"session_id":"b","title":"t2","content":"c2"}]`); code != http.StatusCreated {
+		t.Fatalf("status %d, want 201", code)
+	}
+	if sessions, err := st.RecentSessions("", 10); err != nil || len(sessions) != 2 {
+		t.Errorf("sessions = %v (%v), want a and b", sessions, err)
+	}
+}
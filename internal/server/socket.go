@@ -0,0 +1,269 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+
+	"github.com/alanbuscaglia/engram/internal/store"
+)
+
+// ─── Unix Socket Transport ───────────────────────────────────────────────────
+//
+// For agents on the same machine, StartSocket serves a line-based JSON
+// protocol on a Unix domain socket: no TCP, no port to pick. Each line is
+// one request, answered by one response line, in order:
+//
+//	{"id": 1, "op": "save", "params": {"session_id": "s1", "content": "..."}}
+//	{"id": 1, "ok": true, "result": {"id": 42, "title": "...", ...}}
+//
+// Ops are save, search, context and ping. id is optional and echoed back
+// untouched so clients can pipeline requests.
+
+// maxSocketLine caps a single request line. Longer lines get an error
+// response and the connection is closed, since the stream can't be resynced.
+const maxSocketLine = 4 << 20 // 4 MB
+
+type socketRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Op     string          `json:"op"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type socketResponse struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	OK     bool            `json:"ok"`
+	Result any             `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// socketSearchParams mirrors the GET /search query parameters.
+type socketSearchParams struct {
+	Query    string   `json:"query"`
+	Type     string   `json:"type"`
+	Types    []string `json:"types"`
+	Project  string   `json:"project"`
+	Projects []string `json:"projects"`
+	Author   string   `json:"author"`
+	Session  string   `json:"session_id"`
+	Limit    int      `json:"limit"`
+	Offset   int      `json:"offset"`
+	Tag      string   `json:"tag"`
+	Exclude  []string `json:"exclude"`
+	NotType  []string `json:"not_type"`
+}
+
+// StartSocket listens on a Unix socket at path and serves until ctx is
+// cancelled, then removes the socket file. A stale socket left by a crashed
+// server is replaced; one that still accepts connections is an error.
+func (s *Server) StartSocket(ctx context.Context, path string) error {
+	if err := removeStaleSocket(path); err != nil {
+		return fmt.Errorf("engram server: %w", err)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("engram server: listen %s: %w", path, err)
+	}
+	// Only the owner may talk to the socket, like the database file itself
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return fmt.Errorf("engram server: chmod %s: %w", path, err)
+	}
+	log.Printf("[engram] socket server listening on %s", path)
+
+	go func() {
+		<-ctx.Done()
+		ln.Close() // also unlinks the socket file
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("engram server: accept: %w", err)
+		}
+		go s.serveSocketConn(conn)
+	}
+}
+
+// removeStaleSocket deletes a leftover socket file nobody is listening on.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another server", path)
+	}
+	return os.Remove(path)
+}
+
+// serveSocketConn answers requests on one connection until the client
+// closes it.
+func (s *Server) serveSocketConn(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	enc := json.NewEncoder(conn) // Encode terminates each response with '\n'
+	for {
+		line, err := readSocketLine(r)
+		if errors.Is(err, errLineTooLong) {
+			enc.Encode(socketResponse{Error: fmt.Sprintf("request line exceeds %d bytes", maxSocketLine)})
+			return
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			if encErr := enc.Encode(s.handleSocketLine(line)); encErr != nil {
+				return
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Printf("[engram] socket read: %v", err)
+			}
+			return
+		}
+	}
+}
+
+var errLineTooLong = errors.New("line too long")
+
+// readSocketLine reads up to and excluding the next '\n', enforcing
+// maxSocketLine. A final line without a newline is returned with io.EOF.
+func readSocketLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxSocketLine {
+			return nil, errLineTooLong
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		return bytes.TrimSuffix(line, []byte("\n")), err
+	}
+}
+
+// handleSocketLine decodes and dispatches one request.
+func (s *Server) handleSocketLine(line []byte) socketResponse {
+	var req socketRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return socketResponse{Error: "invalid json: " + err.Error()}
+	}
+
+	result, err := s.dispatchSocket(req)
+	if err != nil {
+		return socketResponse{ID: req.ID, Error: err.Error()}
+	}
+	return socketResponse{ID: req.ID, OK: true, Result: result}
+}
+
+func (s *Server) dispatchSocket(req socketRequest) (any, error) {
+	switch req.Op {
+	case "ping":
+		return map[string]string{"status": "ok", "service": "engram"}, nil
+
+	case "save":
+		var p store.AddObservationParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		if p.SessionID == "" || p.Content == "" {
+			return nil, errors.New("session_id and content are required")
+		}
+		// As with POST /observations: validate, then create the session
+		// on demand, since the protocol has no op for it
+		if err := s.store.ValidateObservation(p); err != nil {
+			return nil, err
+		}
+		if err := s.store.CreateSession(p.SessionID, p.Project, ""); err != nil {
+			return nil, err
+		}
+		res, err := s.store.SaveObservation(p)
+		if err != nil {
+			return nil, err
+		}
+		status := "saved"
+		if res.Duplicate {
+			status = "duplicate"
+		}
+		return map[string]any{
+			"id":              res.ID,
+			"title":           res.Title,
+			"status":          status,
+			"redaction_count": res.RedactionCount,
+		}, nil
+
+	case "search":
+		var p socketSearchParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		if p.Limit == 0 {
+			p.Limit = 10
+		}
+		resp, err := s.searchPage(p.Query, store.SearchOptions{
+			Type:         p.Type,
+			Types:        p.Types,
+			Project:      p.Project,
+			Projects:     p.Projects,
+			Author:       p.Author,
+			SessionID:    p.Session,
+			Limit:        p.Limit,
+			Offset:       p.Offset,
+			Tag:          p.Tag,
+			ExcludeTerms: p.Exclude,
+			ExcludeTypes: p.NotType,
+		})
+		if err != nil {
+			return nil, err
+		}
+		resp.Results = orEmpty(resp.Results)
+		return resp, nil
+
+	case "context":
+		var p struct {
+			Project string `json:"project"`
+		}
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		context, err := s.store.FormatContext(p.Project)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"context": context}, nil
+
+	case "":
+		return nil, errors.New("op is required")
+	}
+	return nil, fmt.Errorf("unknown op %q (expected save, search, context, or ping)", req.Op)
+}
+
+// decodeParams unmarshals request params, treating missing params as {}.
+func decodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSocketSaveSearchRoundTrip(t *testing.T) {
+	_, st := newTestServer(t)
+
+	path := filepath.Join(t.TempDir(), "engram.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- New(st, 0).StartSocket(ctx, path) }()
+	defer func() {
+		cancel()
+		if err := <-served; err != nil {
+			t.Error(err)
+		}
+	}()
+
+	var conn net.Conn
+	var err error
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if conn, err = net.Dial("unix", path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("socket never came up: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	call := func(req string) map[string]any {
+		t.Helper()
+		if _, err := conn.Write([]byte(req + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resp struct {
+			OK     bool           `json:"ok"`
+			Result map[string]any `json:"result"`
+			Error  string         `json:"error"`
+		}
+		if err := json.Unmarshal(line, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if !resp.OK {
+			t.Fatalf("%s: %s", req, resp.Error)
+		}
+		return resp.Result
+	}
+
+	// Nothing matches yet: an empty array, not null
+	if got := call(`{"op":"search","params":{"query":"hello"}}`); got["results"] == nil {
+		t.Errorf("empty search: results = %v, want []", got["results"])
+	}
+
+	// The session doesn't exist yet; save creates it
+	saved := call(`{"op":"save","params":{"session_id":"s1","content":"hello world"}}`)
+	if saved["status"] != "saved" {
+		t.Fatalf("save = %v", saved)
+	}
+
+	results, _ := call(`{"op":"search","params":{"query":"hello"}}`)["results"].([]any)
+	if len(results) != 1 {
+		t.Fatalf("search found %d results, want 1", len(results))
+	}
+	if id := results[0].(map[string]any)["id"]; id != saved["id"] {
+		t.Errorf("search found #%v, want the saved #%v", id, saved["id"])
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alanbuscaglia/engram/internal/store"
+)
+
+// ─── Live Stream ─────────────────────────────────────────────────────────────
+//
+// GET /observations/stream is a server-sent events feed of observations as
+// they're saved, for watching an agent's memory from a browser:
+//
+//	id: 42
+//	data: {"id": 42, "title": "...", ...}
+//
+// The hub hears about new observations through the store's
+// EventObservationCreated, so only saves made through this process show up
+// live. since_id (or the Last-Event-ID header EventSource sends when it
+// reconnects) replays everything saved after that ID first, from the
+// database, so nothing is missed across reconnects or other processes.
+
+const (
+	// streamBuffer is how many observations a client may fall behind by.
+	// A client further behind is disconnected rather than silently skipped;
+	// it reconnects with Last-Event-ID and catches up from the database.
+	streamBuffer = 64
+	// streamReplayPage is how many observations are read per query when
+	// replaying since_id.
+	streamReplayPage = 100
+	// streamHeartbeat keeps idle connections from being closed by proxies.
+	streamHeartbeat = 30 * time.Second
+)
+
+// hub fans new observations out to every connected stream client.
+type hub struct {
+	mu   sync.Mutex
+	subs map[chan store.Observation]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[chan store.Observation]struct{})}
+}
+
+func (h *hub) subscribe() chan store.Observation {
+	ch := make(chan store.Observation, streamBuffer)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *hub) unsubscribe(ch chan store.Observation) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[ch]; ok {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+// publish never blocks the writer: a subscriber whose buffer is full is
+// dropped and its channel closed.
+func (h *hub) publish(o store.Observation) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- o:
+		default:
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *Server) handleObservationStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		jsonError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	since := r.URL.Query().Get("since_id")
+	if since == "" {
+		since = r.Header.Get("Last-Event-ID")
+	}
+	var last int64
+	if since != "" {
+		n, err := strconv.ParseInt(since, 10, 64)
+		if err != nil || n < 0 {
+			jsonError(w, http.StatusBadRequest, "invalid since_id")
+			return
+		}
+		last = n
+	}
+
+	// Subscribe before replaying so nothing saved in between is lost;
+	// anything seen twice is skipped by ID.
+	ch := s.hub.subscribe()
+	defer s.hub.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(o store.Observation) bool {
+		data, err := json.Marshal(o)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", o.ID, data); err != nil {
+			return false
+		}
+		last = o.ID
+		return true
+	}
+
+	if since != "" {
+		for {
+			page, err := s.store.ObservationsSince(last, streamReplayPage)
+			if err != nil {
+				fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error())
+				return
+			}
+			for _, o := range page {
+				if !send(o) {
+					return
+				}
+			}
+			if len(page) < streamReplayPage {
+				break
+			}
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case o, ok := <-ch:
+			if !ok {
+				return
+			}
+			if o.ID <= last {
+				continue
+			}
+			if !send(o) {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
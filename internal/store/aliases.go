@@ -0,0 +1,153 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ─── Project Aliases ─────────────────────────────────────────────────────────
+//
+// Agents derive the project from the directory they run in, so one project
+// can end up as "engram", "Engram" and "engram-co". An alias maps one of
+// those names onto the canonical one: existing rows are moved over when the
+// alias is added, project values are rewritten on every write, and project
+// filters on reads are resolved the same way, so the alias reaches the same
+// memories. Matching is exact; add one alias per spelling.
+
+// ProjectAlias maps Alias onto Canonical.
+type ProjectAlias struct {
+	Alias     string `json:"alias"`
+	Canonical string `json:"canonical"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ProjectAliasResult reports what AddProjectAlias moved over to the
+// canonical project.
+type ProjectAliasResult struct {
+	Alias        string `json:"alias"`
+	Canonical    string `json:"canonical"`
+	Sessions     int64  `json:"sessions"`
+	Observations int64  `json:"observations"`
+	Prompts      int64  `json:"prompts"`
+	Facts        int64  `json:"facts"`
+}
+
+// AddProjectAlias makes alias another name for canonical. If canonical is
+// itself an alias, its target is used, and aliases that pointed at alias now
+// point at the target too, so lookups never chain. Sessions, observations,
+// prompts and facts filed under alias move to canonical; a fact whose key
+// canonical already has is dropped in favour of canonical's.
+func (s *Store) AddProjectAlias(alias, canonical string) (*ProjectAliasResult, error) {
+	alias, canonical = strings.TrimSpace(alias), strings.TrimSpace(canonical)
+	if alias == "" || canonical == "" {
+		return nil, fmt.Errorf("project alias: alias and canonical project are required")
+	}
+	canonical = s.canonicalProject(canonical)
+	if alias == canonical {
+		return nil, fmt.Errorf("project alias: %q can't be an alias of itself", alias)
+	}
+
+	result := &ProjectAliasResult{Alias: alias, Canonical: canonical}
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("project alias: begin tx: %w", err)
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(
+			`INSERT INTO project_aliases (alias, canonical) VALUES (?, ?)
+			 ON CONFLICT(alias) DO UPDATE SET canonical = excluded.canonical`,
+			alias, canonical,
+		); err != nil {
+			return fmt.Errorf("project alias: %w", err)
+		}
+		if _, err := tx.Exec(
+			"UPDATE project_aliases SET canonical = ? WHERE canonical = ?", canonical, alias,
+		); err != nil {
+			return fmt.Errorf("project alias: %w", err)
+		}
+
+		moved := []struct {
+			table string
+			n     *int64
+		}{
+			{"sessions", &result.Sessions},
+			{"observations", &result.Observations},
+			{"user_prompts", &result.Prompts},
+		}
+		for _, m := range moved {
+			res, err := tx.Exec("UPDATE "+m.table+" SET project = ? WHERE project = ?", canonical, alias)
+			if err != nil {
+				return fmt.Errorf("project alias: move %s: %w", m.table, err)
+			}
+			*m.n, _ = res.RowsAffected()
+		}
+
+		// facts are unique per (project, key)
+		res, err := tx.Exec("UPDATE OR IGNORE facts SET project = ? WHERE project = ?", canonical, alias)
+		if err != nil {
+			return fmt.Errorf("project alias: move facts: %w", err)
+		}
+		result.Facts, _ = res.RowsAffected()
+		if _, err := tx.Exec("DELETE FROM facts WHERE project = ?", alias); err != nil {
+			return fmt.Errorf("project alias: move facts: %w", err)
+		}
+
+		detail := fmt.Sprintf("moved to %s: %d sessions, %d observations, %d prompts, %d facts",
+			canonical, result.Sessions, result.Observations, result.Prompts, result.Facts)
+		if err := s.writeAudit(tx, AuditProjectAlias, alias, detail); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RemoveProjectAlias stops mapping alias. Rows already moved to the
+// canonical project stay there.
+func (s *Store) RemoveProjectAlias(alias string) error {
+	res, err := s.exec("DELETE FROM project_aliases WHERE alias = ?", alias)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("project alias %q not found", alias)
+	}
+	return nil
+}
+
+// ProjectAliases lists every alias, sorted by canonical project then alias.
+func (s *Store) ProjectAliases() ([]ProjectAlias, error) {
+	rows, err := s.db.Query("SELECT alias, canonical, created_at FROM project_aliases ORDER BY canonical, alias")
+	if err != nil {
+		return nil, fmt.Errorf("project aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var aliases []ProjectAlias
+	for rows.Next() {
+		var a ProjectAlias
+		if err := rows.Scan(&a.Alias, &a.Canonical, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
+
+// canonicalProject resolves project through project_aliases. Empty and
+// unaliased names come back unchanged, as does project if the lookup fails.
+func (s *Store) canonicalProject(project string) string {
+	if project == "" {
+		return ""
+	}
+	var canonical string
+	if err := s.db.QueryRow("SELECT canonical FROM project_aliases WHERE alias = ?", project).Scan(&canonical); err != nil {
+		return project
+	}
+	return canonical
+}
@@ -0,0 +1,78 @@
+package store
+
+import "fmt"
+
+// ─── Audit Log ───────────────────────────────────────────────────────────────
+//
+// On a shared database, deletes and edits need to be accountable. Every
+// destructive or rewriting operation appends a row to audit_log in the same
+// transaction as the change itself, so a change can't happen without its
+// entry or the other way round. The actor is Config.Author. Triggers reject
+// UPDATE and DELETE on the table: entries are only ever added.
+
+// Audit log operations.
+const (
+	AuditDelete                    = "delete"
+	AuditDeleteSession             = "delete_session"
+	AuditDeleteSessionObservations = "delete_session_observations"
+	AuditMergeSessions             = "merge_sessions"
+	AuditArchive                   = "archive"
+	AuditUnarchive                 = "unarchive"
+	AuditProjectAlias              = "project_alias"
+	AuditDeleteFact                = "delete_fact"
+	AuditEdit                      = "edit"
+	AuditPrune                     = "prune"
+	AuditImport                    = "import"
+	AuditRestore                   = "restore"
+)
+
+// AuditEntry is one audit_log row.
+type AuditEntry struct {
+	ID        int64  `json:"id"`
+	Operation string `json:"operation"`
+	// TargetID is the observation, session or fact ID, or the project
+	// alias, the operation acted on; empty for operations on many rows
+	// (prune, import, restore).
+	TargetID  string `json:"target_id,omitempty"`
+	Actor     string `json:"actor"`
+	Detail    string `json:"detail,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// writeAudit appends an audit_log entry through x, which should be the
+// transaction making the change.
+func (s *Store) writeAudit(x execer, operation, targetID, detail string) error {
+	_, err := x.Exec(
+		"INSERT INTO audit_log (operation, target_id, actor, detail, created_at) VALUES (?, ?, ?, ?, ?)",
+		operation, targetID, s.cfg.Author, detail, Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("audit log: %w", err)
+	}
+	return nil
+}
+
+// AuditLog returns the most recent audit entries, newest first.
+func (s *Store) AuditLog(limit int) ([]AuditEntry, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query(
+		`SELECT id, operation, target_id, actor, detail, created_at FROM audit_log
+		 ORDER BY id DESC LIMIT ?`, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Operation, &e.TargetID, &e.Actor, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
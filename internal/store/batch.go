@@ -0,0 +1,169 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ─── Batched Writes ──────────────────────────────────────────────────────────
+//
+// Under heavy multi-agent activity every AddObservation is its own implicit
+// transaction, and they all contend for SQLite's single write lock. The
+// batchWriter trades a little latency for throughput: callers enqueue
+// prepared rows and block until a background goroutine commits them as part
+// of a group, then receive their row ID from that flush.
+
+// errStoreClosed is returned for writes enqueued after Close.
+var errStoreClosed = errors.New("engram: store is closed")
+
+const defaultBatchSize = 100
+
+type batchItem struct {
+	params AddObservationParams
+	result chan batchResult
+}
+
+type batchResult struct {
+	id  int64
+	err error
+}
+
+type batchWriter struct {
+	db     *sql.DB
+	window time.Duration
+	size   int
+	retry  func(func() error) error // Store.withRetry
+
+	mu     sync.RWMutex // guards closed + sends on queue
+	closed bool
+	queue  chan batchItem
+	done   chan struct{}
+}
+
+func newBatchWriter(db *sql.DB, window time.Duration, size int, retry func(func() error) error) *batchWriter {
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	w := &batchWriter{
+		db:     db,
+		window: window,
+		size:   size,
+		retry:  retry,
+		queue:  make(chan batchItem, size),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// add enqueues a prepared observation and waits for the flush that writes it.
+func (w *batchWriter) add(p AddObservationParams) (int64, error) {
+	item := batchItem{params: p, result: make(chan batchResult, 1)}
+
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return 0, errStoreClosed
+	}
+	w.queue <- item
+	w.mu.RUnlock()
+
+	res := <-item.result
+	return res.id, res.err
+}
+
+// close stops accepting writes, flushes whatever is pending, and waits for
+// the background goroutine to exit.
+func (w *batchWriter) close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	<-w.done
+}
+
+func (w *batchWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.window)
+	defer ticker.Stop()
+
+	var pending []batchItem
+	for {
+		select {
+		case item, ok := <-w.queue:
+			if !ok {
+				w.flush(pending)
+				return
+			}
+			pending = append(pending, item)
+			if len(pending) >= w.size {
+				w.flush(pending)
+				pending = nil
+			}
+		case <-ticker.C:
+			if len(pending) > 0 {
+				w.flush(pending)
+				pending = nil
+			}
+		}
+	}
+}
+
+// flush inserts all pending items in one transaction, retried like any
+// other write while SQLite is busy. Each row gets its own savepoint, so a
+// failing row is rolled back whole and only fails its own caller; a failing
+// commit fails the whole batch.
+func (w *batchWriter) flush(items []batchItem) {
+	if len(items) == 0 {
+		return
+	}
+
+	results := make([]batchResult, len(items))
+	err := w.retry(func() error {
+		tx, err := w.db.Begin()
+		if err != nil {
+			return fmt.Errorf("batch: begin tx: %w", err)
+		}
+		defer tx.Rollback()
+
+		for i, item := range items {
+			if _, err := tx.Exec("SAVEPOINT batch_row"); err != nil {
+				return fmt.Errorf("batch: %w", err)
+			}
+			id, err := insertObservation(tx, item.params)
+			if isBusy(err) {
+				return err
+			}
+			if err != nil {
+				if _, err := tx.Exec("ROLLBACK TO batch_row"); err != nil {
+					return fmt.Errorf("batch: %w", err)
+				}
+			}
+			results[i] = batchResult{id: id, err: err}
+			if _, err := tx.Exec("RELEASE batch_row"); err != nil {
+				return fmt.Errorf("batch: %w", err)
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("batch: commit: %w", err)
+		}
+		return nil
+	})
+
+	for i, item := range items {
+		if err != nil {
+			results[i] = batchResult{err: err}
+		}
+		item.result <- results[i]
+	}
+}
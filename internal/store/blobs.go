@@ -0,0 +1,102 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// ─── Content Compression ─────────────────────────────────────────────────────
+//
+// Content longer than MaxObservationLength is cut to that length plus
+// "... [truncated]". With Config.CompressContent the cut-off text isn't lost:
+// the full content is gzipped into observation_blobs, keyed by observation
+// ID, while the observations row keeps the truncated preview. Search, FTS
+// and list views work on the preview; GetObservation and exports return the
+// full text.
+
+// truncatedSuffix marks content cut to MaxObservationLength.
+const truncatedSuffix = "... [truncated]"
+
+// fitContent cuts content to MaxObservationLength. stored is what goes in
+// the observations row; full is the uncut content to keep as a blob, or ""
+// when nothing was cut or compression is off.
+func (s *Store) fitContent(content string) (stored, full string) {
+	if len(content) <= s.cfg.MaxObservationLength {
+		return content, ""
+	}
+	stored = content[:s.cfg.MaxObservationLength] + truncatedSuffix
+	if s.cfg.CompressContent {
+		full = content
+	}
+	return stored, full
+}
+
+// writeBlob stores full as observation id's compressed content, or removes
+// its blob when full is "".
+func writeBlob(x execer, id int64, full string) error {
+	if full == "" {
+		_, err := x.Exec("DELETE FROM observation_blobs WHERE observation_id = ?", id)
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(full)); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	_, err := x.Exec(
+		"INSERT OR REPLACE INTO observation_blobs (observation_id, content, size) VALUES (?, ?, ?)",
+		id, buf.Bytes(), len(full),
+	)
+	return err
+}
+
+// readBlob decompresses a blob's content.
+func readBlob(compressed []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+	full, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+	return string(full), nil
+}
+
+// attachFullContent replaces the truncated content of observations that
+// have a blob with the full text.
+func (s *Store) attachFullContent(obs []Observation) error {
+	if len(obs) == 0 {
+		return nil
+	}
+	index, lo, hi := indexObservations(obs)
+
+	rows, err := s.db.Query(
+		"SELECT observation_id, content FROM observation_blobs WHERE observation_id BETWEEN ? AND ?", lo, hi,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var compressed []byte
+		if err := rows.Scan(&id, &compressed); err != nil {
+			return err
+		}
+		i, ok := index[id]
+		if !ok {
+			continue
+		}
+		if obs[i].Content, err = readBlob(compressed); err != nil {
+			return fmt.Errorf("observation #%d content: %w", id, err)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ─── Config File ─────────────────────────────────────────────────────────────
+//
+// Long-lived settings can live in a JSON file instead of the shell profile:
+//
+//	{
+//	  "data_dir": "/srv/engram",
+//	  "author": "alice",
+//	  "max_search_results": 50,
+//	  "compress_content": true,
+//	  "redaction_patterns": ["ACME-[0-9]{8}"],
+//	  "port": 7500
+//	}
+//
+// Only the CLI reads it, on top of DefaultConfig; environment variables and
+// flags still win over it. DefaultConfig and New never look at the file, so
+// Go callers load and apply it themselves.
+
+// ConfigFileName is the config file's name in the default data directory
+// (~/.engram/config.json).
+const ConfigFileName = "config.json"
+
+// FileConfig is what a config file can set. Keys left out keep their
+// defaults; unknown keys are an error, so a typo doesn't go unnoticed.
+type FileConfig struct {
+	DataDir              *string  `json:"data_dir,omitempty"`
+	Author               *string  `json:"author,omitempty"`
+	MaxObservationLength *int     `json:"max_observation_length,omitempty"`
+	CompressContent      *bool    `json:"compress_content,omitempty"`
+	MaxContextResults    *int     `json:"max_context_results,omitempty"`
+	MaxSearchResults     *int     `json:"max_search_results,omitempty"`
+	MaxSummaryLength     *int     `json:"max_summary_length,omitempty"`
+	MaxContextChars      *int     `json:"max_context_chars,omitempty"`
+	MaxRetries           *int     `json:"max_retries,omitempty"`
+	RedactionPatterns    []string `json:"redaction_patterns,omitempty"`
+	FTSTokenizer         *string  `json:"fts_tokenizer,omitempty"`
+
+	// Port is the HTTP server's default port. It isn't part of Config;
+	// the caller applies it.
+	Port *int `json:"port,omitempty"`
+}
+
+// DefaultConfigPath is ~/.engram/config.json. It doesn't follow a DataDir
+// override, since the file may set DataDir itself.
+func DefaultConfigPath() string {
+	return filepath.Join(DefaultConfig().DataDir, ConfigFileName)
+}
+
+// LoadConfigFile reads a config file. A file that doesn't exist is the same
+// as an empty one.
+func LoadConfigFile(path string) (*FileConfig, error) {
+	var fc FileConfig
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &fc, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fc); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return &fc, nil
+}
+
+// Apply copies the settings the file sets into cfg.
+func (fc *FileConfig) Apply(cfg *Config) {
+	if fc.DataDir != nil {
+		cfg.DataDir = expandHome(*fc.DataDir)
+	}
+	if fc.Author != nil {
+		cfg.Author = *fc.Author
+	}
+	if fc.CompressContent != nil {
+		cfg.CompressContent = *fc.CompressContent
+	}
+	if fc.FTSTokenizer != nil {
+		cfg.FTSTokenizer = *fc.FTSTokenizer
+	}
+	for _, f := range []struct {
+		from *int
+		to   *int
+	}{
+		{fc.MaxObservationLength, &cfg.MaxObservationLength},
+		{fc.MaxContextResults, &cfg.MaxContextResults},
+		{fc.MaxSearchResults, &cfg.MaxSearchResults},
+		{fc.MaxSummaryLength, &cfg.MaxSummaryLength},
+		{fc.MaxContextChars, &cfg.MaxContextChars},
+		{fc.MaxRetries, &cfg.MaxRetries},
+	} {
+		if f.from != nil {
+			*f.to = *f.from
+		}
+	}
+	if fc.RedactionPatterns != nil {
+		cfg.RedactionPatterns = fc.RedactionPatterns
+	}
+}
+
+// expandHome turns a leading ~ into the home directory, as a shell would
+// have for the same path in ENGRAM_DATA_DIR.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
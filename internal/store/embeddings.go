@@ -0,0 +1,263 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ─── Semantic Search ─────────────────────────────────────────────────────────
+//
+// FTS only finds memories that share words with the query; "login broke"
+// misses "authentication failure". Semantic search ranks by the cosine
+// similarity of embedding vectors instead. Engram ships no model: the caller
+// plugs one in through Config.Embedder, and without one everything else
+// works as before. Vectors are cached in the embeddings table, one row per
+// observation, and recomputed when the observation's text or the model
+// changes.
+
+// Embedder turns text into vectors. Implementations wrap whatever model the
+// caller has — a local model, an HTTP API. Every vector an Embedder returns
+// must have the same length.
+type Embedder interface {
+	// Name identifies the model. Vectors stored under another name are
+	// recomputed, so switching models never compares across vector spaces.
+	Name() string
+	// Embed returns one vector per text, in the same order.
+	Embed(texts []string) ([][]float32, error)
+}
+
+// ErrNoEmbedder is returned by SearchSemantic when Config.Embedder is nil.
+var ErrNoEmbedder = errors.New("semantic search needs an embedder (Config.Embedder)")
+
+// embedBatchSize is how many observations are sent to the Embedder per call
+// when filling in missing vectors.
+const embedBatchSize = 32
+
+// SearchSemantic ranks observations by similarity to query using
+// Config.Embedder. Filters, Limit and Offset work as in Search; Explain and
+// Raw are ignored. Rank is the negated cosine similarity, so lower is better
+// as with FTS. Observations without an up-to-date vector are embedded first,
+// which makes the first search after many saves slower. An empty query
+// falls back to Search.
+func (s *Store) SearchSemantic(query string, opts SearchOptions) ([]SearchResult, error) {
+	embedder := s.cfg.Embedder
+	if embedder == nil {
+		return nil, ErrNoEmbedder
+	}
+	if strings.TrimSpace(query) == "" {
+		return s.Search(query, opts)
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > s.cfg.MaxSearchResults {
+		limit = s.cfg.MaxSearchResults
+	}
+
+	opts.Project, opts.Projects = "", s.searchProjects(opts)
+	filters, args := searchFilters(opts)
+	if excluded := excludeFTS(opts.ExcludeTerms); excluded != "" {
+		filters += " AND o.id NOT IN (SELECT rowid FROM observations_fts WHERE observations_fts MATCH ?)"
+		args = append(args, excluded)
+	}
+
+	if err := s.embedMissing(embedder, filters, args); err != nil {
+		return nil, fmt.Errorf("semantic search: %w", err)
+	}
+
+	vectors, err := embedder.Embed([]string{query})
+	if err != nil {
+		return nil, fmt.Errorf("semantic search: embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("semantic search: embedder returned %d vectors for 1 query", len(vectors))
+	}
+	queryVec := vectors[0]
+
+	rows, err := s.db.Query(
+		"SELECT e.observation_id, e.vector FROM embeddings e JOIN observations o ON o.id = e.observation_id WHERE e.model = ?"+filters,
+		append([]any{embedder.Name()}, args...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("semantic search: %w", err)
+	}
+	defer rows.Close()
+
+	type scored struct {
+		id    int64
+		score float64
+	}
+	var ranked []scored
+	for rows.Next() {
+		var id int64
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			return nil, err
+		}
+		vec := decodeVector(blob)
+		if len(vec) != len(queryVec) {
+			return nil, fmt.Errorf("semantic search: observation #%d has a %d-dimension vector, query has %d", id, len(vec), len(queryVec))
+		}
+		ranked = append(ranked, scored{id, cosineSimilarity(queryVec, vec)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].id > ranked[j].id
+	})
+	offset := min(max(opts.Offset, 0), len(ranked))
+	ranked = ranked[offset:min(offset+limit, len(ranked))]
+	if len(ranked) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]any, len(ranked))
+	for i, r := range ranked {
+		ids[i] = r.id
+	}
+	observations, err := s.queryObservations(
+		"SELECT "+observationColumns+" FROM observations o WHERE o.id IN (?"+strings.Repeat(", ?", len(ids)-1)+")", ids...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("semantic search: %w", err)
+	}
+	byID := make(map[int64]Observation, len(observations))
+	for _, o := range observations {
+		byID[o.ID] = o
+	}
+
+	results := make([]SearchResult, 0, len(ranked))
+	for _, r := range ranked {
+		if o, ok := byID[r.id]; ok {
+			results = append(results, SearchResult{Observation: o, Rank: -r.score})
+		}
+	}
+	return results, nil
+}
+
+// embedMissing embeds the observations matching filters whose stored vector
+// is missing, from another model, or from an older version of their text.
+func (s *Store) embedMissing(embedder Embedder, filters string, args []any) error {
+	rows, err := s.db.Query(
+		`SELECT o.id, o.title, o.content, COALESCE(e.model, ''), COALESCE(e.text_hash, '')
+		 FROM observations o LEFT JOIN embeddings e ON e.observation_id = o.id
+		 WHERE 1=1`+filters, args...,
+	)
+	if err != nil {
+		return err
+	}
+
+	type pending struct {
+		id         int64
+		text, hash string
+	}
+	var stale []pending
+	for rows.Next() {
+		var p pending
+		var title, content, model, hash string
+		if err := rows.Scan(&p.id, &title, &content, &model, &hash); err != nil {
+			rows.Close()
+			return err
+		}
+		p.text = embeddingText(title, content)
+		p.hash = textHash(p.text)
+		if model != embedder.Name() || hash != p.hash {
+			stale = append(stale, p)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for start := 0; start < len(stale); start += embedBatchSize {
+		batch := stale[start:min(start+embedBatchSize, len(stale))]
+		texts := make([]string, len(batch))
+		for i, p := range batch {
+			texts[i] = p.text
+		}
+		vectors, err := embedder.Embed(texts)
+		if err != nil {
+			return fmt.Errorf("embed observations: %w", err)
+		}
+		if len(vectors) != len(batch) {
+			return fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), len(batch))
+		}
+
+		err = s.withRetry(func() error {
+			tx, err := s.db.Begin()
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+			for i, p := range batch {
+				if _, err := tx.Exec(
+					"INSERT OR REPLACE INTO embeddings (observation_id, model, text_hash, vector) VALUES (?, ?, ?, ?)",
+					p.id, embedder.Name(), p.hash, encodeVector(vectors[i]),
+				); err != nil {
+					return err
+				}
+			}
+			return tx.Commit()
+		})
+		if err != nil {
+			return fmt.Errorf("store embeddings: %w", err)
+		}
+	}
+	return nil
+}
+
+// embeddingText is what gets embedded for an observation.
+func embeddingText(title, content string) string {
+	return title + "\n\n" + content
+}
+
+func textHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// encodeVector packs a vector as little-endian float32s.
+func encodeVector(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
+
+// cosineSimilarity returns a value in [-1, 1]; 0 when either vector is all
+// zeros.
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"log"
+	"sync"
+)
+
+// ─── Events ──────────────────────────────────────────────────────────────────
+//
+// A minimal in-process pub/sub for anyone embedding the store. Write methods
+// emit after their statement (or batch transaction) has committed, so
+// handlers never run while SQLite's write lock is held and always see the
+// data they're told about.
+//
+// Handlers run synchronously on the writer's goroutine — keep them cheap or
+// hand off to your own goroutine. A panicking handler is recovered and
+// logged; it doesn't affect the write or the other handlers.
+
+// Event names and their payload types.
+const (
+	EventObservationCreated = "observation.created" // *Observation
+	EventObservationUpdated = "observation.updated" // *Observation
+	EventObservationDeleted = "observation.deleted" // int64 (observation ID)
+	EventSessionCreated     = "session.created"     // *Session
+	EventSessionEnded       = "session.ended"       // *Session
+	EventPromptCreated      = "prompt.created"      // *Prompt
+)
+
+type eventBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]func(any)
+}
+
+// On registers handler to be called with the payload of every event named
+// event. See the Event* constants for names and payload types.
+func (s *Store) On(event string, handler func(any)) {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+	if s.events.handlers == nil {
+		s.events.handlers = make(map[string][]func(any))
+	}
+	s.events.handlers[event] = append(s.events.handlers[event], handler)
+}
+
+// hasHandlers lets emitters skip building payloads nobody will see.
+func (s *Store) hasHandlers(event string) bool {
+	s.events.mu.RLock()
+	defer s.events.mu.RUnlock()
+	return len(s.events.handlers[event]) > 0
+}
+
+func (s *Store) emit(event string, payload any) {
+	s.events.mu.RLock()
+	handlers := append([]func(any){}, s.events.handlers[event]...)
+	s.events.mu.RUnlock()
+
+	for _, h := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("[engram] event handler for %s panicked: %v", event, r)
+				}
+			}()
+			h(payload)
+		}()
+	}
+}
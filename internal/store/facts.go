@@ -0,0 +1,138 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ─── Facts ───────────────────────────────────────────────────────────────────
+//
+// Facts are stable environmental knowledge ("staging DB is at host X") that
+// keeps coming up across sessions. Unlike observations they're deduplicated
+// at write time: recording the same key again refreshes the content,
+// last_seen and seen_count instead of adding a row.
+
+type Fact struct {
+	ID        int64  `json:"id"`
+	Key       string `json:"key"`
+	Content   string `json:"content"`
+	Project   string `json:"project,omitempty"` // empty = applies everywhere
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+	SeenCount int    `json:"seen_count"`
+}
+
+// normalizeFactKey folds case, punctuation and spacing so "Staging DB",
+// "staging-db" and "staging_db " are the same fact.
+func normalizeFactKey(key string) string {
+	words := strings.FieldsFunc(strings.ToLower(key), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// RecordFact stores a fact or, when project already has one with the same
+// normalized key, updates its content and bumps last_seen and seen_count.
+// Content goes through the same private-tag stripping and secret redaction
+// as observations.
+func (s *Store) RecordFact(key, content, project string) (*Fact, error) {
+	project = s.canonicalProject(project)
+	normalized := normalizeFactKey(key)
+	if normalized == "" {
+		return nil, fmt.Errorf("record fact: key is required")
+	}
+
+	content, redactions := s.redact(stripPrivateTags(content))
+	if redactions > 0 && s.cfg.StrictRedaction {
+		return nil, fmt.Errorf("%w: found %d likely secret(s), refusing to save", ErrSecretDetected, redactions)
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("record fact: content is required")
+	}
+	if len(content) > s.cfg.MaxObservationLength {
+		content = content[:s.cfg.MaxObservationLength] + "... [truncated]"
+	}
+
+	_, err := s.exec(
+		`INSERT INTO facts (key, content, project) VALUES (?, ?, ?)
+		 ON CONFLICT(project, key) DO UPDATE SET
+		   content = excluded.content,
+		   last_seen = datetime('now'),
+		   seen_count = seen_count + 1`,
+		normalized, content, project,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("record fact: %w", err)
+	}
+
+	var f Fact
+	err = s.db.QueryRow(
+		`SELECT id, key, content, project, first_seen, last_seen, seen_count
+		 FROM facts WHERE project = ? AND key = ?`, project, normalized,
+	).Scan(&f.ID, &f.Key, &f.Content, &f.Project, &f.FirstSeen, &f.LastSeen, &f.SeenCount)
+	if err != nil {
+		return nil, fmt.Errorf("record fact: %w", err)
+	}
+	return &f, nil
+}
+
+// Facts returns the facts for project plus the project-less ones, most
+// relevant first: seen most often, then seen most recently. An empty
+// project returns every fact.
+func (s *Store) Facts(project string, limit int) ([]Fact, error) {
+	project = s.canonicalProject(project)
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := "SELECT id, key, content, project, first_seen, last_seen, seen_count FROM facts"
+	args := []any{}
+	if project != "" {
+		query += " WHERE project IN (?, '')"
+		args = append(args, project)
+	}
+	query += " ORDER BY seen_count DESC, last_seen DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var facts []Fact
+	for rows.Next() {
+		var f Fact
+		if err := rows.Scan(&f.ID, &f.Key, &f.Content, &f.Project, &f.FirstSeen, &f.LastSeen, &f.SeenCount); err != nil {
+			return nil, err
+		}
+		facts = append(facts, f)
+	}
+	return facts, rows.Err()
+}
+
+// DeleteFact removes a fact by ID.
+func (s *Store) DeleteFact(id int64) error {
+	return s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("delete fact: begin tx: %w", err)
+		}
+		defer tx.Rollback()
+
+		var key string
+		err = tx.QueryRow("SELECT key FROM facts WHERE id = ?", id).Scan(&key)
+		if err != nil {
+			return fmt.Errorf("delete fact: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM facts WHERE id = ?", id); err != nil {
+			return fmt.Errorf("delete fact: %w", err)
+		}
+		if err := s.writeAudit(tx, AuditDeleteFact, strconv.FormatInt(id, 10), key); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
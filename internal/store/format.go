@@ -0,0 +1,75 @@
+package store
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// ─── Content Format Detection ────────────────────────────────────────────────
+//
+// Tool observations often hold JSON payloads or diffs as raw strings. We tag
+// each observation with a format so viewers (the TUI detail view) can
+// pretty-print it instead of showing an unreadable blob.
+
+// Content formats stored in observations.content_format.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatDiff = "diff"
+	FormatCode = "code"
+)
+
+func validContentFormat(f string) bool {
+	switch f {
+	case FormatText, FormatJSON, FormatDiff, FormatCode:
+		return true
+	}
+	return false
+}
+
+// codeLineRegex matches lines that look like source code: statement ends,
+// block braces, or common declaration keywords.
+var codeLineRegex = regexp.MustCompile(
+	`(^\s*(func|def|class|import|package|return|if|for|while|const|let|var|fn|pub|public|private|#include)\b)|([;{}]\s*$)`,
+)
+
+// DetectContentFormat guesses the format of an observation's content.
+func DetectContentFormat(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return FormatText
+	}
+
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		return FormatJSON
+	}
+
+	lines := strings.Split(trimmed, "\n")
+	if strings.HasPrefix(trimmed, "diff --git") ||
+		(len(lines) >= 2 && strings.HasPrefix(lines[0], "--- ") && strings.HasPrefix(lines[1], "+++ ")) {
+		return FormatDiff
+	}
+	for _, l := range lines {
+		if strings.HasPrefix(l, "@@ ") && strings.Contains(l[3:], " @@") {
+			return FormatDiff
+		}
+	}
+
+	// Code: at least 3 non-empty lines and half of them look like code
+	nonEmpty, codeLike := 0, 0
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		nonEmpty++
+		if codeLineRegex.MatchString(l) {
+			codeLike++
+		}
+	}
+	if nonEmpty >= 3 && codeLike*2 >= nonEmpty {
+		return FormatCode
+	}
+
+	return FormatText
+}
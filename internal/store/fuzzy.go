@@ -0,0 +1,163 @@
+package store
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ─── Fuzzy Fallback ──────────────────────────────────────────────────────────
+//
+// FTS matches whole tokens, so "athentication" finds nothing even with
+// "authentication" memories around. With SearchOptions.Fuzzy, a query that
+// matches nothing is retried against observation titles by edit distance:
+// each query word is paired with its closest title word, and a title scores
+// the average similarity (1 - distance / longer length) of those pairs.
+// It's a scan over every title that passes the filters, which is why it's
+// opt-in and only runs when FTS came back empty.
+
+// fuzzyThreshold is the lowest title score that counts as a match: about one
+// typo in a four-letter word, or three in a twelve-letter one.
+const fuzzyThreshold = 0.75
+
+// fuzzyMinWordLength skips query words too short to compare meaningfully.
+const fuzzyMinWordLength = 3
+
+// fuzzyPage is searchPage's fallback when FTS found nothing for query.
+func (s *Store) fuzzyPage(query string, opts SearchOptions, limit int) (*SearchResponse, error) {
+	var terms []string
+	for _, w := range fuzzyWords(query) {
+		if len([]rune(w)) >= fuzzyMinWordLength {
+			terms = append(terms, w)
+		}
+	}
+	resp := &SearchResponse{Fuzzy: true}
+	if len(terms) == 0 {
+		return resp, nil
+	}
+
+	from, args, _ := s.searchSource("", opts)
+	rows, err := s.db.Query("SELECT o.id, o.title"+from, args...)
+	if err != nil {
+		return nil, fmt.Errorf("fuzzy search: %w", err)
+	}
+	type match struct {
+		id    int64
+		score float64
+	}
+	var matches []match
+	for rows.Next() {
+		var id int64
+		var title string
+		if err := rows.Scan(&id, &title); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if score := fuzzyScore(terms, fuzzyWords(title)); score >= fuzzyThreshold {
+			matches = append(matches, match{id, score})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fuzzy search: %w", err)
+	}
+
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(b.id, a.id))
+	})
+	resp.FuzzyTotal = len(matches)
+	matches = matches[min(max(opts.Offset, 0), len(matches)):]
+	if len(matches) > limit {
+		matches = matches[:limit]
+		resp.HasMore = true
+	}
+
+	ids := make([]int64, len(matches))
+	for i, m := range matches {
+		ids[i] = m.id
+	}
+	observations, err := s.GetObservations(ids)
+	if err != nil {
+		return nil, fmt.Errorf("fuzzy search: %w", err)
+	}
+	score := make(map[int64]float64, len(matches))
+	for _, m := range matches {
+		score[m.id] = m.score
+	}
+	for _, o := range observations {
+		// Negated so lower is better, as with bm25
+		resp.Results = append(resp.Results, SearchResult{Observation: o, Rank: -score[o.ID]})
+	}
+	resp.Returned = len(resp.Results)
+	return resp, nil
+}
+
+// fuzzyWords lowercases text and splits it into words of letters and digits.
+func fuzzyWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// fuzzyScore is the average, over terms, of each term's best similarity to
+// one of words.
+func fuzzyScore(terms, words []string) float64 {
+	if len(words) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, t := range terms {
+		best := 0.0
+		for _, w := range words {
+			best = max(best, similarity(t, w))
+			if best == 1 {
+				break
+			}
+		}
+		total += best
+	}
+	return total / float64(len(terms))
+}
+
+// similarity is 1 - levenshtein(a, b) / the longer length, in [0, 1]. Words
+// whose lengths alone rule out reaching fuzzyThreshold score 0 without
+// computing the distance.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longer := max(len(ra), len(rb))
+	if longer == 0 {
+		return 1
+	}
+	diff := len(ra) - len(rb)
+	if diff < 0 {
+		diff = -diff
+	}
+	if 1-float64(diff)/float64(longer) < fuzzyThreshold {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longer)
+}
+
+// levenshtein counts the single-rune insertions, deletions and substitutions
+// turning a into b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
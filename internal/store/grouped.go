@@ -0,0 +1,118 @@
+package store
+
+import "encoding/json"
+
+// ─── Grouped Export ──────────────────────────────────────────────────────────
+//
+// ExportData is three flat arrays tied together by session_id, which is easy
+// to import but tedious to read. GroupedExport nests each session's
+// observations and prompts under it — same data, one object per session.
+
+// GroupedExportFormat marks a GroupedExport so imports can tell it apart from
+// a flat ExportData.
+const GroupedExportFormat = "grouped"
+
+type GroupedExport struct {
+	Version    string         `json:"version"`
+	Format     string         `json:"format"`
+	ExportedAt string         `json:"exported_at"`
+	Sessions   []SessionGroup `json:"sessions"`
+}
+
+type SessionGroup struct {
+	Session
+	Observations []Observation `json:"observations"`
+	Prompts      []Prompt      `json:"prompts"`
+}
+
+// Grouped nests observations and prompts under their sessions, keeping the
+// export's ordering within each session.
+func (d *ExportData) Grouped() *GroupedExport {
+	g := &GroupedExport{
+		Version:    d.Version,
+		Format:     GroupedExportFormat,
+		ExportedAt: d.ExportedAt,
+		Sessions:   make([]SessionGroup, len(d.Sessions)),
+	}
+	index := make(map[string]int, len(d.Sessions))
+	for i, sess := range d.Sessions {
+		g.Sessions[i] = SessionGroup{Session: sess, Observations: []Observation{}, Prompts: []Prompt{}}
+		index[sess.ID] = i
+	}
+
+	// Rows whose session isn't in the export get a stub so nothing is lost
+	group := func(sessionID string) *SessionGroup {
+		i, ok := index[sessionID]
+		if !ok {
+			i = len(g.Sessions)
+			index[sessionID] = i
+			g.Sessions = append(g.Sessions, SessionGroup{
+				Session:      Session{ID: sessionID},
+				Observations: []Observation{},
+				Prompts:      []Prompt{},
+			})
+		}
+		return &g.Sessions[i]
+	}
+	for _, o := range d.Observations {
+		sg := group(o.SessionID)
+		sg.Observations = append(sg.Observations, o)
+	}
+	for _, p := range d.Prompts {
+		sg := group(p.SessionID)
+		sg.Prompts = append(sg.Prompts, p)
+	}
+	return g
+}
+
+// Flatten turns a grouped export back into ExportData for Import. Nested rows
+// inherit their session's ID, so a hand-edited file doesn't need to repeat it.
+func (g *GroupedExport) Flatten() *ExportData {
+	d := &ExportData{
+		Version:      g.Version,
+		ExportedAt:   g.ExportedAt,
+		Sessions:     []Session{},
+		Observations: []Observation{},
+		Prompts:      []Prompt{},
+	}
+	for _, sg := range g.Sessions {
+		d.Sessions = append(d.Sessions, sg.Session)
+		for _, o := range sg.Observations {
+			o.SessionID = sg.ID
+			d.Observations = append(d.Observations, o)
+		}
+		for _, p := range sg.Prompts {
+			p.SessionID = sg.ID
+			d.Prompts = append(d.Prompts, p)
+		}
+	}
+	if d.ExportedAt == "" {
+		d.ExportedAt = Now()
+	}
+	return d
+}
+
+// DecodeExport parses either a flat ExportData or a GroupedExport document,
+// telling them apart by the "format" field.
+func DecodeExport(raw []byte) (*ExportData, error) {
+	var probe struct {
+		Format string `json:"format"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, err
+	}
+
+	if probe.Format == GroupedExportFormat {
+		var g GroupedExport
+		if err := json.Unmarshal(raw, &g); err != nil {
+			return nil, err
+		}
+		return g.Flatten(), nil
+	}
+
+	var data ExportData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ─── Links ───────────────────────────────────────────────────────────────────
+//
+// Links record how two observations relate — "#42 caused #57", "#57 fixes
+// #42" — so an agent can follow cause and effect instead of only looking at
+// chronological neighbors. They live in observation_links, one row per
+// (from, to, relation), and are directed: the relation reads from → to.
+
+// DefaultRelation is used when a link is made without naming a relation.
+const DefaultRelation = "related"
+
+// maxRelationLength caps a relation name; it's a label, not a description.
+const maxRelationLength = 64
+
+// Link is one directed relationship between two observations. The titles
+// are filled in so a list of links reads without further lookups.
+type Link struct {
+	FromID    int64  `json:"from_id"`
+	ToID      int64  `json:"to_id"`
+	Relation  string `json:"relation"`
+	FromTitle string `json:"from_title"`
+	ToTitle   string `json:"to_title"`
+	CreatedAt string `json:"created_at"`
+}
+
+// normalizeRelation lowercases a relation and joins its words with dashes:
+// "Caused By" → "caused-by". Empty means DefaultRelation.
+func normalizeRelation(relation string) string {
+	relation = strings.Join(strings.Fields(strings.ToLower(relation)), "-")
+	if relation == "" {
+		return DefaultRelation
+	}
+	return relation
+}
+
+// LinkObservations records that from relates to to, e.g.
+// LinkObservations(42, 57, "caused"). Both observations must exist and be
+// different. Adding a link that already exists is a no-op.
+func (s *Store) LinkObservations(from, to int64, relation string) error {
+	relation = normalizeRelation(relation)
+	if len(relation) > maxRelationLength {
+		return fmt.Errorf("relation is too long (max %d characters)", maxRelationLength)
+	}
+	if from == to {
+		return fmt.Errorf("can't link observation #%d to itself", from)
+	}
+	for _, id := range []int64{from, to} {
+		var exists int
+		err := s.db.QueryRow("SELECT 1 FROM observations WHERE id = ?", id).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("observation #%d not found", id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := s.exec(
+		"INSERT OR IGNORE INTO observation_links (from_id, to_id, relation) VALUES (?, ?, ?)",
+		from, to, relation,
+	)
+	return err
+}
+
+// GetLinks returns every link touching an observation, in either direction,
+// oldest first. Check Link.FromID to tell outgoing links from incoming ones.
+func (s *Store) GetLinks(id int64) ([]Link, error) {
+	var exists int
+	err := s.db.QueryRow("SELECT 1 FROM observations WHERE id = ?", id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("observation #%d not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT l.from_id, l.to_id, l.relation, f.title, t.title, l.created_at
+		FROM observation_links l
+		JOIN observations f ON f.id = l.from_id
+		JOIN observations t ON t.id = l.to_id
+		WHERE l.from_id = ? OR l.to_id = ?
+		ORDER BY l.created_at, l.rowid`, id, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []Link
+	for rows.Next() {
+		var l Link
+		if err := rows.Scan(&l.FromID, &l.ToID, &l.Relation, &l.FromTitle, &l.ToTitle, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
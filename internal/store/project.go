@@ -0,0 +1,121 @@
+package store
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ─── Project Detection ───────────────────────────────────────────────────────
+//
+// The CLI files memories under the project it's run in. Using the working
+// directory's name splits one repo into "engram", "store" and "cmd"
+// depending on where the command ran, so detection goes by the git repo
+// instead: the name in origin's URL if there is one, since that's the same
+// on every clone, else the name of the directory holding .git.
+
+// DetectProject returns the project name for the current directory: the
+// slug of the enclosing git repo's origin remote, else the repo root's
+// directory name, else the directory's own name. It returns "" only if the
+// working directory can't be read.
+func DetectProject() string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return detectProject(cwd)
+}
+
+func detectProject(dir string) string {
+	root, gitDir := findGitRoot(dir)
+	if root == "" {
+		return filepath.Base(dir)
+	}
+	if slug := remoteSlug(gitConfigPath(gitDir)); slug != "" {
+		return slug
+	}
+	return filepath.Base(root)
+}
+
+// findGitRoot walks up from dir to the first directory containing .git and
+// returns it with the path of its git directory. .git is a file pointing
+// elsewhere ("gitdir: ...") in worktrees and submodules.
+func findGitRoot(dir string) (root, gitDir string) {
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return dir, dotGit
+			}
+			if raw, err := os.ReadFile(dotGit); err == nil {
+				if target, ok := strings.CutPrefix(strings.TrimSpace(string(raw)), "gitdir:"); ok {
+					target = strings.TrimSpace(target)
+					if !filepath.IsAbs(target) {
+						target = filepath.Join(dir, target)
+					}
+					return dir, target
+				}
+			}
+			return dir, ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// gitConfigPath returns the config file for gitDir. A worktree's git
+// directory names the shared one in its commondir file.
+func gitConfigPath(gitDir string) string {
+	if gitDir == "" {
+		return ""
+	}
+	if raw, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(raw))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		return filepath.Join(common, "config")
+	}
+	return filepath.Join(gitDir, "config")
+}
+
+// remoteSlug reads the origin remote's URL from a git config file and returns
+// the repo name at the end of it: "engram" for both
+// git@github.com:owner/engram.git and https://github.com/owner/engram.
+func remoteSlug(configPath string) string {
+	if configPath == "" {
+		return ""
+	}
+	f, err := os.Open(configPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	inOrigin := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inOrigin = line == `[remote "origin"]`
+			continue
+		}
+		if !inOrigin {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) != "url" {
+			continue
+		}
+		url := strings.TrimSuffix(strings.TrimRight(strings.TrimSpace(value), "/"), ".git")
+		if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+			url = url[i+1:]
+		}
+		return url
+	}
+	return ""
+}
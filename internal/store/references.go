@@ -0,0 +1,142 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ─── References ──────────────────────────────────────────────────────────────
+//
+// References link an observation to things outside engram: issue URLs, docs,
+// PRs, or short issue IDs like "owner/repo#123". They live in
+// observation_references, one row per (observation, reference), and are kept
+// verbatim apart from trimming — URLs are case-sensitive.
+
+// maxReferenceLength caps a single reference. Anything longer is almost
+// certainly pasted content, not a link.
+const maxReferenceLength = 2048
+
+// urlRegex matches http(s) URLs up to the next whitespace or closing
+// bracket/quote. Trailing sentence punctuation is trimmed afterwards.
+var urlRegex = regexp.MustCompile(`https?://[^\s<>"'()\[\]{}]+`)
+
+// issueRefRegex matches GitHub-style "owner/repo#123" issue references at a
+// word boundary.
+var issueRefRegex = regexp.MustCompile(`(?:^|[\s(\[{,;])([A-Za-z0-9][\w.-]*/[\w.-]+#[0-9]+)\b`)
+
+// extractReferences returns the URLs and owner/repo#N issue references in
+// content, in order of appearance.
+func extractReferences(content string) []string {
+	var refs []string
+	for _, m := range urlRegex.FindAllString(content, -1) {
+		refs = append(refs, m)
+	}
+	for _, m := range issueRefRegex.FindAllStringSubmatch(content, -1) {
+		refs = append(refs, m[1])
+	}
+	return refs
+}
+
+// normalizeReferences trims whitespace and trailing punctuation, drops empty
+// or oversized entries, and dedupes, keeping first-seen order.
+func normalizeReferences(refs []string) []string {
+	seen := make(map[string]bool, len(refs))
+	var out []string
+	for _, r := range refs {
+		r = strings.TrimRight(strings.TrimSpace(r), ".,;:!?")
+		if r == "" || len(r) > maxReferenceLength || seen[r] {
+			continue
+		}
+		seen[r] = true
+		out = append(out, r)
+	}
+	return out
+}
+
+// insertReferences records references for an observation; duplicates are
+// ignored.
+func insertReferences(x execer, obsID int64, refs []string) error {
+	for _, r := range refs {
+		if _, err := x.Exec(
+			"INSERT OR IGNORE INTO observation_references (observation_id, ref) VALUES (?, ?)", obsID, r,
+		); err != nil {
+			return fmt.Errorf("insert reference %q: %w", r, err)
+		}
+	}
+	return nil
+}
+
+// AddReference links an existing observation to a URL or issue ID. Adding a
+// reference the observation already has is a no-op.
+func (s *Store) AddReference(obsID int64, ref string) error {
+	refs := normalizeReferences([]string{ref})
+	if len(refs) == 0 {
+		return fmt.Errorf("invalid reference %q", ref)
+	}
+
+	var exists int
+	err := s.db.QueryRow("SELECT 1 FROM observations WHERE id = ?", obsID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("observation #%d not found", obsID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.withRetry(func() error {
+		return insertReferences(s.db, obsID, refs)
+	})
+}
+
+// ObservationReferences returns an observation's references in the order
+// they were added.
+func (s *Store) ObservationReferences(id int64) ([]string, error) {
+	rows, err := s.db.Query(
+		"SELECT ref FROM observation_references WHERE observation_id = ? ORDER BY rowid", id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []string
+	for rows.Next() {
+		var r string
+		if err := rows.Scan(&r); err != nil {
+			return nil, err
+		}
+		refs = append(refs, r)
+	}
+	return refs, rows.Err()
+}
+
+// attachReferences fills in References for a batch of observations with one
+// query.
+func (s *Store) attachReferences(obs []Observation) error {
+	if len(obs) == 0 {
+		return nil
+	}
+	index, lo, hi := indexObservations(obs)
+
+	rows, err := s.db.Query(
+		"SELECT observation_id, ref FROM observation_references WHERE observation_id BETWEEN ? AND ? ORDER BY rowid", lo, hi,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var r string
+		if err := rows.Scan(&id, &r); err != nil {
+			return err
+		}
+		if i, ok := index[id]; ok {
+			obs[i].References = append(obs[i].References, r)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// ─── Search Cache ────────────────────────────────────────────────────────────
+//
+// An optional LRU of SearchPage responses (Config.SearchCache), for clients
+// that repeat the same query — agents re-asking for context, UIs re-running
+// a search. Entries expire after Config.SearchCacheTTL.
+//
+// Invalidation doesn't rely on remembering every write path: the cache holds
+// one dedicated connection and checks PRAGMA data_version on it before each
+// lookup. SQLite changes that value whenever any *other* connection commits —
+// this process's pool or another process sharing the DB — so any write
+// anywhere flushes the cache before stale results can be served. The
+// dedicated connection itself never writes.
+
+type searchCache struct {
+	mu      sync.Mutex
+	conn    *sql.Conn // only used for PRAGMA data_version
+	size    int
+	ttl     time.Duration
+	version int64 // data_version the current entries were computed at
+
+	entries map[string]*list.Element // key → element holding *cacheEntry
+	order   *list.List               // front = most recently used
+
+	hits, misses uint64
+}
+
+type cacheEntry struct {
+	key    string
+	resp   *SearchResponse
+	stored time.Time
+}
+
+// SearchCacheStats reports search cache effectiveness since the store was
+// opened.
+type SearchCacheStats struct {
+	Size    int     `json:"size"` // capacity in entries
+	Entries int     `json:"entries"`
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	HitRate float64 `json:"hit_rate"` // hits / (hits + misses), 0 when unused
+}
+
+func newSearchCache(db *sql.DB, size int, ttl time.Duration) (*searchCache, error) {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	c := &searchCache{
+		conn:    conn,
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+	if c.version, err = c.dataVersion(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *searchCache) dataVersion() (int64, error) {
+	var v int64
+	err := c.conn.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&v)
+	return v, err
+}
+
+// searchCacheKey identifies a search by its query and every option.
+func searchCacheKey(query string, opts SearchOptions) string {
+	b, _ := json.Marshal(struct {
+		Q string        `json:"q"`
+		O SearchOptions `json:"o"`
+	}{query, opts})
+	return string(b)
+}
+
+// get returns a copy of a fresh cached response. It also returns the data
+// version observed, which the caller passes to put so a result computed
+// while a write landed is never stored. ok is false on a miss; version is
+// -1 if it couldn't be read, which makes put a no-op.
+func (c *searchCache) get(key string) (resp *SearchResponse, version int64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, err := c.dataVersion()
+	if err != nil {
+		c.misses++
+		return nil, -1, false
+	}
+	if v != c.version {
+		c.flushLocked()
+		c.version = v
+	}
+
+	el, found := c.entries[key]
+	if !found {
+		c.misses++
+		return nil, v, false
+	}
+	e := el.Value.(*cacheEntry)
+	if time.Since(e.stored) > c.ttl {
+		c.removeLocked(el)
+		c.misses++
+		return nil, v, false
+	}
+
+	c.order.MoveToFront(el)
+	c.hits++
+	return e.resp.clone(), v, true
+}
+
+// put stores a response computed at the given data version.
+func (c *searchCache) put(key string, version int64, resp *SearchResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version != c.version {
+		return
+	}
+	if el, found := c.entries[key]; found {
+		c.removeLocked(el)
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, resp: resp.clone(), stored: time.Now()})
+	for c.order.Len() > c.size {
+		c.removeLocked(c.order.Back())
+	}
+}
+
+func (c *searchCache) removeLocked(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+}
+
+func (c *searchCache) flushLocked() {
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+func (c *searchCache) stats() SearchCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st := SearchCacheStats{Size: c.size, Entries: c.order.Len(), Hits: c.hits, Misses: c.misses}
+	if total := c.hits + c.misses; total > 0 {
+		st.HitRate = float64(c.hits) / float64(total)
+	}
+	return st
+}
+
+func (c *searchCache) close() error {
+	return c.conn.Close()
+}
+
+// clone copies a response so callers can modify results without touching
+// the cached entry.
+func (r *SearchResponse) clone() *SearchResponse {
+	cp := *r
+	cp.Results = append([]SearchResult(nil), r.Results...)
+	return &cp
+}
+
+// SearchCacheStats returns hit/miss counts for the search cache, or nil when
+// Config.SearchCache is off.
+func (s *Store) SearchCacheStats() *SearchCacheStats {
+	if s.searchCache == nil {
+		return nil
+	}
+	st := s.searchCache.stats()
+	return &st
+}
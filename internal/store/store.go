@@ -6,14 +6,31 @@
 package store
 
 import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/google/uuid"
 	_ "modernc.org/sqlite"
 )
 
@@ -29,19 +46,55 @@ type Session struct {
 }
 
 type Observation struct {
-	ID        int64   `json:"id"`
-	SessionID string  `json:"session_id"`
-	Type      string  `json:"type"`
-	Title     string  `json:"title"`
-	Content   string  `json:"content"`
-	ToolName  *string `json:"tool_name,omitempty"`
-	Project   *string `json:"project,omitempty"`
-	CreatedAt string  `json:"created_at"`
+	ID         int64   `json:"id"`
+	UID        string  `json:"uid,omitempty"` // stable across export/import; ID is local
+	SessionID  string  `json:"session_id"`
+	Type       string  `json:"type"`
+	Title      string  `json:"title"`
+	Content    string  `json:"content"`
+	ToolName   *string `json:"tool_name,omitempty"`
+	Project    *string `json:"project,omitempty"`
+	Author     *string `json:"author,omitempty"` // who recorded it, on a shared database
+	Status     *string `json:"status,omitempty"` // task status: pending, in-progress, done
+	Importance int     `json:"importance,omitempty"`
+	Format     *string `json:"content_format,omitempty"` // text, json, diff, code
+	PromptID   *int64  `json:"prompt_id,omitempty"`      // user prompt that led to this
+	Archived   bool    `json:"archived,omitempty"`       // hidden from search and context
+	Pinned     bool    `json:"pinned,omitempty"`         // kept by Prune, listed first in context
+	CreatedAt  string  `json:"created_at"`
+	UpdatedAt  string  `json:"updated_at"` // last edit, status change, pin or archive; created_at until then
+	// OccurredAt is when what it records happened: AddObservationParams.
+	// OccurredAt for backfilled history, else created_at. Timelines and
+	// recent lists are ordered by it.
+	OccurredAt string `json:"occurred_at,omitempty"`
+
+	// Tags and References are only filled in by GetObservation and Export.
+	Tags       []string `json:"tags,omitempty"`
+	References []string `json:"references,omitempty"`
+
+	// Read tracking (Config.TrackAccess): how often this was retrieved.
+	AccessCount    int     `json:"access_count,omitempty"`
+	LastAccessedAt *string `json:"last_accessed_at,omitempty"`
 }
 
 type SearchResult struct {
 	Observation
-	Rank float64 `json:"rank"`
+	Rank    float64          `json:"rank"`
+	Explain *RankExplanation `json:"explain,omitempty"` // only with SearchOptions.Explain
+}
+
+// RankExplanation breaks a search rank down per FTS column. Lower (more
+// negative) BM25 is a better match, same as Rank.
+type RankExplanation struct {
+	Columns []ColumnScore `json:"columns"`
+}
+
+// ColumnScore is one FTS column's BM25 contribution and the query terms
+// found in that column.
+type ColumnScore struct {
+	Column string   `json:"column"`
+	BM25   float64  `json:"bm25"`
+	Terms  []string `json:"terms,omitempty"`
 }
 
 type SessionSummary struct {
@@ -54,22 +107,47 @@ type SessionSummary struct {
 }
 
 type Stats struct {
+	// Project is only set by ProjectStats, which also fills in the date
+	// range of the project's sessions, observations and prompts.
+	Project string `json:"project,omitempty"`
+	FirstAt string `json:"first_at,omitempty"`
+	LastAt  string `json:"last_at,omitempty"`
+
 	TotalSessions     int      `json:"total_sessions"`
 	TotalObservations int      `json:"total_observations"`
 	TotalPrompts      int      `json:"total_prompts"`
 	Projects          []string `json:"projects"`
+	// ByType counts observations per type, to show where memory piles up.
+	ByType map[string]int `json:"by_type"`
+
+	// SearchCache is only set when Config.SearchCache is on.
+	SearchCache *SearchCacheStats `json:"search_cache,omitempty"`
+}
+
+// ProjectSummary is the "catch me up" overview of one project.
+type ProjectSummary struct {
+	Project      string `json:"project"`
+	Sessions     int    `json:"sessions"`
+	Observations int    `json:"observations"`
+	Prompts      int    `json:"prompts"`
+	OpenTasks    int    `json:"open_tasks"`
+	FirstAt      string `json:"first_at,omitempty"`
+	LastAt       string `json:"last_at,omitempty"`
+
+	TopTypes       []TypeCount      `json:"top_types"`
+	KeyDecisions   []Observation    `json:"key_decisions"`
+	RecentSessions []SessionSummary `json:"recent_sessions"`
+	RecentActivity []Observation    `json:"recent_activity"`
+}
+
+type TypeCount struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
 }
 
 type TimelineEntry struct {
-	ID        int64   `json:"id"`
-	SessionID string  `json:"session_id"`
-	Type      string  `json:"type"`
-	Title     string  `json:"title"`
-	Content   string  `json:"content"`
-	ToolName  *string `json:"tool_name,omitempty"`
-	Project   *string `json:"project,omitempty"`
-	CreatedAt string  `json:"created_at"`
-	IsFocus   bool    `json:"is_focus"` // true for the anchor observation
+	Observation
+	IsFocus bool `json:"is_focus"` // true for the anchor observation
 }
 
 type TimelineResult struct {
@@ -78,21 +156,131 @@ type TimelineResult struct {
 	After        []TimelineEntry `json:"after"`        // Observations after the focus (chronological)
 	SessionInfo  *Session        `json:"session_info"` // Session that contains the focus observation
 	TotalInRange int             `json:"total_in_range"`
+	Links        []Link          `json:"links,omitempty"` // Links to and from the focus, across sessions
+}
+
+// SessionTimelineResult is the full, unwindowed replay of one session.
+type SessionTimelineResult struct {
+	Session      *Session        `json:"session"`
+	Observations []TimelineEntry `json:"observations"` // All observations, chronological
+	Prompts      []Prompt        `json:"prompts"`      // All user prompts, chronological
+	Total        int             `json:"total"`
 }
 
 type SearchOptions struct {
-	Type    string `json:"type,omitempty"`
-	Project string `json:"project,omitempty"`
-	Limit   int    `json:"limit,omitempty"`
+	Type string `json:"type,omitempty"`
+	// Types restricts results to any of these observation types. Type, if
+	// set, is treated as one more entry.
+	Types   []string `json:"types,omitempty"`
+	Project string   `json:"project,omitempty"`
+	// Projects searches several projects at once: results may come from
+	// any of them. Project, if set, is treated as one more entry.
+	Projects []string `json:"projects,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+
+	// Author restricts results to observations recorded by this author.
+	Author string `json:"author,omitempty"`
+
+	// SessionID restricts results to one session, and SearchAll to that
+	// session's prompts.
+	SessionID string `json:"session_id,omitempty"`
+
+	// ExcludeTerms drops results matching any of these terms (FTS NOT).
+	ExcludeTerms []string `json:"exclude_terms,omitempty"`
+	// ExcludeTypes drops results with any of these observation types.
+	ExcludeTypes []string `json:"exclude_types,omitempty"`
+
+	// Tag restricts results to observations carrying this tag.
+	Tag string `json:"tag,omitempty"`
+	// Tags restricts results to observations carrying all of these tags.
+	// Tag, if set, is treated as one more entry.
+	Tags []string `json:"tags,omitempty"`
+
+	// Offset skips that many results, for paging: request the next page
+	// with Offset += Limit while SearchResponse.HasMore is true.
+	Offset int `json:"offset,omitempty"`
+
+	// Explain populates SearchResult.Explain with per-column BM25 scores
+	// and matched terms. Diagnostic only — costs one bm25() call per column.
+	Explain bool `json:"explain,omitempty"`
+
+	// IncludeArchived returns archived observations too; by default they
+	// are left out.
+	IncludeArchived bool `json:"include_archived,omitempty"`
+
+	// Raw passes the query to FTS5 untouched — full MATCH syntax (NEAR, OR,
+	// column filters) for power users, and syntax errors are theirs to fix.
+	Raw bool `json:"raw,omitempty"`
+
+	// RecencyWeight, from 0 to 1, blends relevance with age so a strong
+	// recent match can beat a perfect old one. Each candidate scores
+	//
+	//	(1 - w) * relevance + w * 0.5^(age / 30 days)
+	//
+	// where relevance is its boosted BM25 divided by the best candidate's
+	// (1 for the top match). Candidates are the FTS top matches, several
+	// pages deep; Rank stays the raw BM25. Zero keeps pure FTS order.
+	RecencyWeight float64 `json:"recency_weight,omitempty"`
+
+	// Fuzzy retries a query that matches nothing against titles by edit
+	// distance, so typos still find something (see SearchResponse.Fuzzy).
+	// It doesn't apply to Raw queries.
+	Fuzzy bool `json:"fuzzy,omitempty"`
 }
 
 type AddObservationParams struct {
-	SessionID string `json:"session_id"`
-	Type      string `json:"type"`
-	Title     string `json:"title"`
-	Content   string `json:"content"`
-	ToolName  string `json:"tool_name,omitempty"`
-	Project   string `json:"project,omitempty"`
+	SessionID  string `json:"session_id"`
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	ToolName   string `json:"tool_name,omitempty"`
+	Project    string `json:"project,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Importance *int   `json:"importance,omitempty"` // 0 to 5 (critical); nil = the type's default
+	// ContentFormat hints how content should be rendered (text, json, diff,
+	// code). Detected from the content when empty.
+	ContentFormat string `json:"content_format,omitempty"`
+	// Tags to record with the observation. With Config.ExtractHashtags,
+	// #hashtags in content are added too.
+	Tags []string `json:"tags,omitempty"`
+	// References are URLs or issue IDs the observation relates to. With
+	// Config.ExtractReferences, URLs and owner/repo#N in content are added.
+	References []string `json:"references,omitempty"`
+	// PromptID attributes the observation to the user prompt that triggered
+	// it. The prompt must exist and belong to the same session.
+	PromptID int64 `json:"prompt_id,omitempty"`
+	// Author records who saved the observation. Empty uses Config.Author.
+	Author string `json:"author,omitempty"`
+	// OccurredAt is when it happened, for history recorded after the fact
+	// (RFC 3339, "2006-01-02 15:04:05" UTC, or a date). Empty means now.
+	// created_at is always the insert time.
+	OccurredAt string `json:"occurred_at,omitempty"`
+
+	// fullContent is the uncut content to store compressed, set by
+	// prepareObservation when Content was truncated with CompressContent.
+	fullContent string
+}
+
+// Task statuses. An observation with a non-NULL status is tracked as a task;
+// anything not yet "done" shows up in OpenTasks and in FormatContext.
+const (
+	StatusPending    = "pending"
+	StatusInProgress = "in-progress"
+	StatusDone       = "done"
+)
+
+// ValidStatus reports whether status is one of the known task statuses.
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusInProgress, StatusDone:
+		return true
+	}
+	return false
+}
+
+// isTaskType reports whether observations of this type are tasks by default.
+func isTaskType(typ string) bool {
+	return typ == "task" || typ == "todo"
 }
 
 type Prompt struct {
@@ -121,10 +309,137 @@ type ExportData struct {
 // ─── Config ──────────────────────────────────────────────────────────────────
 
 type Config struct {
-	DataDir              string
+	DataDir string
+	// Profile selects a fully independent database under
+	// DataDir/profiles/<name> instead of DataDir itself. Empty (or
+	// DefaultProfile) uses the default database.
+	Profile string
+
 	MaxObservationLength int
 	MaxContextResults    int
 	MaxSearchResults     int
+	// MaxContextChars caps the size of FormatContext's output, in
+	// characters. Items are left out, least important first, until it fits.
+	// Zero means no cap.
+	MaxContextChars int
+	// MaxSummaryLength caps summaries built by SummarizeSession, in bytes.
+	MaxSummaryLength int
+
+	// Author is recorded on observations saved without one. The CLI sets
+	// it to the current user ($ENGRAM_AUTHOR, else the login name).
+	Author string
+
+	// CompressContent keeps content longer than MaxObservationLength in
+	// full, gzipped in a separate table, instead of dropping what doesn't
+	// fit. The observation row, and so search, still holds the truncated
+	// text; GetObservation and exports return the whole thing.
+	CompressContent bool
+
+	// BusyTimeoutMs is how long SQLite itself waits on a locked database
+	// before returning SQLITE_BUSY. MaxRetries is how many more times write
+	// statements are retried, with backoff, after that.
+	BusyTimeoutMs int
+	MaxRetries    int
+
+	// SQLite tuning. CacheSizeKB maps to PRAGMA cache_size (page cache per
+	// connection) and MmapSizeMB to PRAGMA mmap_size. Zero keeps SQLite's
+	// built-in default, which is tiny for large memory databases.
+	CacheSizeKB int
+	MmapSizeMB  int
+
+	// GlobalInsightMinImportance is the importance threshold for project-less
+	// observations to be injected into every project's context.
+	GlobalInsightMinImportance int
+
+	// TypeImportance is the importance an observation of a given type gets
+	// when saved without one (importance 0). Types not listed stay at 0.
+	TypeImportance map[string]int
+
+	// RankWeights are the BM25 weights for the title, content, tool_name,
+	// type and project columns, in that order. Empty weights every column
+	// equally.
+	RankWeights []float64
+
+	// SearchCache keeps up to this many SearchPage responses in an LRU,
+	// each for at most SearchCacheTTL. Any committed write to the database,
+	// from this process or another, empties it. Zero disables caching, and
+	// so does TrackAccess: its access-count writes would empty the cache on
+	// nearly every read, and rankings depend on those counts.
+	SearchCache    int
+	SearchCacheTTL time.Duration
+
+	// Buffered write mode. When BatchWindow > 0, AddObservation enqueues rows
+	// that a background goroutine inserts in a single transaction every
+	// BatchWindow or every BatchSize rows, whichever comes first.
+	BatchWindow time.Duration
+	BatchSize   int
+
+	// DedupWindow makes saves skip an observation identical to one already
+	// saved (same session, type, title and content) within this long, and
+	// return the existing ID instead. Zero disables deduplication.
+	DedupWindow time.Duration
+
+	// AutoTitleWords is how many words of content become the title when an
+	// observation is saved without one. Zero disables auto-titling.
+	AutoTitleWords int
+
+	// ContextTemplate is a text/template that replaces FormatContext's
+	// built-in layout. Empty uses DefaultContextTemplate.
+	ContextTemplate string
+
+	// TrackAccess counts reads (GetObservation, timeline) per observation
+	// and lets frequently retrieved memories rank higher in search. Off by
+	// default since every read becomes a write.
+	TrackAccess bool
+
+	// StrictRedaction makes saves fail with ErrSecretDetected when content
+	// looks like it contains a secret, instead of storing it as [REDACTED].
+	StrictRedaction bool
+
+	// RedactionPatterns are extra regular expressions (RE2 syntax) treated
+	// as secrets on top of the built-in ones: each match is replaced with
+	// [REDACTED] in observations, prompts and facts. An invalid pattern
+	// makes New fail.
+	RedactionPatterns []string
+
+	// StrictTypes makes saves fail with ErrUnknownType when the type isn't
+	// one of KnownTypes, so "file-change" can't sit next to "file_change".
+	StrictTypes bool
+
+	// Search term filtering. Query words in Stopwords or shorter than
+	// MinTermLength are dropped before matching; quoted words are always
+	// kept. If nothing survives, the original query is used as-is.
+	Stopwords     []string
+	MinTermLength int
+
+	// ExtractHashtags records #hashtags found in observation content as
+	// tags at save time. The content itself is left unchanged.
+	ExtractHashtags bool
+
+	// ExtractReferences records http(s) URLs and owner/repo#N issue IDs
+	// found in observation content as references at save time.
+	ExtractReferences bool
+
+	// FTSPrefix adds FTS5 prefix indexes for these token lengths (e.g.
+	// [2, 3]) and turns unquoted search words into prefix queries, so "auth"
+	// matches "authentication". Existing databases need Reindex to build
+	// the indexes; until then prefix queries still work, just slower.
+	FTSPrefix []int
+
+	// FTSTokenizer is the FTS5 tokenize option for both full-text indexes,
+	// e.g. "porter unicode61 remove_diacritics 2" so "running" matches
+	// "run". Empty keeps FTS5's default (unicode61). SQLite can't change the
+	// tokenizer of an existing table, so changing it needs Reindex.
+	FTSTokenizer string
+
+	// TimeFormat controls how timestamps are displayed by FormatTime and
+	// the context template's when helper: TimeFormatAbsolute (default),
+	// TimeFormatRelative, or a Go time layout. Stored values are unaffected.
+	TimeFormat string
+
+	// Embedder enables SearchSemantic. Nil (the default) leaves semantic
+	// search off; nothing else depends on it.
+	Embedder Embedder
 }
 
 func DefaultConfig() Config {
@@ -134,52 +449,253 @@ func DefaultConfig() Config {
 		MaxObservationLength: 2000,
 		MaxContextResults:    20,
 		MaxSearchResults:     20,
+		MaxSummaryLength:     1000,
+		BusyTimeoutMs:        5000,
+		MaxRetries:           3,
+		CacheSizeKB:          64 * 1024, // 64 MB page cache
+		MmapSizeMB:           256,
+
+		GlobalInsightMinImportance: 3,
+		AutoTitleWords:             8,
+		Stopwords:                  DefaultStopwords,
+		MinTermLength:              2,
+		TypeImportance:             DefaultTypeImportance,
+		RankWeights:                DefaultRankWeights,
+		SearchCacheTTL:             30 * time.Second,
 	}
 }
 
+// DefaultTypeImportance ranks deliberate memories above tool noise. It stays
+// below the default GlobalInsightMinImportance (3) so a project-less
+// decision doesn't become a global insight unless someone pins it higher.
+var DefaultTypeImportance = map[string]int{
+	"decision":     2,
+	"architecture": 2,
+	"convention":   2,
+	"pattern":      1,
+	"bugfix":       1,
+	"learning":     1,
+	"manual":       1,
+}
+
+// DefaultRankWeights favor title matches: an observation titled "Migration
+// plan" should beat one that mentions migrations deep in a long payload.
+var DefaultRankWeights = []float64{10, 2, 1, 1, 1}
+
+// DefaultStopwords are common English words that match nearly every memory
+// and only add noise to full-text ranking.
+var DefaultStopwords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "did", "do", "does",
+	"for", "from", "how", "i", "in", "is", "it", "its", "of", "on", "or", "that",
+	"the", "this", "to", "was", "we", "were", "what", "when", "where", "which",
+	"who", "why", "with",
+}
+
 // ─── Store ───────────────────────────────────────────────────────────────────
 
 type Store struct {
-	db  *sql.DB
-	cfg Config
+	db     *sql.DB
+	cfg    Config
+	batch  *batchWriter // nil unless Config.BatchWindow > 0
+	events eventBus
+
+	searchCache *searchCache // nil unless Config.SearchCache > 0 and TrackAccess is off
+
+	contextTmpl *template.Template
+	stopwords   map[string]bool
+	rankExpr    string           // weighted bm25() call built from Config.RankWeights
+	redactions  []*regexp.Regexp // compiled Config.RedactionPatterns
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// withRetry runs fn, retrying up to Config.MaxRetries times with exponential
+// backoff while it fails with SQLITE_BUSY/SQLITE_LOCKED. In WAL mode readers
+// never block writers, but writers still serialize, and a write that upgrades
+// a read transaction fails immediately instead of honoring busy_timeout —
+// that's the case these retries cover.
+func (s *Store) withRetry(fn func() error) error {
+	backoff := 25 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !isBusy(err) || attempt >= s.cfg.MaxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// exec is db.Exec with busy retries, for single write statements.
+func (s *Store) exec(query string, args ...any) (sql.Result, error) {
+	var res sql.Result
+	err := s.withRetry(func() error {
+		var err error
+		res, err = s.db.Exec(query, args...)
+		return err
+	})
+	return res, err
+}
+
+// isBusy reports whether err is SQLite's "database is locked/busy".
+func isBusy(err error) bool {
+	var coded interface{ Code() int }
+	if errors.As(err, &coded) {
+		code := coded.Code() & 0xff   // strip extended result code
+		return code == 5 || code == 6 // SQLITE_BUSY, SQLITE_LOCKED
+	}
+	return false
 }
 
 func New(cfg Config) (*Store, error) {
-	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
-		return nil, fmt.Errorf("engram: create data dir: %w", err)
+	if !validTimeFormat(cfg.TimeFormat) {
+		return nil, fmt.Errorf("engram: invalid time format %q (expected absolute, relative, or a Go time layout)", cfg.TimeFormat)
+	}
+
+	if err := checkTokenizer(cfg.FTSTokenizer); err != nil {
+		return nil, fmt.Errorf("engram: fts tokenizer: %w", err)
 	}
 
-	dbPath := filepath.Join(cfg.DataDir, "engram.db")
-	db, err := sql.Open("sqlite", dbPath)
+	rankExpr, err := rankExpression(cfg.RankWeights)
 	if err != nil {
-		return nil, fmt.Errorf("engram: open database: %w", err)
+		return nil, fmt.Errorf("engram: rank weights: %w", err)
 	}
 
-	// SQLite performance pragmas
-	pragmas := []string{
-		"PRAGMA journal_mode = WAL",
-		"PRAGMA busy_timeout = 5000",
-		"PRAGMA synchronous = NORMAL",
-		"PRAGMA foreign_keys = ON",
+	contextTmpl, err := parseContextTemplate(cfg.ContextTemplate, cfg.TimeFormat)
+	if err != nil {
+		return nil, fmt.Errorf("engram: context template: %w", err)
 	}
-	for _, p := range pragmas {
-		if _, err := db.Exec(p); err != nil {
-			return nil, fmt.Errorf("engram: pragma %q: %w", p, err)
+
+	var redactions []*regexp.Regexp
+	for _, pattern := range cfg.RedactionPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("engram: redaction pattern %q: %w", pattern, err)
 		}
+		redactions = append(redactions, re)
+	}
+
+	if cfg.Profile != "" && cfg.Profile != DefaultProfile && !profileNamePattern.MatchString(cfg.Profile) {
+		return nil, fmt.Errorf("engram: invalid profile name %q (use letters, digits, '.', '-' and '_')", cfg.Profile)
+	}
+
+	dbPath := cfg.DBPath()
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("engram: create data dir: %w", err)
+	}
+
+	// busy_timeout, synchronous, foreign_keys, cache_size and mmap_size are
+	// per connection, so they go in the DSN where the driver applies them to
+	// every connection in the pool.
+	pragmas := []string{"synchronous(NORMAL)", "foreign_keys(1)"}
+	if cfg.BusyTimeoutMs > 0 {
+		pragmas = append(pragmas, fmt.Sprintf("busy_timeout(%d)", cfg.BusyTimeoutMs))
+	}
+	if cfg.CacheSizeKB > 0 {
+		// Negative cache_size is interpreted by SQLite as KiB instead of pages
+		pragmas = append(pragmas, fmt.Sprintf("cache_size(-%d)", cfg.CacheSizeKB))
+	}
+	if cfg.MmapSizeMB > 0 {
+		pragmas = append(pragmas, fmt.Sprintf("mmap_size(%d)", int64(cfg.MmapSizeMB)<<20))
+	}
+	// The path is escaped so '#', '?' and '%' in it aren't read as URI syntax
+	dsn := url.URL{Scheme: "file", Path: dbPath, RawQuery: "_pragma=" + strings.Join(pragmas, "&_pragma=")}
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("engram: open database: %w", err)
 	}
 
-	s := &Store{db: db, cfg: cfg}
+	// WAL is a property of the database file, not the connection
+	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("engram: pragma journal_mode: %w", err)
+	}
+
+	s := &Store{db: db, cfg: cfg, contextTmpl: contextTmpl, stopwords: make(map[string]bool), rankExpr: rankExpr, redactions: redactions}
+	for _, w := range cfg.Stopwords {
+		s.stopwords[strings.ToLower(w)] = true
+	}
 	if err := s.migrate(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("engram: migration: %w", err)
 	}
 
+	if cfg.SearchCache > 0 && !cfg.TrackAccess {
+		s.searchCache, err = newSearchCache(db, cfg.SearchCache, cfg.SearchCacheTTL)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("engram: search cache: %w", err)
+		}
+	}
+
+	// Last, since its goroutine has to be stopped once started
+	if cfg.BatchWindow > 0 {
+		s.batch = newBatchWriter(db, cfg.BatchWindow, cfg.BatchSize, s.withRetry)
+	}
+
 	return s, nil
 }
 
+// DefaultProfile names the database directly in DataDir, used when no
+// profile is selected.
+const DefaultProfile = "default"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// DBPath is the database file for this configuration: DataDir/engram.db,
+// or DataDir/profiles/<Profile>/engram.db for a named profile.
+func (c Config) DBPath() string {
+	if c.Profile == "" || c.Profile == DefaultProfile {
+		return filepath.Join(c.DataDir, "engram.db")
+	}
+	return filepath.Join(c.DataDir, "profiles", c.Profile, "engram.db")
+}
+
+// Profiles lists the named profiles under dataDir that have a database,
+// sorted by name. The default database isn't included.
+func Profiles(dataDir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dataDir, "profiles"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list profiles: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() || !profileNamePattern.MatchString(e.Name()) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dataDir, "profiles", e.Name(), "engram.db")); err == nil {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+// Close flushes any buffered observations and closes the database.
 func (s *Store) Close() error {
+	if s.batch != nil {
+		s.batch.close()
+	}
+	if s.searchCache != nil {
+		s.searchCache.close()
+	}
 	return s.db.Close()
 }
 
+// Ping checks that the database answers a query, for health checks.
+func (s *Store) Ping(ctx context.Context) error {
+	var one int
+	if err := s.db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+	return nil
+}
+
 // ─── Migrations ──────────────────────────────────────────────────────────────
 
 func (s *Store) migrate() error {
@@ -210,15 +726,7 @@ func (s *Store) migrate() error {
 		CREATE INDEX IF NOT EXISTS idx_obs_project  ON observations(project);
 		CREATE INDEX IF NOT EXISTS idx_obs_created  ON observations(created_at DESC);
 
-		CREATE VIRTUAL TABLE IF NOT EXISTS observations_fts USING fts5(
-			title,
-			content,
-			tool_name,
-			type,
-			project,
-			content='observations',
-			content_rowid='id'
-		);
+		` + observationsFTSTable(s.ftsOptions()) + `;
 
 		CREATE TABLE IF NOT EXISTS user_prompts (
 			id         INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -233,22 +741,171 @@ func (s *Store) migrate() error {
 		CREATE INDEX IF NOT EXISTS idx_prompts_project ON user_prompts(project);
 		CREATE INDEX IF NOT EXISTS idx_prompts_created ON user_prompts(created_at DESC);
 
-		CREATE VIRTUAL TABLE IF NOT EXISTS prompts_fts USING fts5(
-			content,
-			project,
-			content='user_prompts',
-			content_rowid='id'
-		);
+		` + promptsFTSTable(s.ftsOptions()) + `;
 
 		CREATE TABLE IF NOT EXISTS sync_chunks (
 			chunk_id    TEXT PRIMARY KEY,
 			imported_at TEXT NOT NULL DEFAULT (datetime('now'))
 		);
+
+		CREATE TABLE IF NOT EXISTS observation_tags (
+			observation_id INTEGER NOT NULL REFERENCES observations(id) ON DELETE CASCADE,
+			tag            TEXT NOT NULL,
+			PRIMARY KEY (observation_id, tag)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_obs_tags_tag ON observation_tags(tag);
+
+		CREATE TABLE IF NOT EXISTS observation_references (
+			observation_id INTEGER NOT NULL REFERENCES observations(id) ON DELETE CASCADE,
+			ref            TEXT NOT NULL,
+			PRIMARY KEY (observation_id, ref)
+		);
+
+		CREATE TABLE IF NOT EXISTS observation_links (
+			from_id    INTEGER NOT NULL REFERENCES observations(id) ON DELETE CASCADE,
+			to_id      INTEGER NOT NULL REFERENCES observations(id) ON DELETE CASCADE,
+			relation   TEXT    NOT NULL,
+			created_at TEXT    NOT NULL DEFAULT (datetime('now')),
+			PRIMARY KEY (from_id, to_id, relation)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_obs_links_to ON observation_links(to_id);
+
+		CREATE TABLE IF NOT EXISTS observation_blobs (
+			observation_id INTEGER PRIMARY KEY REFERENCES observations(id) ON DELETE CASCADE,
+			content        BLOB    NOT NULL,
+			size           INTEGER NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS embeddings (
+			observation_id INTEGER PRIMARY KEY REFERENCES observations(id) ON DELETE CASCADE,
+			model          TEXT    NOT NULL,
+			text_hash      TEXT    NOT NULL,
+			vector         BLOB    NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS facts (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			key        TEXT    NOT NULL,
+			content    TEXT    NOT NULL,
+			project    TEXT    NOT NULL DEFAULT '',
+			first_seen TEXT    NOT NULL DEFAULT (datetime('now')),
+			last_seen  TEXT    NOT NULL DEFAULT (datetime('now')),
+			seen_count INTEGER NOT NULL DEFAULT 1,
+			UNIQUE (project, key)
+		);
+
+		CREATE TABLE IF NOT EXISTS project_aliases (
+			alias      TEXT PRIMARY KEY,
+			canonical  TEXT NOT NULL,
+			created_at TEXT NOT NULL DEFAULT (datetime('now'))
+		);
+
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			operation  TEXT NOT NULL,
+			target_id  TEXT NOT NULL DEFAULT '',
+			actor      TEXT NOT NULL DEFAULT '',
+			detail     TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL DEFAULT (datetime('now'))
+		);
+
+		CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log BEGIN
+			SELECT RAISE(ABORT, 'audit_log is append-only');
+		END;
+
+		CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log BEGIN
+			SELECT RAISE(ABORT, 'audit_log is append-only');
+		END;
+
+		CREATE TABLE IF NOT EXISTS export_watermark (
+			id                 INTEGER PRIMARY KEY CHECK (id = 1),
+			observation_id     INTEGER NOT NULL DEFAULT 0,
+			prompt_id          INTEGER NOT NULL DEFAULT 0,
+			session_started_at TEXT NOT NULL DEFAULT '',
+			exported_at        TEXT NOT NULL DEFAULT ''
+		);
 	`
 	if _, err := s.db.Exec(schema); err != nil {
 		return err
 	}
 
+	// Columns added after the initial schema (idempotent)
+	columns := []struct{ table, name, definition string }{
+		{"observations", "status", "TEXT"},
+		{"observations", "importance", "INTEGER NOT NULL DEFAULT 0"},
+		{"observations", "uid", "TEXT"},
+		{"observations", "access_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"observations", "last_accessed_at", "TEXT"},
+		{"observations", "content_format", "TEXT"},
+		{"observations", "prompt_id", "INTEGER REFERENCES user_prompts(id) ON DELETE SET NULL"},
+		{"observations", "content_hash", "TEXT"},
+		{"observations", "author", "TEXT"},
+		{"observations", "occurred_at", "TEXT"},
+		{"observations", "archived", "INTEGER NOT NULL DEFAULT 0"},
+		{"observations", "pinned", "INTEGER NOT NULL DEFAULT 0"},
+		{"observations", "updated_at", "TEXT"},
+	}
+	for _, c := range columns {
+		if err := s.addColumnIfMissing(c.table, c.name, c.definition); err != nil {
+			return err
+		}
+	}
+	// Rows from before updated_at existed haven't been touched since
+	if _, err := s.db.Exec(
+		"UPDATE observations SET updated_at = created_at WHERE updated_at IS NULL",
+	); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(
+		"CREATE INDEX IF NOT EXISTS idx_obs_updated ON observations(updated_at)",
+	); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(
+		"CREATE INDEX IF NOT EXISTS idx_obs_author ON observations(author)",
+	); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(
+		"CREATE INDEX IF NOT EXISTS idx_obs_occurred ON observations(occurred_at)",
+	); err != nil {
+		return err
+	}
+	// Rows from before occurred_at existed happened when they were saved
+	if _, err := s.db.Exec(
+		"UPDATE observations SET occurred_at = created_at WHERE occurred_at IS NULL",
+	); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(
+		"CREATE INDEX IF NOT EXISTS idx_obs_status ON observations(status)",
+	); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(
+		"CREATE INDEX IF NOT EXISTS idx_obs_prompt ON observations(prompt_id)",
+	); err != nil {
+		return err
+	}
+	if err := s.backfillUIDs(); err != nil {
+		return fmt.Errorf("backfill uids: %w", err)
+	}
+	if err := s.backfillContentHashes(); err != nil {
+		return fmt.Errorf("backfill content hashes: %w", err)
+	}
+	if _, err := s.db.Exec(
+		"CREATE INDEX IF NOT EXISTS idx_obs_content_hash ON observations(content_hash)",
+	); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_obs_uid ON observations(uid)",
+	); err != nil {
+		return err
+	}
+
 	// Create triggers to keep FTS in sync (idempotent check)
 	var name string
 	err := s.db.QueryRow(
@@ -267,18 +924,24 @@ func (s *Store) migrate() error {
 				VALUES ('delete', old.id, old.title, old.content, old.tool_name, old.type, old.project);
 			END;
 
-			CREATE TRIGGER obs_fts_update AFTER UPDATE ON observations BEGIN
-				INSERT INTO observations_fts(observations_fts, rowid, title, content, tool_name, type, project)
-				VALUES ('delete', old.id, old.title, old.content, old.tool_name, old.type, old.project);
-				INSERT INTO observations_fts(rowid, title, content, tool_name, type, project)
-				VALUES (new.id, new.title, new.content, new.tool_name, new.type, new.project);
-			END;
-		`
+		` + obsFTSUpdateTrigger
 		if _, err := s.db.Exec(triggers); err != nil {
 			return err
 		}
 	}
 
+	// obs_fts_update used to fire on every UPDATE, re-indexing rows whose
+	// text hadn't changed (status, access counts). Narrow it to FTS columns.
+	var updateTrigger string
+	s.db.QueryRow(
+		"SELECT sql FROM sqlite_master WHERE type='trigger' AND name='obs_fts_update'",
+	).Scan(&updateTrigger)
+	if !strings.Contains(updateTrigger, "UPDATE OF") {
+		if _, err := s.db.Exec("DROP TRIGGER IF EXISTS obs_fts_update;" + obsFTSUpdateTrigger); err != nil {
+			return err
+		}
+	}
+
 	// Prompts FTS triggers (separate idempotent check)
 	var promptTrigger string
 	err = s.db.QueryRow(
@@ -312,76 +975,655 @@ func (s *Store) migrate() error {
 	return nil
 }
 
-// ─── Sessions ────────────────────────────────────────────────────────────────
-
-func (s *Store) CreateSession(id, project, directory string) error {
-	_, err := s.db.Exec(
-		`INSERT OR IGNORE INTO sessions (id, project, directory) VALUES (?, ?, ?)`,
-		id, project, directory,
-	)
-	return err
+// obsFTSUpdateTrigger re-indexes an observation only when an indexed column
+// changes, so status/access-count updates don't churn the FTS index.
+const obsFTSUpdateTrigger = `
+	CREATE TRIGGER obs_fts_update AFTER UPDATE OF title, content, tool_name, type, project ON observations BEGIN
+		INSERT INTO observations_fts(observations_fts, rowid, title, content, tool_name, type, project)
+		VALUES ('delete', old.id, old.title, old.content, old.tool_name, old.type, old.project);
+		INSERT INTO observations_fts(rowid, title, content, tool_name, type, project)
+		VALUES (new.id, new.title, new.content, new.tool_name, new.type, new.project);
+	END;
+`
+
+// observationsFTSTable and promptsFTSTable are the FTS5 table definitions,
+// shared by migrate and Reindex so both honor Config.FTSPrefix and
+// Config.FTSTokenizer. options is what ftsOptions returns.
+func observationsFTSTable(options string) string {
+	return `CREATE VIRTUAL TABLE IF NOT EXISTS observations_fts USING fts5(
+			title,
+			content,
+			tool_name,
+			type,
+			project,
+			content='observations',
+			content_rowid='id'` + options + `
+		)`
 }
 
-func (s *Store) EndSession(id string, summary string) error {
-	_, err := s.db.Exec(
-		`UPDATE sessions SET ended_at = datetime('now'), summary = ? WHERE id = ?`,
-		nullableString(summary), id,
-	)
-	return err
+func promptsFTSTable(options string) string {
+	return `CREATE VIRTUAL TABLE IF NOT EXISTS prompts_fts USING fts5(
+			content,
+			project,
+			content='user_prompts',
+			content_rowid='id'` + options + `
+		)`
 }
 
-func (s *Store) GetSession(id string) (*Session, error) {
-	row := s.db.QueryRow(
-		`SELECT id, project, directory, started_at, ended_at, summary FROM sessions WHERE id = ?`, id,
-	)
-	var sess Session
-	if err := row.Scan(&sess.ID, &sess.Project, &sess.Directory, &sess.StartedAt, &sess.EndedAt, &sess.Summary); err != nil {
-		return nil, err
+// ftsOptions renders the configured FTS5 table options, e.g.
+// ", prefix='2 3', tokenize='porter unicode61'".
+func (s *Store) ftsOptions() string {
+	options := ftsPrefixOption(s.cfg.FTSPrefix)
+	if s.cfg.FTSTokenizer != "" {
+		options += ", tokenize='" + strings.ReplaceAll(s.cfg.FTSTokenizer, "'", "''") + "'"
 	}
-	return &sess, nil
+	return options
 }
 
-func (s *Store) RecentSessions(project string, limit int) ([]SessionSummary, error) {
-	if limit <= 0 {
-		limit = 5
+// ftsTokenizers are the tokenizers FTS5 ships with. porter wraps another
+// one ("porter unicode61"), or unicode61 when none is named.
+var ftsTokenizers = []string{"unicode61", "ascii", "porter", "trigram"}
+
+// checkTokenizer rejects a tokenize option that doesn't start with a
+// built-in tokenizer. Existing tables only pick it up on Reindex, so a typo
+// would otherwise go unnoticed until then.
+func checkTokenizer(tokenizer string) error {
+	fields := strings.Fields(tokenizer)
+	if len(fields) == 0 {
+		return nil
 	}
+	if !slices.Contains(ftsTokenizers, fields[0]) {
+		return fmt.Errorf("unknown tokenizer %q (expected one of %s)", fields[0], strings.Join(ftsTokenizers, ", "))
+	}
+	return nil
+}
 
-	query := `
-		SELECT s.id, s.project, s.started_at, s.ended_at, s.summary,
-		       COUNT(o.id) as observation_count
-		FROM sessions s
-		LEFT JOIN observations o ON o.session_id = s.id
-		WHERE 1=1
-	`
-	args := []any{}
+// rebuildFTSStatements refill both full-text indexes from their content
+// tables.
+var rebuildFTSStatements = []string{
+	"INSERT INTO observations_fts(observations_fts) VALUES ('rebuild')",
+	"INSERT INTO prompts_fts(prompts_fts) VALUES ('rebuild')",
+}
 
-	if project != "" {
-		query += " AND s.project = ?"
-		args = append(args, project)
-	}
+// Reindex drops and rebuilds both full-text indexes from their content
+// tables, applying the current Config.FTSPrefix and Config.FTSTokenizer.
+// Needed after changing either on an existing database; also repairs a
+// corrupted index.
+func (s *Store) Reindex() error {
+	return s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
 
-	query += " GROUP BY s.id ORDER BY s.started_at DESC LIMIT ?"
-	args = append(args, limit)
+		stmts := []string{
+			"DROP TABLE IF EXISTS observations_fts",
+			observationsFTSTable(s.ftsOptions()),
+			"DROP TABLE IF EXISTS prompts_fts",
+			promptsFTSTable(s.ftsOptions()),
+		}
+		for _, stmt := range append(stmts, rebuildFTSStatements...) {
+			if _, err := tx.Exec(stmt); err != nil {
+				return fmt.Errorf("reindex: %w", err)
+			}
+		}
+		return tx.Commit()
+	})
+}
 
-	rows, err := s.db.Query(query, args...)
+// RebuildFTS refills both full-text indexes from observations and
+// user_prompts in place, in one transaction. It's the fix for an index that
+// drifted from its table, e.g. after rows were edited with the triggers
+// bypassed, which makes search miss or misreport rows without any error.
+// Unlike Reindex it keeps the tables' definitions, so it doesn't apply a
+// changed FTSPrefix or FTSTokenizer, and doesn't undo one either.
+func (s *Store) RebuildFTS() error {
+	return s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for _, stmt := range rebuildFTSStatements {
+			if _, err := tx.Exec(stmt); err != nil {
+				return fmt.Errorf("rebuild fts: %w", err)
+			}
+		}
+		return tx.Commit()
+	})
+}
+
+// Vacuum reclaims space left by deleted rows: it merges the full-text
+// indexes (FTS5 keeps deleted entries until segments are merged, which is
+// most of the space a prune frees), rebuilds the database file, and
+// truncates the write-ahead log. SQLite never shrinks the file on its own,
+// so run it after a large prune or bulk delete. While other processes hold
+// the database open the WAL may not shrink fully.
+func (s *Store) Vacuum() error {
+	return s.withRetry(func() error {
+		stmts := []string{
+			"INSERT INTO observations_fts(observations_fts) VALUES ('optimize')",
+			"INSERT INTO prompts_fts(prompts_fts) VALUES ('optimize')",
+			"VACUUM",
+			"PRAGMA wal_checkpoint(TRUNCATE)",
+		}
+		for _, stmt := range stmts {
+			if _, err := s.db.Exec(stmt); err != nil {
+				return fmt.Errorf("vacuum: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// backfillUIDs gives every observation created before the uid column
+// existed a stable UID.
+func (s *Store) backfillUIDs() error {
+	rows, err := s.db.Query("SELECT id FROM observations WHERE uid IS NULL")
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
-
-	var results []SessionSummary
+	var ids []int64
 	for rows.Next() {
-		var ss SessionSummary
-		if err := rows.Scan(&ss.ID, &ss.Project, &ss.StartedAt, &ss.EndedAt, &ss.Summary, &ss.ObservationCount); err != nil {
-			return nil, err
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
 		}
-		results = append(results, ss)
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
 	}
-	return results, rows.Err()
-}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, id := range ids {
+		if _, err := tx.Exec("UPDATE observations SET uid = ? WHERE id = ?", uuid.NewString(), id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// backfillContentHashes computes content_hash for observations created before
+// the column existed, so imports can deduplicate against them.
+func (s *Store) backfillContentHashes() error {
+	rows, err := s.db.Query(
+		"SELECT id, session_id, type, title, content, created_at FROM observations WHERE content_hash IS NULL",
+	)
+	if err != nil {
+		return err
+	}
+	hashes := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var sessionID, typ, title, content, createdAt string
+		if err := rows.Scan(&id, &sessionID, &typ, &title, &content, &createdAt); err != nil {
+			rows.Close()
+			return err
+		}
+		hashes[id] = contentHash(sessionID, typ, title, content, createdAt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for id, hash := range hashes {
+		if _, err := tx.Exec("UPDATE observations SET content_hash = ? WHERE id = ?", hash, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// addColumnIfMissing runs ALTER TABLE ADD COLUMN unless the column exists.
+// SQLite has no ADD COLUMN IF NOT EXISTS, so we check PRAGMA table_info.
+func (s *Store) addColumnIfMissing(table, column, definition string) error {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue any
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// ─── Sessions ────────────────────────────────────────────────────────────────
+
+func (s *Store) CreateSession(id, project, directory string) error {
+	project = s.canonicalProject(project)
+	res, err := s.exec(
+		`INSERT OR IGNORE INTO sessions (id, project, directory) VALUES (?, ?, ?)`,
+		id, project, directory,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		s.emitSession(EventSessionCreated, id)
+	}
+	return nil
+}
+
+// ErrSessionNotFound and ErrSessionEnded are returned by EndSession for a
+// session that doesn't exist or was already ended. SessionTimeline returns
+// ErrSessionNotFound too.
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionEnded    = errors.New("session already ended")
+)
+
+// EndSession marks a session as ended. An empty summary is filled in by
+// SummarizeSession, so agents that forget to write one still leave something
+// behind for FormatContext. Ending a session twice is an error, so a second
+// call can't overwrite the first one's summary.
+func (s *Store) EndSession(id string, summary string) error {
+	sess, err := s.GetSession(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	if err != nil {
+		return err
+	}
+	if sess.EndedAt != nil {
+		return fmt.Errorf("%w: %s (at %s)", ErrSessionEnded, id, *sess.EndedAt)
+	}
+
+	// A failed summary fails the call before the session is ended, since it
+	// can't be ended again to retry
+	if strings.TrimSpace(summary) == "" {
+		if summary, err = s.SummarizeSession(id); err != nil {
+			return fmt.Errorf("end session: %w", err)
+		}
+	}
+	res, err := s.exec(
+		`UPDATE sessions SET ended_at = datetime('now'), summary = ? WHERE id = ? AND ended_at IS NULL`,
+		nullableString(summary), id,
+	)
+	if err != nil {
+		return err
+	}
+	// Ended by someone else since the check above
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrSessionEnded, id)
+	}
+	s.emitSession(EventSessionEnded, id)
+	return nil
+}
+
+// SetSessionSummary replaces a session's summary without touching ended_at.
+func (s *Store) SetSessionSummary(id, summary string) error {
+	res, err := s.exec("UPDATE sessions SET summary = ? WHERE id = ?", nullableString(summary), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("session %s not found", id)
+	}
+	return nil
+}
+
+// SummarizeSession builds a bullet summary from the titles of a session's
+// observations, oldest first, skipping archived ones and repeated titles.
+// Once Config.MaxSummaryLength would be exceeded the remaining titles are
+// counted in a final "and N more" line. A session without observations
+// summarizes to "".
+func (s *Store) SummarizeSession(id string) (string, error) {
+	if _, err := s.GetSession(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("session %s not found", id)
+		}
+		return "", err
+	}
+	observations, err := s.queryObservations(
+		"SELECT "+observationColumns+" FROM observations o WHERE o.session_id = ? AND o.archived = 0 ORDER BY o.occurred_at, o.id",
+		id,
+	)
+	if err != nil {
+		return "", fmt.Errorf("summarize session: %w", err)
+	}
+
+	var titles []string
+	seen := make(map[string]bool)
+	for _, o := range observations {
+		title := truncate(strings.Join(strings.Fields(o.Title), " "), 200)
+		if title == "" || seen[strings.ToLower(title)] {
+			continue
+		}
+		seen[strings.ToLower(title)] = true
+		titles = append(titles, title)
+	}
+
+	// Leave room for the "and N more" line whenever something is cut
+	const moreReserve = len("- … and 10000 more")
+	maxLen := s.cfg.MaxSummaryLength
+	var b strings.Builder
+	for i, title := range titles {
+		line := "- " + title + "\n"
+		rest := len(titles) - i - 1
+		if maxLen > 0 && b.Len()+len(line) > maxLen-moreReserve && (rest > 0 || b.Len()+len(line) > maxLen) {
+			fmt.Fprintf(&b, "- … and %d more\n", len(titles)-i)
+			break
+		}
+		b.WriteString(line)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// emitSession loads a session and emits it, if anyone is listening.
+func (s *Store) emitSession(event, id string) {
+	if !s.hasHandlers(event) {
+		return
+	}
+	if sess, err := s.GetSession(id); err == nil {
+		s.emit(event, sess)
+	}
+}
+
+// DeleteSession removes a session. With cascade, its observations and
+// prompts are deleted first (the FTS triggers keep the indexes consistent),
+// all in one transaction. Without cascade it refuses to delete a session
+// that still has observations or prompts.
+func (s *Store) DeleteSession(id string, cascade bool) error {
+	var deletedObs []int64
+	err := s.withRetry(func() error {
+		deletedObs = deletedObs[:0]
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("delete session: begin tx: %w", err)
+		}
+		defer tx.Rollback()
+
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM sessions WHERE id = ?", id).Scan(&exists); err != nil {
+			return fmt.Errorf("delete session: %w", err)
+		}
+		if exists == 0 {
+			return fmt.Errorf("session %q not found", id)
+		}
+
+		rows, err := tx.Query("SELECT id FROM observations WHERE session_id = ?", id)
+		if err != nil {
+			return fmt.Errorf("delete session: %w", err)
+		}
+		for rows.Next() {
+			var obsID int64
+			if err := rows.Scan(&obsID); err != nil {
+				rows.Close()
+				return err
+			}
+			deletedObs = append(deletedObs, obsID)
+		}
+		rows.Close()
+
+		var prompts int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM user_prompts WHERE session_id = ?", id).Scan(&prompts); err != nil {
+			return fmt.Errorf("delete session: %w", err)
+		}
+
+		if !cascade && (len(deletedObs) > 0 || prompts > 0) {
+			return fmt.Errorf("session %q still has %d observations and %d prompts (use cascade to delete them)",
+				id, len(deletedObs), prompts)
+		}
+
+		if _, err := tx.Exec("DELETE FROM user_prompts WHERE session_id = ?", id); err != nil {
+			return fmt.Errorf("delete session prompts: %w", err)
+		}
+		if _, err := deleteObservationRows(tx, "session_id = ?", id); err != nil {
+			return fmt.Errorf("delete session observations: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM sessions WHERE id = ?", id); err != nil {
+			return fmt.Errorf("delete session: %w", err)
+		}
+		detail := fmt.Sprintf("%d observations, %d prompts", len(deletedObs), prompts)
+		if err := s.writeAudit(tx, AuditDeleteSession, id, detail); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, obsID := range deletedObs {
+		s.emit(EventObservationDeleted, obsID)
+	}
+	return nil
+}
+
+// MergeSessions moves every observation and prompt from the source sessions
+// into target and deletes the sources, in one transaction — for one piece of
+// work split across sessions, e.g. by an agent restarting after a crash.
+// All sessions must exist and belong to the same project. Rows keep their
+// created_at, so timelines interleave them in the order they happened; the
+// target's started_at and ended_at widen to cover the sources, and their
+// summaries are appended to its own.
+func (s *Store) MergeSessions(target string, sources ...string) error {
+	if len(sources) == 0 {
+		return errors.New("merge sessions: no source sessions given")
+	}
+	seen := map[string]bool{target: true}
+	for _, src := range sources {
+		if seen[src] {
+			return fmt.Errorf("merge sessions: session %q given twice", src)
+		}
+		seen[src] = true
+	}
+
+	var moved []int64
+	err := s.withRetry(func() error {
+		moved = moved[:0]
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("merge sessions: begin tx: %w", err)
+		}
+		defer tx.Rollback()
+
+		var into Session
+		err = tx.QueryRow(
+			"SELECT id, project, started_at, ended_at, summary FROM sessions WHERE id = ?", target,
+		).Scan(&into.ID, &into.Project, &into.StartedAt, &into.EndedAt, &into.Summary)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("session %q not found", target)
+		}
+		if err != nil {
+			return fmt.Errorf("merge sessions: %w", err)
+		}
+		summaries := []string{deref(into.Summary)}
+
+		for _, src := range sources {
+			var from Session
+			err := tx.QueryRow(
+				"SELECT id, project, started_at, ended_at, summary FROM sessions WHERE id = ?", src,
+			).Scan(&from.ID, &from.Project, &from.StartedAt, &from.EndedAt, &from.Summary)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("session %q not found", src)
+			}
+			if err != nil {
+				return fmt.Errorf("merge sessions: %w", err)
+			}
+			if from.Project != into.Project {
+				return fmt.Errorf("merge sessions: session %q is in project %q, not %q", src, from.Project, into.Project)
+			}
+			into.StartedAt = min(into.StartedAt, from.StartedAt)
+			// A session still open (no ended_at) keeps the merged one open
+			if into.EndedAt != nil && (from.EndedAt == nil || *from.EndedAt > *into.EndedAt) {
+				into.EndedAt = from.EndedAt
+			}
+			summaries = append(summaries, deref(from.Summary))
+
+			ids, err := moveSessionObservations(tx, src, target)
+			if err != nil {
+				return fmt.Errorf("merge sessions: %w", err)
+			}
+			moved = append(moved, ids...)
+			if _, err := tx.Exec("UPDATE user_prompts SET session_id = ? WHERE session_id = ?", target, src); err != nil {
+				return fmt.Errorf("merge sessions: move prompts: %w", err)
+			}
+			if _, err := tx.Exec("DELETE FROM sessions WHERE id = ?", src); err != nil {
+				return fmt.Errorf("merge sessions: delete %q: %w", src, err)
+			}
+		}
+
+		summaries = slices.DeleteFunc(summaries, func(sum string) bool { return strings.TrimSpace(sum) == "" })
+		if _, err := tx.Exec(
+			"UPDATE sessions SET started_at = ?, ended_at = ?, summary = ? WHERE id = ?",
+			into.StartedAt, into.EndedAt, nullableString(strings.Join(summaries, "\n\n")), target,
+		); err != nil {
+			return fmt.Errorf("merge sessions: %w", err)
+		}
+		detail := fmt.Sprintf("%s merged in, %d observations moved", strings.Join(sources, ", "), len(moved))
+		if err := s.writeAudit(tx, AuditMergeSessions, target, detail); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range moved {
+		s.emitObservation(EventObservationUpdated, id)
+	}
+	return nil
+}
+
+// moveSessionObservations reassigns from's observations to session to,
+// recomputing their content hashes (which cover the session), and returns
+// their IDs.
+func moveSessionObservations(tx *sql.Tx, from, to string) ([]int64, error) {
+	rows, err := tx.Query("SELECT id, type, title, content, created_at FROM observations WHERE session_id = ?", from)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[int64]string)
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var typ, title, content, createdAt string
+		if err := rows.Scan(&id, &typ, &title, &content, &createdAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+		hashes[id] = contentHash(to, typ, title, content, createdAt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := Now()
+	for _, id := range ids {
+		if _, err := tx.Exec(
+			"UPDATE observations SET session_id = ?, content_hash = ?, updated_at = ? WHERE id = ?",
+			to, hashes[id], now, id,
+		); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+func (s *Store) GetSession(id string) (*Session, error) {
+	row := s.db.QueryRow(
+		`SELECT id, project, directory, started_at, ended_at, summary FROM sessions WHERE id = ?`, id,
+	)
+	var sess Session
+	if err := row.Scan(&sess.ID, &sess.Project, &sess.Directory, &sess.StartedAt, &sess.EndedAt, &sess.Summary); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+func (s *Store) RecentSessions(project string, limit int) ([]SessionSummary, error) {
+	project = s.canonicalProject(project)
+	if limit <= 0 {
+		limit = 5
+	}
+
+	query := `
+		SELECT s.id, s.project, s.started_at, s.ended_at, s.summary,
+		       COUNT(o.id) as observation_count
+		FROM sessions s
+		LEFT JOIN observations o ON o.session_id = s.id
+		WHERE 1=1
+	`
+	args := []any{}
+
+	if project != "" {
+		query += " AND s.project = ?"
+		args = append(args, project)
+	}
+
+	query += " GROUP BY s.id ORDER BY s.started_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SessionSummary
+	for rows.Next() {
+		var ss SessionSummary
+		if err := rows.Scan(&ss.ID, &ss.Project, &ss.StartedAt, &ss.EndedAt, &ss.Summary, &ss.ObservationCount); err != nil {
+			return nil, err
+		}
+		results = append(results, ss)
+	}
+	return results, rows.Err()
+}
 
 // AllSessions returns recent sessions ordered by most recent first (for TUI browsing).
 func (s *Store) AllSessions(project string, limit int) ([]SessionSummary, error) {
+	project = s.canonicalProject(project)
 	if limit <= 0 {
 		limit = 50
 	}
@@ -421,15 +1663,14 @@ func (s *Store) AllSessions(project string, limit int) ([]SessionSummary, error)
 }
 
 // AllObservations returns recent observations ordered by most recent first (for TUI browsing).
-func (s *Store) AllObservations(project string, limit int) ([]Observation, error) {
+// offset skips that many of the newest, for paging back through history.
+func (s *Store) AllObservations(project string, limit, offset int) ([]Observation, error) {
+	project = s.canonicalProject(project)
 	if limit <= 0 {
 		limit = s.cfg.MaxContextResults
 	}
 
-	query := `
-		SELECT o.id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project, o.created_at
-		FROM observations o
-	`
+	query := "SELECT " + observationColumns + " FROM observations o"
 	args := []any{}
 
 	if project != "" {
@@ -437,8 +1678,8 @@ func (s *Store) AllObservations(project string, limit int) ([]Observation, error
 		args = append(args, project)
 	}
 
-	query += " ORDER BY o.created_at DESC LIMIT ?"
-	args = append(args, limit)
+	query += " ORDER BY o.occurred_at DESC, o.id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, max(offset, 0))
 
 	return s.queryObservations(query, args...)
 }
@@ -449,478 +1690,2977 @@ func (s *Store) SessionObservations(sessionID string, limit int) ([]Observation,
 		limit = 200
 	}
 
-	query := `
-		SELECT id, session_id, type, title, content, tool_name, project, created_at
-		FROM observations
-		WHERE session_id = ?
-		ORDER BY created_at ASC
-		LIMIT ?
-	`
-	return s.queryObservations(query, sessionID, limit)
+	query := `
+		SELECT ` + observationColumns + `
+		FROM observations o
+		WHERE o.session_id = ?
+		ORDER BY o.occurred_at ASC, o.id ASC
+		LIMIT ?
+	`
+	return s.queryObservations(query, sessionID, limit)
+}
+
+// ─── Observations ────────────────────────────────────────────────────────────
+
+func (s *Store) AddObservation(p AddObservationParams) (int64, error) {
+	res, err := s.SaveObservation(p)
+	if err != nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+// SaveResult reports the outcome of SaveObservation.
+type SaveResult struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"` // as stored, after redaction or auto-titling
+	// RedactionCount is how many likely secrets (API keys, private keys,
+	// passwords) were replaced with [REDACTED] before storing.
+	RedactionCount int `json:"redaction_count"`
+	// Duplicate means nothing was written: ID is an identical observation
+	// saved within Config.DedupWindow.
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// ValidateObservation runs the checks a save would, without writing
+// anything: it returns the error SaveObservation would fail with for p
+// (ErrInvalidObservation, ErrSecretDetected or ErrUnknownType), if any.
+// Callers that create p's session on demand check first, so a rejected save
+// doesn't leave an empty session behind.
+func (s *Store) ValidateObservation(p AddObservationParams) error {
+	_, _, err := s.prepareObservation(p)
+	return err
+}
+
+// SaveObservation is AddObservation plus visibility into secret redaction.
+// With Config.StrictRedaction the save is refused instead of redacted.
+func (s *Store) SaveObservation(p AddObservationParams) (*SaveResult, error) {
+	p, redactions, err := s.prepareObservation(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cfg.DedupWindow > 0 {
+		existing, err := s.recentDuplicate(p)
+		if err != nil {
+			return nil, err
+		}
+		if existing != 0 {
+			return &SaveResult{ID: existing, Title: p.Title, RedactionCount: redactions, Duplicate: true}, nil
+		}
+	}
+
+	var id int64
+	if s.batch != nil {
+		id, err = s.batch.add(p)
+	} else {
+		err = s.withRetry(func() error {
+			tx, err := s.db.Begin()
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+			if id, err = insertObservation(tx, p); err != nil {
+				return err
+			}
+			return tx.Commit()
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.emitObservation(EventObservationCreated, id)
+	return &SaveResult{ID: id, Title: p.Title, RedactionCount: redactions}, nil
+}
+
+// AddObservations saves many observations in one transaction with one
+// prepared statement, for agents replaying a whole transcript. Each row goes
+// through the same preparation as SaveObservation (private tags, redaction,
+// truncation, type and status checks); if any row fails it, nothing is
+// written and the error names the row's index. The returned IDs are in
+// params order. DedupWindow and the write batch don't apply.
+func (s *Store) AddObservations(params []AddObservationParams) ([]int64, error) {
+	prepared := make([]AddObservationParams, len(params))
+	for i, p := range params {
+		var err error
+		if prepared[i], _, err = s.prepareObservation(p); err != nil {
+			return nil, fmt.Errorf("observation %d: %w", i, err)
+		}
+	}
+	if len(prepared) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int64, len(prepared))
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("add observations: begin tx: %w", err)
+		}
+		defer tx.Rollback()
+
+		stmt, err := tx.Prepare(insertObservationSQL)
+		if err != nil {
+			return fmt.Errorf("add observations: %w", err)
+		}
+		defer stmt.Close()
+
+		for i, p := range prepared {
+			if ids[i], err = insertObservationWith(tx, stmt.Exec, p); err != nil {
+				return fmt.Errorf("add observations: observation %d: %w", i, err)
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		s.emitObservation(EventObservationCreated, id)
+	}
+	return ids, nil
+}
+
+// recentDuplicate returns the newest observation identical to p (after
+// prepareObservation) created within Config.DedupWindow, or 0. Rows still
+// waiting in the write batch aren't visible yet, so they don't count.
+//
+// Content is compared in full: with CompressContent, two long contents that
+// only share their truncated preview aren't duplicates, so candidates'
+// blobs are checked against p's uncut content.
+func (s *Store) recentDuplicate(p AddObservationParams) (int64, error) {
+	cutoff := time.Now().UTC().Add(-s.cfg.DedupWindow).Format(sqliteTimeLayout)
+	rows, err := s.db.Query(
+		`SELECT o.id, b.content FROM observations o
+		 LEFT JOIN observation_blobs b ON b.observation_id = o.id
+		 WHERE o.session_id = ? AND o.type = ? AND o.title = ? AND o.content = ? AND o.created_at >= ?
+		 ORDER BY o.id DESC`,
+		p.SessionID, p.Type, p.Title, p.Content, cutoff,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("check duplicate: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var compressed []byte
+		if err := rows.Scan(&id, &compressed); err != nil {
+			return 0, fmt.Errorf("check duplicate: %w", err)
+		}
+		full := ""
+		if compressed != nil {
+			if full, err = readBlob(compressed); err != nil {
+				return 0, fmt.Errorf("check duplicate: observation #%d content: %w", id, err)
+			}
+		}
+		if full == p.fullContent {
+			return id, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("check duplicate: %w", err)
+	}
+	return 0, nil
+}
+
+// emitObservation loads an observation and emits it, if anyone is listening.
+func (s *Store) emitObservation(event string, id int64) {
+	if !s.hasHandlers(event) {
+		return
+	}
+	if obs, err := s.getObservation(id); err == nil {
+		s.emit(event, obs)
+	}
+}
+
+// ErrInvalidObservation is returned by SaveObservation and AddObservations
+// for params that can't be saved as given: an unknown content format or
+// status, an importance outside 0-5, an unreadable occurred_at, or a prompt
+// that doesn't exist or belongs to another session.
+var ErrInvalidObservation = errors.New("invalid observation")
+
+// prepareObservation applies everything that must happen before a row is
+// written: private-tag stripping, secret redaction, truncation, and status
+// defaults/validation. Returns the number of secrets redacted.
+func (s *Store) prepareObservation(p AddObservationParams) (AddObservationParams, int, error) {
+	p.Project = s.canonicalProject(p.Project)
+	p.Author = cmp.Or(strings.TrimSpace(p.Author), s.cfg.Author)
+	if p.OccurredAt != "" {
+		occurred, err := ParseTimestamp(p.OccurredAt)
+		if err != nil {
+			return p, 0, fmt.Errorf("%w: occurred_at: %w", ErrInvalidObservation, err)
+		}
+		p.OccurredAt = occurred
+	}
+
+	// Strip <private>...</private> tags before persisting ANYTHING
+	p.Title = stripPrivateTags(p.Title)
+	p.Content = stripPrivateTags(p.Content)
+
+	var titleHits, contentHits int
+	p.Title, titleHits = s.redact(p.Title)
+	p.Content, contentHits = s.redact(p.Content)
+	redactions := titleHits + contentHits
+	if redactions > 0 && s.cfg.StrictRedaction {
+		return p, redactions, fmt.Errorf("%w: found %d likely secret(s), refusing to save", ErrSecretDetected, redactions)
+	}
+
+	if strings.TrimSpace(p.Title) == "" && s.cfg.AutoTitleWords > 0 {
+		p.Title = deriveTitle(p, s.cfg.AutoTitleWords)
+	}
+
+	p.Content, p.fullContent = s.fitContent(p.Content)
+
+	if p.ContentFormat == "" {
+		p.ContentFormat = DetectContentFormat(p.Content)
+	}
+	if !validContentFormat(p.ContentFormat) {
+		return p, redactions, fmt.Errorf("%w: content_format %q (expected text, json, diff, or code)", ErrInvalidObservation, p.ContentFormat)
+	}
+
+	if s.cfg.StrictTypes {
+		if err := checkType(p.Type); err != nil {
+			return p, redactions, err
+		}
+	}
+
+	if p.Importance == nil {
+		importance := s.cfg.TypeImportance[p.Type]
+		p.Importance = &importance
+	} else if *p.Importance < 0 || *p.Importance > 5 {
+		return p, redactions, fmt.Errorf("%w: importance %d out of range (expected 0 to 5)", ErrInvalidObservation, *p.Importance)
+	}
+
+	if p.PromptID != 0 {
+		var promptSession string
+		err := s.db.QueryRow("SELECT session_id FROM user_prompts WHERE id = ?", p.PromptID).Scan(&promptSession)
+		if errors.Is(err, sql.ErrNoRows) {
+			return p, redactions, fmt.Errorf("%w: prompt #%d not found", ErrInvalidObservation, p.PromptID)
+		}
+		if err != nil {
+			return p, redactions, err
+		}
+		if promptSession != p.SessionID {
+			return p, redactions, fmt.Errorf("%w: prompt #%d belongs to session %q, not %q", ErrInvalidObservation, p.PromptID, promptSession, p.SessionID)
+		}
+	}
+
+	if p.Status == "" && isTaskType(p.Type) {
+		p.Status = StatusPending
+	}
+	if p.Status != "" && !ValidStatus(p.Status) {
+		return p, redactions, fmt.Errorf("%w: status %q (expected pending, in-progress, or done)", ErrInvalidObservation, p.Status)
+	}
+
+	// Hashtags are read after redaction so secrets can't leak into tags,
+	// and from the full content when the rest of it is kept compressed
+	content := cmp.Or(p.fullContent, p.Content)
+	if s.cfg.ExtractHashtags {
+		p.Tags = append(p.Tags, extractHashtags(content)...)
+	}
+	p.Tags = normalizeTags(p.Tags)
+
+	if s.cfg.ExtractReferences {
+		p.References = append(p.References, extractReferences(content)...)
+	}
+	p.References = normalizeReferences(p.References)
+
+	return p, redactions, nil
+}
+
+// deriveTitle builds a title for an untitled observation from the first
+// non-empty line of its content, cut to maxWords words. Without usable
+// content it falls back to "<tool_name> <type>".
+func deriveTitle(p AddObservationParams, maxWords int) string {
+	for _, line := range strings.Split(p.Content, "\n") {
+		// Drop markdown heading/list markers so "## Fixed bug" → "Fixed bug"
+		line = strings.TrimLeft(strings.TrimSpace(line), "#*->` ")
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			continue
+		}
+		if len(words) > maxWords {
+			return strings.Join(words[:maxWords], " ") + "..."
+		}
+		return strings.Join(words, " ")
+	}
+
+	if p.ToolName != "" {
+		return strings.TrimSpace(p.ToolName + " " + p.Type)
+	}
+	if p.Type != "" {
+		return p.Type
+	}
+	return "untitled"
+}
+
+// insertObservationSQL inserts one observations row; insertObservationWith
+// supplies the arguments.
+const insertObservationSQL = `INSERT INTO observations (uid, session_id, type, title, content, tool_name, project, author, status, importance, content_format, prompt_id, content_hash, created_at, updated_at, occurred_at)
+	 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// insertObservation writes an already-prepared observation with its tags and
+// references. x should be a transaction so they all land together.
+func insertObservation(x execer, p AddObservationParams) (int64, error) {
+	return insertObservationWith(x, func(args ...any) (sql.Result, error) {
+		return x.Exec(insertObservationSQL, args...)
+	}, p)
+}
+
+// insertObservationWith is insertObservation with the row insert done by
+// insert, which runs insertObservationSQL — directly, or through a statement
+// prepared once for many rows. Tags and references go through x.
+func insertObservationWith(x execer, insert func(args ...any) (sql.Result, error), p AddObservationParams) (int64, error) {
+	// created_at is set here rather than by the column default because it's
+	// part of the content hash
+	createdAt := Now()
+	res, err := insert(
+		uuid.NewString(), p.SessionID, p.Type, p.Title, p.Content,
+		nullableString(p.ToolName), nullableString(p.Project), nullableString(p.Author), nullableString(p.Status), *p.Importance,
+		nullableString(p.ContentFormat), nullableID(p.PromptID),
+		contentHash(p.SessionID, p.Type, p.Title, p.Content, createdAt), createdAt, createdAt, cmp.Or(p.OccurredAt, createdAt),
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := insertTags(x, id, p.Tags); err != nil {
+		return 0, err
+	}
+	if p.fullContent != "" {
+		if err := writeBlob(x, id, p.fullContent); err != nil {
+			return 0, err
+		}
+	}
+	return id, insertReferences(x, id, p.References)
+}
+
+// ObservationOrder is what RecentObservations sorts by, newest first.
+type ObservationOrder string
+
+const (
+	OrderCreated ObservationOrder = "created" // when it happened, occurred_at (the default)
+	OrderUpdated ObservationOrder = "updated" // when last changed: "recently touched"
+)
+
+// RecentObservations returns the newest unarchived observations by order,
+// skipping the first offset of them. An empty order is OrderCreated.
+func (s *Store) RecentObservations(project string, limit, offset int, order ObservationOrder) ([]Observation, error) {
+	project = s.canonicalProject(project)
+	if limit <= 0 {
+		limit = s.cfg.MaxContextResults
+	}
+
+	query := "SELECT " + observationColumns + " FROM observations o WHERE o.archived = 0"
+	args := []any{}
+
+	if project != "" {
+		query += " AND o.project = ?"
+		args = append(args, project)
+	}
+
+	switch order {
+	case "", OrderCreated:
+		query += " ORDER BY o.occurred_at DESC, o.id DESC"
+	case OrderUpdated:
+		query += " ORDER BY o.updated_at DESC, o.id DESC"
+	default:
+		return nil, fmt.Errorf("invalid order %q (expected created or updated)", order)
+	}
+	query += " LIMIT ? OFFSET ?"
+	args = append(args, limit, max(offset, 0))
+
+	return s.queryObservations(query, args...)
+}
+
+// ObservationsAfter pages through unarchived observations newest first by
+// keyset: it returns up to limit observations with an ID below id, where 0
+// starts from the newest. Pass the last ID returned as the next call's id.
+// Unlike an OFFSET, the cost doesn't grow the further back a client pages.
+func (s *Store) ObservationsAfter(id int64, project string, limit int) ([]Observation, error) {
+	project = s.canonicalProject(project)
+	if limit <= 0 {
+		limit = s.cfg.MaxContextResults
+	}
+
+	query := "SELECT " + observationColumns + " FROM observations o WHERE o.archived = 0"
+	args := []any{}
+
+	if id > 0 {
+		query += " AND o.id < ?"
+		args = append(args, id)
+	}
+	if project != "" {
+		query += " AND o.project = ?"
+		args = append(args, project)
+	}
+
+	query += " ORDER BY o.id DESC LIMIT ?"
+	args = append(args, limit)
+
+	return s.queryObservations(query, args...)
+}
+
+// ObservationsSince returns up to limit observations with an ID above id,
+// oldest first, archived ones included: everything saved after id, for
+// clients catching up on a live feed.
+func (s *Store) ObservationsSince(id int64, limit int) ([]Observation, error) {
+	if limit <= 0 {
+		limit = s.cfg.MaxContextResults
+	}
+	return s.queryObservations(
+		"SELECT "+observationColumns+" FROM observations o WHERE o.id > ? ORDER BY o.id LIMIT ?", id, limit,
+	)
+}
+
+// GlobalInsights returns durable, project-agnostic observations (no project,
+// importance at or above Config.GlobalInsightMinImportance). They are meant
+// to follow the user into every project's context.
+func (s *Store) GlobalInsights(limit int) ([]Observation, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	query := `
+		SELECT ` + observationColumns + `
+		FROM observations o
+		WHERE o.project IS NULL AND o.importance >= ? AND o.archived = 0
+		ORDER BY o.importance DESC, o.access_count DESC, o.occurred_at DESC
+		LIMIT ?
+	`
+	return s.queryObservations(query, s.cfg.GlobalInsightMinImportance, limit)
+}
+
+// DeleteObservation permanently removes an observation with its tags and
+// references. The FTS delete trigger keeps the search index in sync.
+func (s *Store) DeleteObservation(id int64) error {
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("delete observation: begin tx: %w", err)
+		}
+		defer tx.Rollback()
+
+		var title string
+		err = tx.QueryRow("SELECT title FROM observations WHERE id = ?", id).Scan(&title)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("observation #%d not found", id)
+		}
+		if err != nil {
+			return fmt.Errorf("delete observation: %w", err)
+		}
+		if _, err := deleteObservationRows(tx, "id = ?", id); err != nil {
+			return fmt.Errorf("delete observation: %w", err)
+		}
+		if err := s.writeAudit(tx, AuditDelete, strconv.FormatInt(id, 10), title); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return err
+	}
+
+	s.emit(EventObservationDeleted, id)
+	return nil
+}
+
+// DeleteSessionObservations removes every observation in a session in one
+// transaction and returns how many were deleted. The session itself and its
+// prompts are kept; use DeleteSession to remove those too.
+func (s *Store) DeleteSessionObservations(sessionID string) (int, error) {
+	var deleted []int64
+	err := s.withRetry(func() error {
+		deleted = deleted[:0]
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("delete session observations: begin tx: %w", err)
+		}
+		defer tx.Rollback()
+
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM sessions WHERE id = ?", sessionID).Scan(&exists); err != nil {
+			return fmt.Errorf("delete session observations: %w", err)
+		}
+		if exists == 0 {
+			return fmt.Errorf("session %q not found", sessionID)
+		}
+
+		rows, err := tx.Query("SELECT id FROM observations WHERE session_id = ?", sessionID)
+		if err != nil {
+			return fmt.Errorf("delete session observations: %w", err)
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			deleted = append(deleted, id)
+		}
+		rows.Close()
+
+		if _, err := deleteObservationRows(tx, "session_id = ?", sessionID); err != nil {
+			return fmt.Errorf("delete session observations: %w", err)
+		}
+		detail := fmt.Sprintf("%d observations", len(deleted))
+		if err := s.writeAudit(tx, AuditDeleteSessionObservations, sessionID, detail); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range deleted {
+		s.emit(EventObservationDeleted, id)
+	}
+	return len(deleted), nil
+}
+
+// UpdateObservationParams edits an observation in place. Nil fields are
+// left as they are.
+type UpdateObservationParams struct {
+	Type       *string `json:"type,omitempty"`
+	Title      *string `json:"title,omitempty"`
+	Content    *string `json:"content,omitempty"`
+	Importance *int    `json:"importance,omitempty"`
+}
+
+// changedFields lists the fields p sets, for the audit log.
+func (p UpdateObservationParams) changedFields() string {
+	var fields []string
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"type", p.Type != nil},
+		{"title", p.Title != nil},
+		{"content", p.Content != nil},
+		{"importance", p.Importance != nil},
+	} {
+		if f.set {
+			fields = append(fields, f.name)
+		}
+	}
+	return strings.Join(fields, ", ")
+}
+
+// UpdateObservation edits an observation and sets its updated_at, keeping its
+// ID, UID and created_at. The new title and content are cleaned like a save's
+// (private tags, redaction, length cap), the content format is detected again
+// when the content changes, and under Config.StrictTypes the type must be
+// known. It returns sql.ErrNoRows if there's no observation id.
+func (s *Store) UpdateObservation(id int64, p UpdateObservationParams) (*Observation, error) {
+	if p.Type == nil && p.Title == nil && p.Content == nil && p.Importance == nil {
+		return nil, errors.New("update observation: nothing to change")
+	}
+	o, err := s.getObservation(id)
+	if err != nil {
+		return nil, err
+	}
+
+	redactions := 0
+	if p.Type != nil {
+		o.Type = strings.TrimSpace(*p.Type)
+		if o.Type == "" {
+			return nil, errors.New("update observation: type can't be empty")
+		}
+		if s.cfg.StrictTypes {
+			if err := checkType(o.Type); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p.Title != nil {
+		var n int
+		o.Title, n = s.redact(stripPrivateTags(*p.Title))
+		redactions += n
+	}
+	// o.Content is the full text; stored is what the row holds
+	var stored, fullContent string
+	if p.Content != nil {
+		var n int
+		o.Content, n = s.redact(stripPrivateTags(*p.Content))
+		redactions += n
+		if strings.TrimSpace(o.Content) == "" {
+			return nil, errors.New("update observation: content can't be empty")
+		}
+		stored, fullContent = s.fitContent(o.Content)
+		o.Content = cmp.Or(fullContent, stored)
+		o.Format = nullableString(DetectContentFormat(stored))
+	} else if err := s.db.QueryRow("SELECT content FROM observations WHERE id = ?", id).Scan(&stored); err != nil {
+		return nil, err
+	}
+	if redactions > 0 && s.cfg.StrictRedaction {
+		return nil, fmt.Errorf("%w: found %d likely secret(s), refusing to save", ErrSecretDetected, redactions)
+	}
+	if p.Importance != nil {
+		if *p.Importance < 0 || *p.Importance > 5 {
+			return nil, fmt.Errorf("update observation: importance %d out of range (expected 0 to 5)", *p.Importance)
+		}
+		o.Importance = *p.Importance
+	}
+
+	o.UpdatedAt = Now()
+	err = s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		res, err := tx.Exec(
+			`UPDATE observations SET type = ?, title = ?, content = ?, content_format = ?, importance = ?,
+			 content_hash = ?, updated_at = ? WHERE id = ?`,
+			o.Type, o.Title, stored, o.Format, o.Importance,
+			contentHash(o.SessionID, o.Type, o.Title, stored, o.CreatedAt), o.UpdatedAt, id,
+		)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return sql.ErrNoRows
+		}
+		// Content left alone keeps its blob
+		if p.Content != nil {
+			if err := writeBlob(tx, id, fullContent); err != nil {
+				return err
+			}
+		}
+		if err := s.writeAudit(tx, AuditEdit, strconv.FormatInt(id, 10), p.changedFields()); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update observation: %w", err)
+	}
+	s.emitObservation(EventObservationUpdated, id)
+	return o, nil
+}
+
+// ArchiveObservation hides an observation from search, recent lists and
+// context without deleting it. It can still be fetched by ID, shows up in
+// timelines and exports, and SearchOptions.IncludeArchived finds it again.
+func (s *Store) ArchiveObservation(id int64) error {
+	return s.setArchived(id, true)
+}
+
+// UnarchiveObservation undoes ArchiveObservation.
+func (s *Store) UnarchiveObservation(id int64) error {
+	return s.setArchived(id, false)
+}
+
+func (s *Store) setArchived(id int64, archived bool) error {
+	flag, operation := 0, AuditUnarchive
+	if archived {
+		flag, operation = 1, AuditArchive
+	}
+	err := s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("%s observation: begin tx: %w", operation, err)
+		}
+		defer tx.Rollback()
+
+		var title string
+		err = tx.QueryRow("SELECT title FROM observations WHERE id = ?", id).Scan(&title)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("observation #%d not found", id)
+		}
+		if err != nil {
+			return fmt.Errorf("%s observation: %w", operation, err)
+		}
+		if _, err := tx.Exec("UPDATE observations SET archived = ?, updated_at = ? WHERE id = ?", flag, Now(), id); err != nil {
+			return fmt.Errorf("%s observation: %w", operation, err)
+		}
+		if err := s.writeAudit(tx, operation, strconv.FormatInt(id, 10), title); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return err
+	}
+	s.emitObservation(EventObservationUpdated, id)
+	return nil
+}
+
+// PinObservation pins or unpins an observation. Pinned observations are
+// never removed by Prune and get their own section at the top of
+// FormatContext.
+func (s *Store) PinObservation(id int64, pinned bool) error {
+	flag := 0
+	if pinned {
+		flag = 1
+	}
+	res, err := s.exec("UPDATE observations SET pinned = ?, updated_at = ? WHERE id = ?", flag, Now(), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("observation #%d not found", id)
+	}
+	s.emitObservation(EventObservationUpdated, id)
+	return nil
+}
+
+// PinnedObservations returns the unarchived pinned observations, in project
+// if set, newest first.
+func (s *Store) PinnedObservations(project string, limit int) ([]Observation, error) {
+	project = s.canonicalProject(project)
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := "SELECT " + observationColumns + " FROM observations o WHERE o.pinned = 1 AND o.archived = 0"
+	var args []any
+
+	if project != "" {
+		query += " AND o.project = ?"
+		args = append(args, project)
+	}
+
+	query += " ORDER BY o.occurred_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	return s.queryObservations(query, args...)
+}
+
+// deleteObservationRows deletes the observations matching where (a condition
+// on the observations table, with its args) along with their tags, references,
+// links, embeddings and compressed content, and returns how many observations
+// were removed. Child rows are deleted explicitly rather than left to foreign
+// keys, so a connection without them enforced can't orphan any.
+func deleteObservationRows(x execer, where string, args ...any) (int64, error) {
+	for _, table := range []string{"observation_tags", "observation_references", "embeddings", "observation_blobs"} {
+		if _, err := x.Exec(
+			"DELETE FROM "+table+" WHERE observation_id IN (SELECT id FROM observations WHERE "+where+")", args...,
+		); err != nil {
+			return 0, fmt.Errorf("%s: %w", table, err)
+		}
+	}
+	if _, err := x.Exec(
+		`DELETE FROM observation_links
+		 WHERE from_id IN (SELECT id FROM observations WHERE `+where+`)
+		    OR to_id IN (SELECT id FROM observations WHERE `+where+`)`, append(args, args...)...,
+	); err != nil {
+		return 0, fmt.Errorf("observation_links: %w", err)
+	}
+	res, err := x.Exec("DELETE FROM observations WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ─── Retention ───────────────────────────────────────────────────────────────
+
+// pruneFilter is the condition on observations (as o) that Prune removes.
+// Pinned observations never match.
+func pruneFilter(olderThan time.Duration, project string) (string, []any, error) {
+	if olderThan <= 0 {
+		return "", nil, fmt.Errorf("prune: age must be positive, got %s", olderThan)
+	}
+	cutoff := time.Now().UTC().Add(-olderThan).Format(sqliteTimeLayout)
+	where := "created_at < ? AND pinned = 0"
+	args := []any{cutoff}
+	// IS rather than =, so NOT (filter) stays true for NULL projects
+	if project != "" {
+		where += " AND project IS ?"
+		args = append(args, project)
+	}
+	return where, args, nil
+}
+
+// prunableSessionsQuery selects the sessions a prune would leave empty: every
+// observation they have matches the filter and they have no prompts. %[1]s is
+// the filter.
+const prunableSessionsQuery = `
+	SELECT s.id FROM sessions s
+	WHERE s.id IN (SELECT session_id FROM observations WHERE %[1]s)
+	  AND NOT EXISTS (SELECT 1 FROM observations WHERE session_id = s.id AND NOT (%[1]s))
+	  AND NOT EXISTS (SELECT 1 FROM user_prompts WHERE session_id = s.id)`
+
+// PrunePreview reports what Prune would delete, without deleting anything.
+func (s *Store) PrunePreview(olderThan time.Duration, project string) (observations, sessions int, err error) {
+	project = s.canonicalProject(project)
+	where, args, err := pruneFilter(olderThan, project)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM observations WHERE "+where, args...).Scan(&observations); err != nil {
+		return 0, 0, fmt.Errorf("prune preview: %w", err)
+	}
+	err = s.db.QueryRow(
+		"SELECT COUNT(*) FROM ("+fmt.Sprintf(prunableSessionsQuery, where)+")", append(args, args...)...,
+	).Scan(&sessions)
+	if err != nil {
+		return 0, 0, fmt.Errorf("prune preview: %w", err)
+	}
+	return observations, sessions, nil
+}
+
+// Prune deletes unpinned observations created more than olderThan ago —
+// only in project, if set — together with their tags, references and links, and
+// any session left with no observations and no prompts. It runs in one
+// transaction and returns how many observations were removed.
+func (s *Store) Prune(olderThan time.Duration, project string) (int, error) {
+	project = s.canonicalProject(project)
+	where, args, err := pruneFilter(olderThan, project)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted []int64
+	var n int64
+	err = s.withRetry(func() error {
+		deleted = nil
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("prune: begin tx: %w", err)
+		}
+		defer tx.Rollback()
+
+		// Sessions have to be picked before their observations are gone
+		rows, err := tx.Query(fmt.Sprintf(prunableSessionsQuery, where), append(args, args...)...)
+		if err != nil {
+			return fmt.Errorf("prune: %w", err)
+		}
+		var sessions []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("prune: %w", err)
+			}
+			sessions = append(sessions, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("prune: %w", err)
+		}
+
+		if s.hasHandlers(EventObservationDeleted) {
+			rows, err := tx.Query("SELECT id FROM observations WHERE "+where, args...)
+			if err != nil {
+				return fmt.Errorf("prune: %w", err)
+			}
+			for rows.Next() {
+				var id int64
+				if err := rows.Scan(&id); err != nil {
+					rows.Close()
+					return fmt.Errorf("prune: %w", err)
+				}
+				deleted = append(deleted, id)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return fmt.Errorf("prune: %w", err)
+			}
+		}
+
+		if n, err = deleteObservationRows(tx, where, args...); err != nil {
+			return fmt.Errorf("prune: %w", err)
+		}
+		for _, id := range sessions {
+			if _, err := tx.Exec("DELETE FROM sessions WHERE id = ?", id); err != nil {
+				return fmt.Errorf("prune: session %s: %w", id, err)
+			}
+		}
+		// A scheduled prune that finds nothing isn't worth an entry
+		if n > 0 || len(sessions) > 0 {
+			detail := fmt.Sprintf("older than %s", olderThan)
+			if project != "" {
+				detail += " in " + project
+			}
+			detail += fmt.Sprintf(": %d observations, %d sessions", n, len(sessions))
+			if err := s.writeAudit(tx, AuditPrune, "", detail); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range deleted {
+		s.emit(EventObservationDeleted, id)
+	}
+	return int(n), nil
+}
+
+// ─── User Prompts ────────────────────────────────────────────────────────────
+
+func (s *Store) AddPrompt(p AddPromptParams) (int64, error) {
+	p.Project = s.canonicalProject(p.Project)
+	// Prompts are what the user typed, so secrets are always redacted here,
+	// even with StrictRedaction
+	content, _ := s.redact(stripPrivateTags(p.Content))
+	if len(content) > s.cfg.MaxObservationLength {
+		content = content[:s.cfg.MaxObservationLength] + "... [truncated]"
+	}
+
+	res, err := s.exec(
+		`INSERT INTO user_prompts (session_id, content, project) VALUES (?, ?, ?)`,
+		p.SessionID, content, nullableString(p.Project),
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if s.hasHandlers(EventPromptCreated) {
+		s.emit(EventPromptCreated, &Prompt{
+			ID:        id,
+			SessionID: p.SessionID,
+			Content:   content,
+			Project:   p.Project,
+			CreatedAt: Now(),
+		})
+	}
+	return id, nil
+}
+
+func (s *Store) RecentPrompts(project string, limit int) ([]Prompt, error) {
+	project = s.canonicalProject(project)
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := `SELECT id, session_id, content, project, created_at FROM user_prompts`
+	args := []any{}
+
+	if project != "" {
+		query += " WHERE project = ?"
+		args = append(args, project)
+	}
+
+	query += " ORDER BY created_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	return s.queryPrompts(query, args...)
+}
+
+// ErrPromptNotFound is returned by ObservationsForPrompt for a prompt ID
+// that doesn't exist.
+var ErrPromptNotFound = errors.New("prompt not found")
+
+// ObservationsForPrompt returns the observations attributed to a prompt
+// (AddObservationParams.PromptID), in the order they were saved.
+func (s *Store) ObservationsForPrompt(promptID int64) ([]Observation, error) {
+	var exists int
+	err := s.db.QueryRow("SELECT 1 FROM user_prompts WHERE id = ?", promptID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: #%d", ErrPromptNotFound, promptID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queryObservations(
+		"SELECT "+observationColumns+" FROM observations o WHERE o.prompt_id = ? ORDER BY o.id", promptID,
+	)
+}
+
+// queryPrompts runs a query selecting id, session_id, content, project and
+// created_at from user_prompts. A NULL project reads as "".
+func (s *Store) queryPrompts(query string, args ...any) ([]Prompt, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Prompt
+	for rows.Next() {
+		var p Prompt
+		var project sql.NullString
+		if err := rows.Scan(&p.ID, &p.SessionID, &p.Content, &project, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.Project = project.String
+		results = append(results, p)
+	}
+	return results, rows.Err()
+}
+
+func (s *Store) SearchPrompts(query string, project string, limit int) ([]Prompt, error) {
+	project = s.canonicalProject(project)
+	if limit <= 0 {
+		limit = 10
+	}
+
+	ftsQuery, _ := s.sanitizeFTS(query)
+
+	sql := `
+		SELECT p.id, p.session_id, p.content, p.project, p.created_at
+		FROM prompts_fts fts
+		JOIN user_prompts p ON p.id = fts.rowid
+		WHERE prompts_fts MATCH ?
+	`
+	args := []any{ftsQuery}
+
+	if project != "" {
+		sql += " AND p.project = ?"
+		args = append(args, project)
+	}
+
+	sql += " ORDER BY fts.rank LIMIT ?"
+	args = append(args, limit)
+
+	results, err := s.queryPrompts(sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search prompts: %w", err)
+	}
+	return results, nil
+}
+
+// ─── Tasks ───────────────────────────────────────────────────────────────────
+
+// SetStatus updates the task status of an observation. Passing an empty
+// status clears it, so the observation is no longer tracked as a task.
+func (s *Store) SetStatus(id int64, status string) error {
+	if status != "" && !ValidStatus(status) {
+		return fmt.Errorf("invalid status %q (expected pending, in-progress, or done)", status)
+	}
+
+	res, err := s.exec(
+		"UPDATE observations SET status = ?, updated_at = ? WHERE id = ?",
+		nullableString(status), Now(), id,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("observation #%d not found", id)
+	}
+	s.emitObservation(EventObservationUpdated, id)
+	return nil
+}
+
+// OpenTasks returns task observations that are not done yet, oldest first
+// so long-standing TODOs don't get buried.
+func (s *Store) OpenTasks(project string, limit int) ([]Observation, error) {
+	project = s.canonicalProject(project)
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := "SELECT " + observationColumns + " FROM observations o WHERE o.status IS NOT NULL AND o.status != ? AND o.archived = 0"
+	args := []any{StatusDone}
+
+	if project != "" {
+		query += " AND o.project = ?"
+		args = append(args, project)
+	}
+
+	query += " ORDER BY o.occurred_at ASC LIMIT ?"
+	args = append(args, limit)
+
+	return s.queryObservations(query, args...)
+}
+
+// ─── Get Single Observation ──────────────────────────────────────────────────
+
+// GetObservation returns one observation and, with TrackAccess, counts the
+// read.
+func (s *Store) GetObservation(id int64) (*Observation, error) {
+	o, err := s.getObservation(id)
+	if err != nil {
+		return nil, err
+	}
+	s.recordAccess(id)
+	return o, nil
+}
+
+// recordAccess bumps access_count/last_accessed_at for the given
+// observations when TrackAccess is on. Failures are ignored — a lost count
+// must never fail a read.
+func (s *Store) recordAccess(ids ...int64) {
+	if !s.cfg.TrackAccess || len(ids) == 0 {
+		return
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	s.exec(
+		`UPDATE observations SET access_count = access_count + 1, last_accessed_at = datetime('now')
+		 WHERE id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)`,
+		args...,
+	)
+}
+
+// GetObservations fetches many observations in one query per 500 IDs,
+// returned in the order requested. Unknown IDs are skipped and duplicates
+// collapse to one entry. Unlike GetObservation it doesn't count reads or
+// load tags — it's meant for assembling views, not for showing one memory.
+func (s *Store) GetObservations(ids []int64) ([]Observation, error) {
+	const chunkSize = 500 // well under SQLite's bound-parameter limit
+
+	byID := make(map[int64]Observation, len(ids))
+	for start := 0; start < len(ids); start += chunkSize {
+		chunk := ids[start:min(start+chunkSize, len(ids))]
+		args := make([]any, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+		found, err := s.queryObservations(
+			"SELECT "+observationColumns+" FROM observations o WHERE o.id IN (?"+strings.Repeat(", ?", len(chunk)-1)+")",
+			args...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("get observations: %w", err)
+		}
+		for _, o := range found {
+			byID[o.ID] = o
+		}
+	}
+
+	result := make([]Observation, 0, len(byID))
+	for _, id := range ids {
+		if o, ok := byID[id]; ok {
+			result = append(result, o)
+			delete(byID, id)
+		}
+	}
+	return result, nil
+}
+
+// ObservationsByUID looks up observations by their stable UID, keyed by
+// UID. Unknown and empty UIDs are left out. Like GetObservations it skips
+// read tracking and tags; sync review uses it to compare incoming chunks
+// against local data.
+func (s *Store) ObservationsByUID(uids []string) (map[string]Observation, error) {
+	const chunkSize = 500
+
+	var want []any
+	for _, uid := range uids {
+		if uid != "" {
+			want = append(want, uid)
+		}
+	}
+
+	byUID := make(map[string]Observation, len(want))
+	for start := 0; start < len(want); start += chunkSize {
+		args := want[start:min(start+chunkSize, len(want))]
+		found, err := s.queryObservations(
+			"SELECT "+observationColumns+" FROM observations o WHERE o.uid IN (?"+strings.Repeat(", ?", len(args)-1)+")",
+			args...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("observations by uid: %w", err)
+		}
+		for _, o := range found {
+			byUID[o.UID] = o
+		}
+	}
+	return byUID, nil
+}
+
+func (s *Store) getObservation(id int64) (*Observation, error) {
+	row := s.db.QueryRow(
+		"SELECT "+observationColumns+" FROM observations o WHERE o.id = ?", id,
+	)
+	var o Observation
+	if err := row.Scan(o.scanFields()...); err != nil {
+		return nil, err
+	}
+	one := []Observation{o}
+	if err := s.attachFullContent(one); err != nil {
+		return nil, err
+	}
+	o = one[0]
+	tags, err := s.ObservationTags(id)
+	if err != nil {
+		return nil, err
+	}
+	o.Tags = tags
+	refs, err := s.ObservationReferences(id)
+	if err != nil {
+		return nil, err
+	}
+	o.References = refs
+	return &o, nil
+}
+
+// ─── Timeline ────────────────────────────────────────────────────────────────
+//
+// Timeline provides chronological context around a specific observation.
+// Given an observation ID, it returns N observations before and M after,
+// all within the same session. This is the "progressive disclosure" pattern
+// from claude-mem — agents first search, then use timeline to drill into
+// the chronological neighborhood of a result.
+
+func (s *Store) Timeline(observationID int64, before, after int) (*TimelineResult, error) {
+	if before <= 0 {
+		before = 5
+	}
+	if after <= 0 {
+		after = 5
+	}
+
+	// 1. Get the focus observation
+	focus, err := s.getObservation(observationID)
+	if err != nil {
+		return nil, fmt.Errorf("timeline: observation #%d not found: %w", observationID, err)
+	}
+
+	// 2. Get session info
+	session, err := s.GetSession(focus.SessionID)
+	if err != nil {
+		// Session might be missing for manual-save observations — non-fatal
+		session = nil
+	}
+
+	// 3. Get observations BEFORE the focus (same session, older, chronological
+	// order). Chronological is by occurred_at, with the ID breaking ties.
+	beforeObs, err := s.queryObservations(`
+		SELECT `+observationColumns+`
+		FROM observations o
+		WHERE o.session_id = ? AND (o.occurred_at < ? OR (o.occurred_at = ? AND o.id < ?))
+		ORDER BY o.occurred_at DESC, o.id DESC
+		LIMIT ?
+	`, focus.SessionID, focus.OccurredAt, focus.OccurredAt, observationID, before)
+	if err != nil {
+		return nil, fmt.Errorf("timeline: before query: %w", err)
+	}
+	beforeEntries := toTimelineEntries(beforeObs)
+	// Reverse to get chronological order (oldest first)
+	for i, j := 0, len(beforeEntries)-1; i < j; i, j = i+1, j-1 {
+		beforeEntries[i], beforeEntries[j] = beforeEntries[j], beforeEntries[i]
+	}
+
+	// 4. Get observations AFTER the focus (same session, newer, chronological order)
+	afterObs, err := s.queryObservations(`
+		SELECT `+observationColumns+`
+		FROM observations o
+		WHERE o.session_id = ? AND (o.occurred_at > ? OR (o.occurred_at = ? AND o.id > ?))
+		ORDER BY o.occurred_at ASC, o.id ASC
+		LIMIT ?
+	`, focus.SessionID, focus.OccurredAt, focus.OccurredAt, observationID, after)
+	if err != nil {
+		return nil, fmt.Errorf("timeline: after query: %w", err)
+	}
+	afterEntries := toTimelineEntries(afterObs)
+
+	// 5. Count total observations in the session for context
+	var totalInRange int
+	s.db.QueryRow(
+		"SELECT COUNT(*) FROM observations WHERE session_id = ?", focus.SessionID,
+	).Scan(&totalInRange)
+
+	// Everything the timeline surfaces counts as accessed
+	accessed := []int64{focus.ID}
+	for _, e := range beforeEntries {
+		accessed = append(accessed, e.ID)
+	}
+	for _, e := range afterEntries {
+		accessed = append(accessed, e.ID)
+	}
+	s.recordAccess(accessed...)
+
+	links, err := s.GetLinks(observationID)
+	if err != nil {
+		return nil, fmt.Errorf("timeline: %w", err)
+	}
+
+	return &TimelineResult{
+		Focus:        *focus,
+		Before:       beforeEntries,
+		After:        afterEntries,
+		SessionInfo:  session,
+		TotalInRange: totalInRange,
+		Links:        links,
+	}, nil
+}
+
+// SessionTimeline returns every observation in a session in chronological
+// order, framed by the session's info and summary. Unlike Timeline it isn't
+// windowed around a focus — it's the "replay the whole session" view.
+func (s *Store) SessionTimeline(sessionID string) (*SessionTimelineResult, error) {
+	session, err := s.GetSession(sessionID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("session timeline: %w: %q", ErrSessionNotFound, sessionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("session timeline: %w", err)
+	}
+
+	observations, err := s.queryObservations(`
+		SELECT `+observationColumns+`
+		FROM observations o
+		WHERE o.session_id = ?
+		ORDER BY o.occurred_at ASC, o.id ASC
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session timeline: %w", err)
+	}
+
+	prompts, err := s.queryPrompts(
+		"SELECT id, session_id, content, project, created_at FROM user_prompts WHERE session_id = ? ORDER BY id", sessionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("session timeline: %w", err)
+	}
+
+	return &SessionTimelineResult{
+		Session:      session,
+		Observations: toTimelineEntries(observations),
+		Prompts:      prompts,
+		Total:        len(observations),
+	}, nil
+}
+
+// ─── Search (FTS5) ───────────────────────────────────────────────────────────
+
+// SearchResponse is a page of search results plus whether the query had
+// more matches than the (possibly clamped) limit allowed.
+type SearchResponse struct {
+	Results  []SearchResult `json:"results"`
+	Returned int            `json:"returned"`
+	HasMore  bool           `json:"has_more"`
+	// PrefixMatch is true when query words were matched as prefixes
+	// (Config.FTSPrefix), so "auth" may have matched "authentication".
+	PrefixMatch bool `json:"prefix_match"`
+	// Fallback is true when the query was empty and the results are the
+	// most recent observations matching the filters, not FTS matches.
+	// Rank is 0 for every result.
+	Fallback bool `json:"fallback"`
+	// Fuzzy is true when the query matched nothing and SearchOptions.Fuzzy
+	// found these by title similarity instead. Rank is minus the
+	// similarity, from -1 (exact word) up to about -0.75.
+	Fuzzy bool `json:"fuzzy,omitempty"`
+	// FuzzyTotal is how many titles the fuzzy fallback matched across all
+	// pages, where SearchCount would say 0.
+	FuzzyTotal int `json:"fuzzy_total,omitempty"`
+}
+
+func (s *Store) Search(query string, opts SearchOptions) ([]SearchResult, error) {
+	resp, err := s.SearchPage(query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// SearchPage is Search with limit feedback. It fetches one row past the
+// limit to tell whether more results are available. An empty query browses
+// recent observations instead (see SearchResponse.Fallback). With
+// Config.SearchCache, repeated identical searches are served from memory.
+func (s *Store) SearchPage(query string, opts SearchOptions) (*SearchResponse, error) {
+	if s.searchCache == nil {
+		return s.searchPage(query, opts)
+	}
+
+	key := searchCacheKey(query, opts)
+	resp, version, ok := s.searchCache.get(key)
+	if ok {
+		return resp, nil
+	}
+	// A write landing after get is caught by the next get's data_version
+	// check, so storing under the old version can't serve stale results
+	resp, err := s.searchPage(query, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.searchCache.put(key, version, resp)
+	return resp, nil
+}
+
+func (s *Store) searchPage(query string, opts SearchOptions) (*SearchResponse, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > s.cfg.MaxSearchResults {
+		limit = s.cfg.MaxSearchResults
+	}
+
+	if strings.TrimSpace(query) == "" {
+		return s.browsePage(opts, limit)
+	}
+
+	from, args, prefixMatch := s.searchSource(query, opts)
+
+	// With Explain, bm25() is evaluated once per column with that column's
+	// weight at 1 and every other column at 0, isolating its contribution.
+	explainCols := ""
+	if opts.Explain {
+		for i := range ftsColumns {
+			weights := make([]string, len(ftsColumns))
+			for j := range weights {
+				weights[j] = "0"
+			}
+			weights[i] = "1"
+			explainCols += ", bm25(observations_fts, " + strings.Join(weights, ", ") + ")"
+		}
+	}
+
+	sql := "SELECT " + observationColumns + ", " + s.rankExpr + explainCols + from
+
+	// Recency reranks in Go, so it needs everything up to the end of the
+	// requested page, from a wider pool than the page itself.
+	sqlLimit, sqlOffset := limit+1, max(opts.Offset, 0)
+	if opts.RecencyWeight > 0 {
+		sqlLimit, sqlOffset = (sqlOffset+limit)*recencyCandidates+1, 0
+	}
+
+	// Frequently retrieved memories get up to a 2x boost (rank is negative,
+	// lower is better). access_count stays 0 unless TrackAccess is on.
+	sql += " ORDER BY " + s.rankExpr + " * (1 + 0.1 * MIN(o.access_count, 10)), o.id DESC LIMIT ? OFFSET ?"
+	args = append(args, sqlLimit, sqlOffset)
+
+	rows, err := s.db.Query(sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	defer rows.Close()
+
+	terms := strings.Fields(strings.ToLower(strings.NewReplacer(`"`, "", "*", "").Replace(query)))
+
+	var results []SearchResult
+	for rows.Next() {
+		var sr SearchResult
+		dest := append(sr.scanFields(), &sr.Rank)
+		var scores []float64
+		if opts.Explain {
+			scores = make([]float64, len(ftsColumns))
+			for i := range scores {
+				dest = append(dest, &scores[i])
+			}
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if opts.Explain {
+			sr.Explain = explainRank(sr.Observation, scores, terms)
+		}
+		results = append(results, sr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if opts.RecencyWeight > 0 {
+		blendRecency(results, opts.RecencyWeight, time.Now())
+		results = results[min(max(opts.Offset, 0), len(results)):]
+	}
+
+	if len(results) == 0 && opts.Fuzzy && !opts.Raw {
+		// Past the last page of real matches is still an empty page
+		if n, err := s.SearchCount(query, opts); err == nil && n == 0 {
+			return s.fuzzyPage(query, opts, limit)
+		}
+	}
+
+	resp := &SearchResponse{Results: results, PrefixMatch: prefixMatch}
+	if len(results) > limit {
+		resp.Results = results[:limit]
+		resp.HasMore = true
+	}
+	resp.Returned = len(resp.Results)
+	return resp, nil
+}
+
+// browsePage answers an empty query: the most recent observations that pass
+// the search filters. Exclude terms still apply, via an FTS subquery.
+func (s *Store) browsePage(opts SearchOptions, limit int) (*SearchResponse, error) {
+	from, args, _ := s.searchSource("", opts)
+	sql := "SELECT " + observationColumns + from
+
+	sql += " ORDER BY o.occurred_at DESC, o.id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit+1, max(opts.Offset, 0))
+
+	observations, err := s.queryObservations(sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+
+	resp := &SearchResponse{Fallback: true}
+	for _, o := range observations {
+		resp.Results = append(resp.Results, SearchResult{Observation: o})
+	}
+	if len(resp.Results) > limit {
+		resp.Results = resp.Results[:limit]
+		resp.HasMore = true
+	}
+	resp.Returned = len(resp.Results)
+	return resp, nil
+}
+
+// searchSource returns the FROM and WHERE clauses (with their args) that
+// select every observation Search would match for query and opts, ignoring
+// Limit and Offset, and whether query words are matched as prefixes. An empty
+// query matches everything that passes the filters, as browsePage does.
+func (s *Store) searchSource(query string, opts SearchOptions) (string, []any, bool) {
+	opts.Project, opts.Projects = "", s.searchProjects(opts)
+	filters, filterArgs := searchFilters(opts)
+	excluded := excludeFTS(opts.ExcludeTerms)
+
+	if strings.TrimSpace(query) == "" {
+		from := " FROM observations o WHERE 1=1" + filters
+		if excluded != "" {
+			from += " AND o.id NOT IN (SELECT rowid FROM observations_fts WHERE observations_fts MATCH ?)"
+			filterArgs = append(filterArgs, excluded)
+		}
+		return from, filterArgs, false
+	}
+
+	// Sanitize query for FTS5 — wrap each term in quotes to avoid syntax errors
+	ftsQuery, prefixMatch := query, false
+	if !opts.Raw {
+		ftsQuery, prefixMatch = s.sanitizeFTS(query)
+	}
+	if excluded != "" {
+		ftsQuery = "(" + ftsQuery + ") NOT (" + excluded + ")"
+	}
+
+	from := `
+		FROM observations_fts fts
+		JOIN observations o ON o.id = fts.rowid
+		WHERE observations_fts MATCH ?
+	` + filters
+	return from, append([]any{ftsQuery}, filterArgs...), prefixMatch
+}
+
+// SearchCount returns how many observations Search would match for query
+// and opts across all pages, so a UI can show "10 of 234". Limit, Offset
+// and RecencyWeight don't affect it.
+func (s *Store) SearchCount(query string, opts SearchOptions) (int, error) {
+	from, args, _ := s.searchSource(query, opts)
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*)"+from, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("search count: %w", err)
+	}
+	return n, nil
+}
+
+// SearchWithCount is Search plus SearchCount: one page of results and the
+// total number of matches. For fuzzy results the total is FuzzyTotal.
+func (s *Store) SearchWithCount(query string, opts SearchOptions) ([]SearchResult, int, error) {
+	resp, err := s.SearchPage(query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.Fuzzy {
+		return resp.Results, resp.FuzzyTotal, nil
+	}
+	total, err := s.SearchCount(query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Results, total, nil
+}
+
+// UnifiedResult kinds.
+const (
+	ResultObservation = "observation"
+	ResultPrompt      = "prompt"
+)
+
+// UnifiedResult is one SearchAll hit. Kind says which of Observation and
+// Prompt is set.
+type UnifiedResult struct {
+	Kind        string       `json:"kind"`
+	Rank        float64      `json:"rank"`
+	Observation *Observation `json:"observation,omitempty"`
+	Prompt      *Prompt      `json:"prompt,omitempty"`
+}
+
+// SearchAll searches observations and user prompts in one call and
+// interleaves the matches by rank, best first. opts filters observations as
+// in Search. Prompts honour Project, ExcludeTerms and Raw, and are left out
+// when opts filters on something prompts don't have (Type, tags, excluded
+// types). Limit and Offset apply to the merged list; RecencyWeight and
+// Explain are ignored. Each FTS index scores on its own scale, so the
+// interleaving is approximate. Unlike Search, the query is required.
+func (s *Store) SearchAll(query string, opts SearchOptions) ([]UnifiedResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("search all: query is required")
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > s.cfg.MaxSearchResults {
+		limit = s.cfg.MaxSearchResults
+	}
+	offset := max(opts.Offset, 0)
+
+	// Both lists are fetched up to the end of the requested page, then merged
+	obsOpts := opts
+	obsOpts.Limit, obsOpts.Offset = offset+limit, 0
+	obsOpts.RecencyWeight, obsOpts.Explain = 0, false
+	observations, err := s.Search(query, obsOpts)
+	if err != nil {
+		return nil, err
+	}
+	var results []UnifiedResult
+	for i := range observations {
+		results = append(results, UnifiedResult{Kind: ResultObservation, Rank: observations[i].Rank, Observation: &observations[i].Observation})
+	}
+
+	// Prompts have no type, tags or author, so those filters leave them out
+	if opts.Type == "" && len(opts.Types) == 0 && opts.Tag == "" && len(opts.Tags) == 0 && len(opts.ExcludeTypes) == 0 && opts.Author == "" {
+		prompts, err := s.rankedPrompts(query, opts, offset+limit)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, prompts...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank < results[j].Rank
+	})
+	results = results[min(offset, len(results)):]
+	return results[:min(limit, len(results))], nil
+}
+
+// rankedPrompts is the prompt half of SearchAll: up to limit prompts
+// matching query, with their FTS rank, best first.
+func (s *Store) rankedPrompts(query string, opts SearchOptions, limit int) ([]UnifiedResult, error) {
+	ftsQuery := query
+	if !opts.Raw {
+		ftsQuery, _ = s.sanitizeFTS(query)
+	}
+	if excluded := excludeFTS(opts.ExcludeTerms); excluded != "" {
+		ftsQuery = "(" + ftsQuery + ") NOT (" + excluded + ")"
+	}
+
+	stmt := `
+		SELECT p.id, p.session_id, p.content, p.project, p.created_at, fts.rank
+		FROM prompts_fts fts
+		JOIN user_prompts p ON p.id = fts.rowid
+		WHERE prompts_fts MATCH ?
+	`
+	args := []any{ftsQuery}
+
+	if projects := s.searchProjects(opts); len(projects) > 0 {
+		stmt += " AND p.project IN (?" + strings.Repeat(", ?", len(projects)-1) + ")"
+		for _, p := range projects {
+			args = append(args, p)
+		}
+	}
+	if opts.SessionID != "" {
+		stmt += " AND p.session_id = ?"
+		args = append(args, opts.SessionID)
+	}
+
+	stmt += " ORDER BY fts.rank, p.id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search prompts: %w", err)
+	}
+	defer rows.Close()
+
+	var results []UnifiedResult
+	for rows.Next() {
+		r := UnifiedResult{Kind: ResultPrompt, Prompt: &Prompt{}}
+		var project sql.NullString
+		if err := rows.Scan(&r.Prompt.ID, &r.Prompt.SessionID, &r.Prompt.Content, &project, &r.Prompt.CreatedAt, &r.Rank); err != nil {
+			return nil, err
+		}
+		r.Prompt.Project = project.String
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// Recency blending (SearchOptions.RecencyWeight).
+const (
+	// recencyHalfLife is the age at which a match's recency score halves.
+	recencyHalfLife = 30 * 24 * time.Hour
+	// recencyCandidates is how many times the requested results are fetched
+	// by FTS rank before reranking, so older top matches have room to fall.
+	recencyCandidates = 5
+)
+
+// blendRecency reorders FTS results, best first, by the score documented on
+// SearchOptions.RecencyWeight. Weights above 1 count as 1.
+func blendRecency(results []SearchResult, weight float64, now time.Time) {
+	weight = min(weight, 1)
+
+	boosted := func(r SearchResult) float64 {
+		return r.Rank * (1 + 0.1*float64(min(r.AccessCount, 10)))
+	}
+	best := 0.0
+	for _, r := range results {
+		best = min(best, boosted(r))
+	}
+
+	scores := make(map[int64]float64, len(results))
+	for _, r := range results {
+		relevance := 1.0
+		if best < 0 {
+			relevance = boosted(r) / best
+		}
+		recency := 0.0
+		if created, err := time.Parse(sqliteTimeLayout, r.OccurredAt); err == nil {
+			age := max(now.Sub(created), 0)
+			recency = math.Exp2(-float64(age) / float64(recencyHalfLife))
+		}
+		scores[r.ID] = (1-weight)*relevance + weight*recency
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return scores[results[i].ID] > scores[results[j].ID]
+	})
+}
+
+// searchProjects is the union of opts.Project and opts.Projects, resolved
+// through project aliases, without blanks or duplicates.
+func (s *Store) searchProjects(opts SearchOptions) []string {
+	var projects []string
+	for _, p := range append([]string{opts.Project}, opts.Projects...) {
+		if p = s.canonicalProject(strings.TrimSpace(p)); p != "" && !slices.Contains(projects, p) {
+			projects = append(projects, p)
+		}
+	}
+	return projects
+}
+
+// searchTypes merges opts.Type and opts.Types, dropping blanks and repeats.
+func searchTypes(opts SearchOptions) []string {
+	var types []string
+	for _, t := range append([]string{opts.Type}, opts.Types...) {
+		if t = strings.TrimSpace(t); t != "" && !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// searchFilters builds the " AND ..." clauses for the non-query parts of
+// SearchOptions, against observations aliased as o.
+func searchFilters(opts SearchOptions) (string, []any) {
+	var sql string
+	var args []any
+
+	if !opts.IncludeArchived {
+		sql += " AND o.archived = 0"
+	}
+
+	if types := searchTypes(opts); len(types) > 0 {
+		sql += " AND o.type IN (?" + strings.Repeat(", ?", len(types)-1) + ")"
+		for _, t := range types {
+			args = append(args, t)
+		}
+	}
+
+	// Callers resolve Project into Projects (see searchProjects)
+	if len(opts.Projects) > 0 {
+		sql += " AND o.project IN (?" + strings.Repeat(", ?", len(opts.Projects)-1) + ")"
+		for _, p := range opts.Projects {
+			args = append(args, p)
+		}
+	}
+
+	if opts.Author != "" {
+		sql += " AND o.author = ?"
+		args = append(args, opts.Author)
+	}
+
+	if opts.SessionID != "" {
+		sql += " AND o.session_id = ?"
+		args = append(args, opts.SessionID)
+	}
+
+	for _, tag := range normalizeTags(append([]string{opts.Tag}, opts.Tags...)) {
+		sql += " AND o.id IN (SELECT observation_id FROM observation_tags WHERE tag = ?)"
+		args = append(args, tag)
+	}
+
+	if len(opts.ExcludeTypes) > 0 {
+		sql += " AND o.type NOT IN (?" + strings.Repeat(", ?", len(opts.ExcludeTypes)-1) + ")"
+		for _, t := range opts.ExcludeTypes {
+			args = append(args, t)
+		}
+	}
+
+	return sql, args
+}
+
+// ftsColumns lists observations_fts columns in declaration order, which is
+// the order bm25() weights are given in.
+var ftsColumns = []string{"title", "content", "tool_name", "type", "project"}
+
+// rankExpression builds the bm25() call used to rank search results. The
+// weights are formatted into the SQL since bm25() only takes literals there.
+func rankExpression(weights []float64) (string, error) {
+	if len(weights) == 0 {
+		return "bm25(observations_fts)", nil
+	}
+	if len(weights) != len(ftsColumns) {
+		return "", fmt.Errorf("got %d weights, expected %d (%s)", len(weights), len(ftsColumns), strings.Join(ftsColumns, ", "))
+	}
+	parts := make([]string, len(weights))
+	for i, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return "", fmt.Errorf("invalid %s weight %v (expected a number >= 0)", ftsColumns[i], w)
+		}
+		parts[i] = strconv.FormatFloat(w, 'f', -1, 64)
+	}
+	return "bm25(observations_fts, " + strings.Join(parts, ", ") + ")", nil
+}
+
+// explainRank pairs per-column BM25 scores with the query terms that appear
+// in each column. Term matching is a case-insensitive substring check, which
+// is close enough to the FTS tokenizer for diagnostics.
+func explainRank(o Observation, scores []float64, terms []string) *RankExplanation {
+	values := []string{o.Title, o.Content, deref(o.ToolName), o.Type, deref(o.Project)}
+
+	exp := &RankExplanation{}
+	for i, col := range ftsColumns {
+		cs := ColumnScore{Column: col, BM25: scores[i]}
+		if cs.BM25 == 0 {
+			cs.BM25 = 0 // normalize -0 from zero-weighted columns
+		}
+		lower := strings.ToLower(values[i])
+		for _, t := range terms {
+			if strings.Contains(lower, t) {
+				cs.Terms = append(cs.Terms, t)
+			}
+		}
+		exp.Columns = append(exp.Columns, cs)
+	}
+	return exp
+}
+
+// ─── Stats ───────────────────────────────────────────────────────────────────
+
+func (s *Store) Stats() (*Stats, error) {
+	stats := &Stats{SearchCache: s.SearchCacheStats()}
+
+	err := s.db.QueryRow(
+		`SELECT
+			(SELECT COUNT(*) FROM sessions),
+			(SELECT COUNT(*) FROM observations),
+			(SELECT COUNT(*) FROM user_prompts)`,
+	).Scan(&stats.TotalSessions, &stats.TotalObservations, &stats.TotalPrompts)
+	if err != nil {
+		return nil, fmt.Errorf("stats: %w", err)
+	}
+	byType, err := s.CountObservations("")
+	if err != nil {
+		return nil, fmt.Errorf("stats: types: %w", err)
+	}
+	stats.ByType = byType
+
+	rows, err := s.db.Query("SELECT DISTINCT project FROM observations WHERE project IS NOT NULL ORDER BY project")
+	if err != nil {
+		return nil, fmt.Errorf("stats: projects: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, fmt.Errorf("stats: projects: %w", err)
+		}
+		stats.Projects = append(stats.Projects, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("stats: projects: %w", err)
+	}
+
+	return stats, nil
+}
+
+// ErrProjectNotFound is returned by ProjectStats and ProjectSummary for a
+// project with no sessions, observations or prompts.
+var ErrProjectNotFound = errors.New("project not found")
+
+// ProjectStats is Stats for one project: its session, observation and
+// prompt counts, observations by type, and when its first and latest
+// activity was. Projects is just project. It returns ErrProjectNotFound if
+// nothing is stored under project.
+func (s *Store) ProjectStats(project string) (*Stats, error) {
+	project = s.canonicalProject(project)
+	if project == "" {
+		return nil, errors.New("project stats: project is required")
+	}
+	stats := &Stats{Project: project, Projects: []string{project}}
+
+	err := s.db.QueryRow(
+		`SELECT
+			(SELECT COUNT(*) FROM sessions WHERE project = ?),
+			(SELECT COUNT(*) FROM observations WHERE project = ?),
+			(SELECT COUNT(*) FROM user_prompts WHERE project = ?)`,
+		project, project, project,
+	).Scan(&stats.TotalSessions, &stats.TotalObservations, &stats.TotalPrompts)
+	if err != nil {
+		return nil, fmt.Errorf("project stats: %w", err)
+	}
+	if stats.TotalSessions == 0 && stats.TotalObservations == 0 && stats.TotalPrompts == 0 {
+		return nil, fmt.Errorf("%w: no memories for %q", ErrProjectNotFound, project)
+	}
+
+	var firstAt, lastAt sql.NullString
+	err = s.db.QueryRow(
+		`SELECT MIN(at), MAX(at) FROM (
+			SELECT started_at AS at FROM sessions WHERE project = ?
+			UNION ALL SELECT created_at FROM observations WHERE project = ?
+			UNION ALL SELECT created_at FROM user_prompts WHERE project = ?
+		)`,
+		project, project, project,
+	).Scan(&firstAt, &lastAt)
+	if err != nil {
+		return nil, fmt.Errorf("project stats: %w", err)
+	}
+	stats.FirstAt, stats.LastAt = firstAt.String, lastAt.String
+
+	if stats.ByType, err = s.CountObservations(project); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// CountObservations returns how many observations there are of each type,
+// optionally limited to one project.
+func (s *Store) CountObservations(project string) (map[string]int, error) {
+	project = s.canonicalProject(project)
+	query := "SELECT type, COUNT(*) FROM observations"
+	var args []any
+	if project != "" {
+		query += " WHERE project = ?"
+		args = append(args, project)
+	}
+	query += " GROUP BY type"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("count observations: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var typ string
+		var n int
+		if err := rows.Scan(&typ, &n); err != nil {
+			return nil, err
+		}
+		counts[typ] = n
+	}
+	return counts, rows.Err()
+}
+
+// ProjectSummary aggregates everything stored for project: counts, the date
+// range, the most common observation types, key decisions (decision-type or
+// importance >= 3), and the latest sessions and observations.
+func (s *Store) ProjectSummary(project string) (*ProjectSummary, error) {
+	project = s.canonicalProject(project)
+	sum := &ProjectSummary{Project: project}
+
+	var firstAt, lastAt sql.NullString
+	err := s.db.QueryRow(
+		`SELECT COUNT(*), MIN(created_at), MAX(created_at) FROM observations WHERE project = ?`, project,
+	).Scan(&sum.Observations, &firstAt, &lastAt)
+	if err != nil {
+		return nil, fmt.Errorf("project summary: %w", err)
+	}
+	sum.FirstAt, sum.LastAt = firstAt.String, lastAt.String
+
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM sessions WHERE project = ?", project).Scan(&sum.Sessions); err != nil {
+		return nil, fmt.Errorf("project summary: sessions: %w", err)
+	}
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM user_prompts WHERE project = ?", project).Scan(&sum.Prompts); err != nil {
+		return nil, fmt.Errorf("project summary: prompts: %w", err)
+	}
+	if sum.Sessions == 0 && sum.Observations == 0 && sum.Prompts == 0 {
+		return nil, fmt.Errorf("%w: no memories for %q", ErrProjectNotFound, project)
+	}
+
+	rows, err := s.db.Query(
+		`SELECT type, COUNT(*) AS n FROM observations WHERE project = ?
+		 GROUP BY type ORDER BY n DESC, type LIMIT 5`, project,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("project summary: types: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tc TypeCount
+		if err := rows.Scan(&tc.Type, &tc.Count); err != nil {
+			return nil, err
+		}
+		sum.TopTypes = append(sum.TopTypes, tc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sum.KeyDecisions, err = s.queryObservations(
+		`SELECT `+observationColumns+` FROM observations o
+		 WHERE o.project = ? AND (o.type = 'decision' OR o.importance >= 3)
+		 ORDER BY o.importance DESC, o.occurred_at DESC LIMIT 10`, project,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("project summary: decisions: %w", err)
+	}
+
+	err = s.db.QueryRow(
+		"SELECT COUNT(*) FROM observations WHERE project = ? AND status IS NOT NULL AND status != ?",
+		project, StatusDone,
+	).Scan(&sum.OpenTasks)
+	if err != nil {
+		return nil, fmt.Errorf("project summary: open tasks: %w", err)
+	}
+
+	if sum.RecentSessions, err = s.RecentSessions(project, 5); err != nil {
+		return nil, err
+	}
+	if sum.RecentActivity, err = s.RecentObservations(project, 10, 0, OrderCreated); err != nil {
+		return nil, err
+	}
+
+	return sum, nil
+}
+
+// ─── Context Formatting ─────────────────────────────────────────────────────
+
+// FormatContext renders Context with the context template, or returns ""
+// when there is nothing to show.
+func (s *Store) FormatContext(project string) (string, error) {
+	data, err := s.Context(project)
+	if err != nil {
+		return "", err
+	}
+	if data.empty() {
+		return "", nil
+	}
+
+	out, err := s.renderContext(data)
+	if err != nil {
+		return "", err
+	}
+	if budget := s.cfg.MaxContextChars; budget > 0 && utf8.RuneCountInString(out) > budget {
+		return s.fitContext(data, budget)
+	}
+	return out, nil
+}
+
+func (s *Store) renderContext(data *ContextData) (string, error) {
+	var b strings.Builder
+	if err := s.contextTmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("format context: %w", err)
+	}
+	return b.String(), nil
+}
+
+// fitContext renders data with items dropped one at a time, least important
+// first (see ContextData.dropLeastImportant), until the output plus a note
+// saying how many were left out fits in budget characters.
+func (s *Store) fitContext(data *ContextData, budget int) (string, error) {
+	omitted := 0
+	for data.dropLeastImportant() {
+		omitted++
+		note := fmt.Sprintf("...%d older items omitted\n", omitted)
+		if data.empty() {
+			return note, nil
+		}
+		out, err := s.renderContext(data)
+		if err != nil {
+			return "", err
+		}
+		if utf8.RuneCountInString(out)+utf8.RuneCountInString(note) <= budget {
+			return out + note, nil
+		}
+	}
+	return "", nil
+}
+
+// Context gathers what FormatContext renders, for callers that want the
+// structured data rather than text.
+func (s *Store) Context(project string) (*ContextData, error) {
+	project = s.canonicalProject(project)
+	data := &ContextData{Project: project}
+	var err error
+
+	if data.Sessions, err = s.RecentSessions(project, 5); err != nil {
+		return nil, err
+	}
+	if data.Observations, err = s.RecentObservations(project, s.cfg.MaxContextResults, 0, OrderCreated); err != nil {
+		return nil, err
+	}
+	if data.Prompts, err = s.RecentPrompts(project, 10); err != nil {
+		return nil, err
+	}
+	if data.Tasks, err = s.OpenTasks(project, 20); err != nil {
+		return nil, err
+	}
+	// Global insights ignore the project filter on purpose
+	if data.Insights, err = s.GlobalInsights(10); err != nil {
+		return nil, err
+	}
+	if data.Facts, err = s.Facts(project, 10); err != nil {
+		return nil, err
+	}
+	if data.Pinned, err = s.PinnedObservations(project, 20); err != nil {
+		return nil, err
+	}
+
+	return data, nil
 }
 
-// ─── Observations ────────────────────────────────────────────────────────────
+// ContextData is what the context template is rendered with.
+type ContextData struct {
+	Project      string           `json:"project"`
+	Pinned       []Observation    `json:"pinned"`       // pinned observations, newest first
+	Insights     []Observation    `json:"insights"`     // project-less, high-importance observations
+	Facts        []Fact           `json:"facts"`        // recurring facts, most seen first
+	Tasks        []Observation    `json:"tasks"`        // open tasks, oldest first
+	Sessions     []SessionSummary `json:"sessions"`     // recent sessions
+	Prompts      []Prompt         `json:"prompts"`      // recent user prompts
+	Observations []Observation    `json:"observations"` // recent observations
+}
 
-func (s *Store) AddObservation(p AddObservationParams) (int64, error) {
-	// Strip <private>...</private> tags before persisting ANYTHING
-	title := stripPrivateTags(p.Title)
-	content := stripPrivateTags(p.Content)
+// dropLeastImportant removes the last item of the least important
+// non-empty section, reporting false if there was nothing left. Sections go
+// in this order, so pinned observations are kept longest, then prompts, then
+// recent observations: sessions, insights, facts, tasks, observations,
+// prompts, pinned. The last item is the oldest in the newest-first lists,
+// the least seen fact, and the newest open task.
+func (d *ContextData) dropLeastImportant() bool {
+	if n := len(d.Sessions); n > 0 {
+		d.Sessions = d.Sessions[:n-1]
+	} else if n := len(d.Insights); n > 0 {
+		d.Insights = d.Insights[:n-1]
+	} else if n := len(d.Facts); n > 0 {
+		d.Facts = d.Facts[:n-1]
+	} else if n := len(d.Tasks); n > 0 {
+		d.Tasks = d.Tasks[:n-1]
+	} else if n := len(d.Observations); n > 0 {
+		d.Observations = d.Observations[:n-1]
+	} else if n := len(d.Prompts); n > 0 {
+		d.Prompts = d.Prompts[:n-1]
+	} else if n := len(d.Pinned); n > 0 {
+		d.Pinned = d.Pinned[:n-1]
+	} else {
+		return false
+	}
+	return true
+}
 
-	if len(content) > s.cfg.MaxObservationLength {
-		content = content[:s.cfg.MaxObservationLength] + "... [truncated]"
+func (d *ContextData) empty() bool {
+	return len(d.Pinned) == 0 && len(d.Sessions) == 0 && len(d.Observations) == 0 && len(d.Prompts) == 0 &&
+		len(d.Tasks) == 0 && len(d.Insights) == 0 && len(d.Facts) == 0
+}
+
+// DefaultContextTemplate is the built-in FormatContext layout. Custom
+// templates (Config.ContextTemplate) get the same ContextData and helpers:
+// truncate (string, max), deref (*string), and when (timestamp, rendered
+// per Config.TimeFormat).
+const DefaultContextTemplate = `## Memory from Previous Sessions
+
+{{if .Pinned}}### Pinned
+{{range .Pinned}}- [{{.Type}}] **{{.Title}}**: {{truncate .Content 300}}
+{{end}}
+{{end}}{{if .Insights}}### Global Insights
+{{range .Insights}}- [{{.Type}}] **{{.Title}}**: {{truncate .Content 300}}
+{{end}}
+{{end}}{{if .Facts}}### Known Facts
+{{range .Facts}}- **{{.Key}}**: {{truncate .Content 300}} (seen {{.SeenCount}}x, last {{when .LastSeen}})
+{{end}}
+{{end}}{{if .Tasks}}### Open Tasks
+{{range .Tasks}}- [{{deref .Status}}] #{{.ID}} **{{.Title}}**: {{truncate .Content 200}}
+{{end}}
+{{end}}{{if .Sessions}}### Recent Sessions
+{{range .Sessions}}- **{{.Project}}** ({{when .StartedAt}}){{if .Summary}}: {{truncate (deref .Summary) 200}}{{end}} [{{.ObservationCount}} observations]
+{{end}}
+{{end}}{{if .Prompts}}### Recent User Prompts
+{{range .Prompts}}- {{when .CreatedAt}}: {{truncate .Content 200}}
+{{end}}
+{{end}}{{if .Observations}}### Recent Observations
+{{range .Observations}}- [{{.Type}}] **{{.Title}}**: {{truncate .Content 300}}
+{{end}}
+{{end}}`
+
+// parseContextTemplate compiles a context template, falling back to the
+// built-in one when text is empty. timeFormat is baked into the when helper.
+func parseContextTemplate(text, timeFormat string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultContextTemplate
 	}
+	return template.New("context").Funcs(template.FuncMap{
+		"truncate": truncate,
+		"deref":    deref,
+		"when": func(ts string) string {
+			return FormatTimestamp(ts, timeFormat)
+		},
+	}).Parse(text)
+}
 
-	res, err := s.db.Exec(
-		`INSERT INTO observations (session_id, type, title, content, tool_name, project)
-		 VALUES (?, ?, ?, ?, ?, ?)`,
-		p.SessionID, p.Type, title, content,
-		nullableString(p.ToolName), nullableString(p.Project),
-	)
+// FormatSessionContext renders one session for pasting into a new
+// conversation: its summary, every prompt and every observation, oldest
+// first, in the same Markdown style as FormatContext. Like FormatContext it
+// keeps to MaxContextChars, dropping the oldest observations, then the
+// oldest prompts.
+func (s *Store) FormatSessionContext(sessionID string) (string, error) {
+	tl, err := s.SessionTimeline(sessionID)
 	if err != nil {
-		return 0, err
+		return "", err
+	}
+	tmpl, err := parseContextTemplate(sessionContextTemplate, s.cfg.TimeFormat)
+	if err != nil {
+		return "", fmt.Errorf("format session context: %w", err)
 	}
-	return res.LastInsertId()
-}
 
-func (s *Store) RecentObservations(project string, limit int) ([]Observation, error) {
-	if limit <= 0 {
-		limit = s.cfg.MaxContextResults
+	omitted := 0
+	for {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, tl); err != nil {
+			return "", fmt.Errorf("format session context: %w", err)
+		}
+		out := b.String()
+		if omitted > 0 {
+			out += fmt.Sprintf("...%d earlier items omitted\n", omitted)
+		}
+		budget := s.cfg.MaxContextChars
+		if budget <= 0 || utf8.RuneCountInString(out) <= budget {
+			return out, nil
+		}
+		switch {
+		case len(tl.Observations) > 0:
+			tl.Observations = tl.Observations[1:]
+		case len(tl.Prompts) > 0:
+			tl.Prompts = tl.Prompts[1:]
+		default:
+			return out, nil
+		}
+		omitted++
 	}
+}
 
-	query := `
-		SELECT o.id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project, o.created_at
-		FROM observations o
-	`
-	args := []any{}
+// sessionContextTemplate is FormatSessionContext's layout, rendered with a
+// SessionTimelineResult.
+const sessionContextTemplate = `## Session {{.Session.ID}}
 
-	if project != "" {
-		query += " WHERE o.project = ?"
-		args = append(args, project)
-	}
+**Project:** {{.Session.Project}} | **Started:** {{when .Session.StartedAt}} | {{if .Session.EndedAt}}**Ended:** {{when (deref .Session.EndedAt)}}{{else}}**In progress**{{end}}
 
-	query += " ORDER BY o.created_at DESC LIMIT ?"
-	args = append(args, limit)
+{{if .Session.Summary}}### Summary
+{{deref .Session.Summary}}
 
-	return s.queryObservations(query, args...)
-}
+{{end}}{{if .Prompts}}### User Prompts
+{{range .Prompts}}- {{when .CreatedAt}}: {{truncate .Content 500}}
+{{end}}
+{{end}}{{if .Observations}}### Observations
+{{range .Observations}}- {{when .OccurredAt}} [{{.Type}}] **{{.Title}}**: {{truncate .Content 300}}
+{{end}}
+{{end}}`
 
-// ─── User Prompts ────────────────────────────────────────────────────────────
+// ─── Export / Import ─────────────────────────────────────────────────────────
 
-func (s *Store) AddPrompt(p AddPromptParams) (int64, error) {
-	content := stripPrivateTags(p.Content)
-	if len(content) > s.cfg.MaxObservationLength {
-		content = content[:s.cfg.MaxObservationLength] + "... [truncated]"
-	}
+func (s *Store) Export() (*ExportData, error) {
+	return s.ExportSince(ExportWatermark{})
+}
 
-	res, err := s.db.Exec(
-		`INSERT INTO user_prompts (session_id, content, project) VALUES (?, ?, ?)`,
-		p.SessionID, content, nullableString(p.Project),
-	)
-	if err != nil {
-		return 0, err
-	}
-	return res.LastInsertId()
+// ExportWatermark marks how far an incremental export got. Observations and
+// prompts are tracked by ID rather than created_at — IDs only grow, and
+// timestamps have one-second resolution, so rows written in the same second
+// as the last export would be missed or duplicated.
+type ExportWatermark struct {
+	ObservationID    int64  `json:"observation_id"`
+	PromptID         int64  `json:"prompt_id"`
+	SessionStartedAt string `json:"session_started_at"`
+	ExportedAt       string `json:"exported_at,omitempty"`
 }
 
-func (s *Store) RecentPrompts(project string, limit int) ([]Prompt, error) {
-	if limit <= 0 {
-		limit = 20
-	}
+// ExportSince exports rows newer than w: observations and prompts with a
+// higher ID, plus sessions started after w or referenced by those rows. The
+// zero watermark exports everything.
+func (s *Store) ExportSince(w ExportWatermark) (*ExportData, error) {
+	return s.exportData(w, ExportOptions{})
+}
 
-	query := `SELECT id, session_id, content, project, created_at FROM user_prompts`
-	args := []any{}
+// ExportOptions narrows an export to part of the database. The zero value
+// exports everything.
+type ExportOptions struct {
+	Project string
+	// Types keeps observations of any of these types. Prompts have no
+	// type, so a type filter leaves them out.
+	Types []string
+	// Since and Until bound observations by occurred_at and prompts by
+	// created_at, Since inclusive and Until exclusive, so consecutive
+	// ranges don't overlap. Any form ParseTimestamp accepts.
+	Since string
+	Until string
+}
 
-	if project != "" {
-		query += " WHERE project = ?"
-		args = append(args, project)
-	}
+// ExportFiltered exports the observations and prompts matching opts, plus
+// the sessions they belong to. The result imports like a full export.
+func (s *Store) ExportFiltered(opts ExportOptions) (*ExportData, error) {
+	return s.exportData(ExportWatermark{}, opts)
+}
 
-	query += " ORDER BY created_at DESC LIMIT ?"
-	args = append(args, limit)
+// ExportFilteredTo streams the rows ExportFiltered would return to out. The
+// summary's Watermark doesn't account for filtered-out rows, so it mustn't
+// be saved for --incremental.
+func (s *Store) ExportFilteredTo(out io.Writer, opts ExportOptions) (*ExportSummary, error) {
+	return s.exportTo(out, ExportWatermark{}, opts)
+}
 
-	rows, err := s.db.Query(query, args...)
+// exportData collects the rows exportTo would stream into an ExportData,
+// from the same queries, without encoding them.
+func (s *Store) exportData(since ExportWatermark, opts ExportOptions) (*ExportData, error) {
+	// Empty rather than nil slices, so the data encodes like a streamed export
+	data := &ExportData{Version: "0.1.0", Sessions: []Session{}, Observations: []Observation{}, Prompts: []Prompt{}}
+	sum, err := s.exportRows(since, opts, (*exportCollector)(data))
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	data.ExportedAt = sum.Watermark.ExportedAt
+	return data, nil
+}
 
-	var results []Prompt
-	for rows.Next() {
-		var p Prompt
-		if err := rows.Scan(&p.ID, &p.SessionID, &p.Content, &p.Project, &p.CreatedAt); err != nil {
-			return nil, err
+// exportFilter holds the " AND ..." clauses ExportOptions adds to the
+// observation (aliased o) and prompt (aliased p) queries.
+type exportFilter struct {
+	observations string
+	obsArgs      []any
+	prompts      string
+	promptArgs   []any
+}
+
+func (s *Store) exportFilter(opts ExportOptions) (*exportFilter, error) {
+	f := &exportFilter{}
+	if project := s.canonicalProject(strings.TrimSpace(opts.Project)); project != "" {
+		f.observations += " AND o.project = ?"
+		f.obsArgs = append(f.obsArgs, project)
+		f.prompts += " AND p.project = ?"
+		f.promptArgs = append(f.promptArgs, project)
+	}
+	if types := searchTypes(SearchOptions{Types: opts.Types}); len(types) > 0 {
+		f.observations += " AND o.type IN (?" + strings.Repeat(", ?", len(types)-1) + ")"
+		for _, t := range types {
+			f.obsArgs = append(f.obsArgs, t)
 		}
-		results = append(results, p)
+		f.prompts += " AND 0"
 	}
-	return results, rows.Err()
+	for _, bound := range []struct {
+		name, value, op string
+	}{
+		{"since", opts.Since, ">="},
+		{"until", opts.Until, "<"},
+	} {
+		if strings.TrimSpace(bound.value) == "" {
+			continue
+		}
+		ts, err := ParseTimestamp(bound.value)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", bound.name, err)
+		}
+		f.observations += " AND o.occurred_at " + bound.op + " ?"
+		f.obsArgs = append(f.obsArgs, ts)
+		f.prompts += " AND p.created_at " + bound.op + " ?"
+		f.promptArgs = append(f.promptArgs, ts)
+	}
+	return f, nil
 }
 
-func (s *Store) SearchPrompts(query string, project string, limit int) ([]Prompt, error) {
-	if limit <= 0 {
-		limit = 10
-	}
+// ExportTo streams a full export to out as the same JSON document Export
+// returns.
+func (s *Store) ExportTo(out io.Writer) error {
+	_, err := s.ExportSinceTo(out, ExportWatermark{})
+	return err
+}
 
-	ftsQuery := sanitizeFTS(query)
+// ExportSummary reports what a streamed export wrote.
+type ExportSummary struct {
+	Sessions     int
+	Observations int
+	Prompts      int
+	// Watermark is where the next incremental export should start.
+	Watermark ExportWatermark
+}
 
-	sql := `
-		SELECT p.id, p.session_id, p.content, p.project, p.created_at
-		FROM prompts_fts fts
-		JOIN user_prompts p ON p.id = fts.rowid
-		WHERE prompts_fts MATCH ?
-	`
-	args := []any{ftsQuery}
+// Empty reports whether the export contained no rows.
+func (e *ExportSummary) Empty() bool {
+	return e.Sessions+e.Observations+e.Prompts == 0
+}
 
-	if project != "" {
-		sql += " AND p.project = ?"
-		args = append(args, project)
-	}
+// exportBatchSize is how many observations ExportSinceTo loads at a time, so
+// tags and references can be fetched per batch instead of per row.
+const exportBatchSize = 500
 
-	sql += " ORDER BY fts.rank LIMIT ?"
-	args = append(args, limit)
+// ExportSinceTo streams the rows ExportSince would return to out, encoding
+// them one at a time so memory stays flat however large the database is.
+func (s *Store) ExportSinceTo(out io.Writer, since ExportWatermark) (*ExportSummary, error) {
+	return s.exportTo(out, since, ExportOptions{})
+}
 
-	rows, err := s.db.Query(sql, args...)
+// exportTo streams the rows past since that match opts.
+func (s *Store) exportTo(out io.Writer, since ExportWatermark, opts ExportOptions) (*ExportSummary, error) {
+	bw := bufio.NewWriter(out)
+	ew := &exportWriter{w: bw, enc: json.NewEncoder(bw)}
+	sum, err := s.exportRows(since, opts, ew)
 	if err != nil {
-		return nil, fmt.Errorf("search prompts: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
-
-	var results []Prompt
-	for rows.Next() {
-		var p Prompt
-		if err := rows.Scan(&p.ID, &p.SessionID, &p.Content, &p.Project, &p.CreatedAt); err != nil {
-			return nil, err
-		}
-		results = append(results, p)
+	ew.raw("]}\n")
+	if ew.err == nil {
+		ew.err = bw.Flush()
 	}
-	return results, rows.Err()
+	if ew.err != nil {
+		return nil, fmt.Errorf("export: write: %w", ew.err)
+	}
+	return sum, nil
 }
 
-// ─── Get Single Observation ──────────────────────────────────────────────────
+// ExportLine is one record of an NDJSON export. Exactly one field is set.
+type ExportLine struct {
+	Session     *Session     `json:"session,omitempty"`
+	Observation *Observation `json:"observation,omitempty"`
+	Prompt      *Prompt      `json:"prompt,omitempty"`
+}
 
-func (s *Store) GetObservation(id int64) (*Observation, error) {
-	row := s.db.QueryRow(
-		`SELECT id, session_id, type, title, content, tool_name, project, created_at
-		 FROM observations WHERE id = ?`, id,
-	)
-	var o Observation
-	if err := row.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Content, &o.ToolName, &o.Project, &o.CreatedAt); err != nil {
+// ExportNDJSONTo streams the same rows as ExportTo as NDJSON, one ExportLine
+// per line, so a reader can start on them before the export ends.
+func (s *Store) ExportNDJSONTo(out io.Writer) (*ExportSummary, error) {
+	bw := bufio.NewWriter(out)
+	nw := &ndjsonWriter{w: bw, enc: json.NewEncoder(bw)}
+	sum, err := s.exportRows(ExportWatermark{}, ExportOptions{}, nw)
+	if err != nil {
 		return nil, err
 	}
-	return &o, nil
-}
-
-// ─── Timeline ────────────────────────────────────────────────────────────────
-//
-// Timeline provides chronological context around a specific observation.
-// Given an observation ID, it returns N observations before and M after,
-// all within the same session. This is the "progressive disclosure" pattern
-// from claude-mem — agents first search, then use timeline to drill into
-// the chronological neighborhood of a result.
-
-func (s *Store) Timeline(observationID int64, before, after int) (*TimelineResult, error) {
-	if before <= 0 {
-		before = 5
+	if nw.err == nil {
+		nw.err = bw.Flush()
 	}
-	if after <= 0 {
-		after = 5
+	if nw.err != nil {
+		return nil, fmt.Errorf("export: write: %w", nw.err)
 	}
+	return sum, nil
+}
 
-	// 1. Get the focus observation
-	focus, err := s.GetObservation(observationID)
-	if err != nil {
-		return nil, fmt.Errorf("timeline: observation #%d not found: %w", observationID, err)
-	}
+// exportSink receives an export's rows in document order: begin, then each
+// section's name followed by its rows. failed stops the export early.
+type exportSink interface {
+	begin(exportedAt string)
+	section(name string)
+	session(Session)
+	observation(Observation)
+	prompt(Prompt)
+	failed() error
+}
 
-	// 2. Get session info
-	session, err := s.GetSession(focus.SessionID)
+// exportRows runs the export queries for the rows past since that match
+// opts, handing each row to sink as it's read.
+func (s *Store) exportRows(since ExportWatermark, opts ExportOptions, sink exportSink) (*ExportSummary, error) {
+	f, err := s.exportFilter(opts)
 	if err != nil {
-		// Session might be missing for manual-save observations — non-fatal
-		session = nil
+		return nil, err
 	}
+	sum := &ExportSummary{Watermark: since}
+	sum.Watermark.ExportedAt = Now()
 
-	// 3. Get observations BEFORE the focus (same session, older, chronological order)
-	beforeRows, err := s.db.Query(`
-		SELECT id, session_id, type, title, content, tool_name, project, created_at
-		FROM observations
-		WHERE session_id = ? AND id < ?
-		ORDER BY id DESC
-		LIMIT ?
-	`, focus.SessionID, observationID, before)
+	sink.begin(sum.Watermark.ExportedAt)
+
+	// Sessions
+	sink.section("sessions")
+	// A filtered export only carries the sessions its rows belong to
+	sessionsSQL := "SELECT id, project, directory, started_at, ended_at, summary FROM sessions WHERE started_at > ?"
+	sessionArgs := []any{since.SessionStartedAt}
+	if f.observations != "" {
+		sessionsSQL = "SELECT id, project, directory, started_at, ended_at, summary FROM sessions WHERE 0"
+		sessionArgs = nil
+	}
+	sessionsSQL += `
+		    OR id IN (SELECT o.session_id FROM observations o WHERE o.id > ?` + f.observations + `)
+		    OR id IN (SELECT p.session_id FROM user_prompts p WHERE p.id > ?` + f.prompts + `)
+		 ORDER BY started_at`
+	sessionArgs = append(sessionArgs, since.ObservationID)
+	sessionArgs = append(sessionArgs, f.obsArgs...)
+	sessionArgs = append(sessionArgs, since.PromptID)
+	sessionArgs = append(sessionArgs, f.promptArgs...)
+	rows, err := s.db.Query(sessionsSQL, sessionArgs...)
 	if err != nil {
-		return nil, fmt.Errorf("timeline: before query: %w", err)
+		return nil, fmt.Errorf("export sessions: %w", err)
 	}
-	defer beforeRows.Close()
-
-	var beforeEntries []TimelineEntry
-	for beforeRows.Next() {
-		var e TimelineEntry
-		if err := beforeRows.Scan(&e.ID, &e.SessionID, &e.Type, &e.Title, &e.Content, &e.ToolName, &e.Project, &e.CreatedAt); err != nil {
+	for rows.Next() && sink.failed() == nil {
+		var sess Session
+		if err := rows.Scan(&sess.ID, &sess.Project, &sess.Directory, &sess.StartedAt, &sess.EndedAt, &sess.Summary); err != nil {
+			rows.Close()
 			return nil, err
 		}
-		beforeEntries = append(beforeEntries, e)
+		sink.session(sess)
+		sum.Sessions++
+		sum.Watermark.SessionStartedAt = max(sum.Watermark.SessionStartedAt, sess.StartedAt)
 	}
-	if err := beforeRows.Err(); err != nil {
-		return nil, err
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("export sessions: %w", err)
 	}
-	// Reverse to get chronological order (oldest first)
-	for i, j := 0, len(beforeEntries)-1; i < j; i, j = i+1, j-1 {
-		beforeEntries[i], beforeEntries[j] = beforeEntries[j], beforeEntries[i]
+
+	// Observations, in ID batches
+	sink.section("observations")
+	for after := since.ObservationID; sink.failed() == nil; {
+		args := append([]any{after}, f.obsArgs...)
+		batch, err := s.queryObservations(
+			"SELECT "+observationColumns+" FROM observations o WHERE o.id > ?"+f.observations+" ORDER BY o.id LIMIT ?",
+			append(args, exportBatchSize)...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("export observations: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if err := s.attachTags(batch); err != nil {
+			return nil, fmt.Errorf("export tags: %w", err)
+		}
+		if err := s.attachReferences(batch); err != nil {
+			return nil, fmt.Errorf("export references: %w", err)
+		}
+		if err := s.attachFullContent(batch); err != nil {
+			return nil, fmt.Errorf("export content: %w", err)
+		}
+		for _, o := range batch {
+			sink.observation(o)
+			sum.Observations++
+		}
+		after = batch[len(batch)-1].ID
+		sum.Watermark.ObservationID = max(sum.Watermark.ObservationID, after)
 	}
 
-	// 4. Get observations AFTER the focus (same session, newer, chronological order)
-	afterRows, err := s.db.Query(`
-		SELECT id, session_id, type, title, content, tool_name, project, created_at
-		FROM observations
-		WHERE session_id = ? AND id > ?
-		ORDER BY id ASC
-		LIMIT ?
-	`, focus.SessionID, observationID, after)
+	// Prompts
+	sink.section("prompts")
+	rows, err = s.db.Query(
+		"SELECT p.id, p.session_id, p.content, p.project, p.created_at FROM user_prompts p WHERE p.id > ?"+f.prompts+" ORDER BY p.id",
+		append([]any{since.PromptID}, f.promptArgs...)...,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("timeline: after query: %w", err)
+		return nil, fmt.Errorf("export prompts: %w", err)
 	}
-	defer afterRows.Close()
-
-	var afterEntries []TimelineEntry
-	for afterRows.Next() {
-		var e TimelineEntry
-		if err := afterRows.Scan(&e.ID, &e.SessionID, &e.Type, &e.Title, &e.Content, &e.ToolName, &e.Project, &e.CreatedAt); err != nil {
+	for rows.Next() && sink.failed() == nil {
+		var p Prompt
+		var project sql.NullString
+		if err := rows.Scan(&p.ID, &p.SessionID, &p.Content, &project, &p.CreatedAt); err != nil {
+			rows.Close()
 			return nil, err
 		}
-		afterEntries = append(afterEntries, e)
+		p.Project = project.String
+		sink.prompt(p)
+		sum.Prompts++
+		sum.Watermark.PromptID = max(sum.Watermark.PromptID, p.ID)
 	}
-	if err := afterRows.Err(); err != nil {
-		return nil, err
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("export prompts: %w", err)
 	}
 
-	// 5. Count total observations in the session for context
-	var totalInRange int
-	s.db.QueryRow(
-		"SELECT COUNT(*) FROM observations WHERE session_id = ?", focus.SessionID,
-	).Scan(&totalInRange)
+	return sum, nil
+}
 
-	return &TimelineResult{
-		Focus:        *focus,
-		Before:       beforeEntries,
-		After:        afterEntries,
-		SessionInfo:  session,
-		TotalInRange: totalInRange,
-	}, nil
+// exportWriter writes the hand-assembled export document, remembering the
+// first write error so callers can check once at the end.
+type exportWriter struct {
+	w        *bufio.Writer
+	enc      *json.Encoder
+	err      error
+	sections int // arrays opened so far
+	items    int // elements written to the current array
 }
 
-// ─── Search (FTS5) ───────────────────────────────────────────────────────────
+func (e *exportWriter) raw(s string) {
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
 
-func (s *Store) Search(query string, opts SearchOptions) ([]SearchResult, error) {
-	limit := opts.Limit
-	if limit <= 0 {
-		limit = 10
+func (e *exportWriter) value(v any) {
+	if e.err == nil {
+		e.err = e.enc.Encode(v)
 	}
-	if limit > s.cfg.MaxSearchResults {
-		limit = s.cfg.MaxSearchResults
+}
+
+// item writes the next element of the current array.
+func (e *exportWriter) item(v any) {
+	if e.items > 0 {
+		e.raw(",")
 	}
+	e.value(v)
+	e.items++
+}
 
-	// Sanitize query for FTS5 — wrap each term in quotes to avoid syntax errors
-	ftsQuery := sanitizeFTS(query)
+func (e *exportWriter) begin(exportedAt string) {
+	e.raw(`{"version":"0.1.0","exported_at":`)
+	e.value(exportedAt)
+}
 
-	sql := `
-		SELECT o.id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project, o.created_at,
-		       fts.rank
-		FROM observations_fts fts
-		JOIN observations o ON o.id = fts.rowid
-		WHERE observations_fts MATCH ?
-	`
-	args := []any{ftsQuery}
+func (e *exportWriter) section(name string) {
+	if e.sections > 0 {
+		e.raw("]")
+	}
+	e.raw(`,"` + name + `":[`)
+	e.sections++
+	e.items = 0
+}
+
+func (e *exportWriter) session(v Session)         { e.item(v) }
+func (e *exportWriter) observation(v Observation) { e.item(v) }
+func (e *exportWriter) prompt(v Prompt)           { e.item(v) }
+func (e *exportWriter) failed() error             { return e.err }
+
+// ndjsonWriter encodes an export's rows as ExportLines.
+type ndjsonWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+	err error
+}
 
-	if opts.Type != "" {
-		sql += " AND o.type = ?"
-		args = append(args, opts.Type)
+func (n *ndjsonWriter) line(l ExportLine) {
+	if n.err == nil {
+		n.err = n.enc.Encode(l)
 	}
+}
 
-	if opts.Project != "" {
-		sql += " AND o.project = ?"
-		args = append(args, opts.Project)
+func (n *ndjsonWriter) begin(string)              {}
+func (n *ndjsonWriter) section(string)            {}
+func (n *ndjsonWriter) session(v Session)         { n.line(ExportLine{Session: &v}) }
+func (n *ndjsonWriter) observation(v Observation) { n.line(ExportLine{Observation: &v}) }
+func (n *ndjsonWriter) prompt(v Prompt)           { n.line(ExportLine{Prompt: &v}) }
+func (n *ndjsonWriter) failed() error             { return n.err }
+
+// exportCollector appends an export's rows to an ExportData.
+type exportCollector ExportData
+
+func (c *exportCollector) begin(string)              {}
+func (c *exportCollector) section(string)            {}
+func (c *exportCollector) session(v Session)         { c.Sessions = append(c.Sessions, v) }
+func (c *exportCollector) observation(v Observation) { c.Observations = append(c.Observations, v) }
+func (c *exportCollector) prompt(v Prompt)           { c.Prompts = append(c.Prompts, v) }
+func (c *exportCollector) failed() error             { return nil }
+
+// Watermark returns the watermark just past this export's rows, starting from
+// prev so an empty export doesn't move it backwards.
+func (d *ExportData) Watermark(prev ExportWatermark) ExportWatermark {
+	w := prev
+	w.ExportedAt = d.ExportedAt
+	for _, o := range d.Observations {
+		w.ObservationID = max(w.ObservationID, o.ID)
+	}
+	for _, p := range d.Prompts {
+		w.PromptID = max(w.PromptID, p.ID)
+	}
+	for _, sess := range d.Sessions {
+		w.SessionStartedAt = max(w.SessionStartedAt, sess.StartedAt)
 	}
+	return w
+}
 
-	sql += " ORDER BY fts.rank LIMIT ?"
-	args = append(args, limit)
+// LastExportWatermark returns where the previous incremental export stopped,
+// or the zero watermark if there hasn't been one.
+func (s *Store) LastExportWatermark() (ExportWatermark, error) {
+	var w ExportWatermark
+	err := s.db.QueryRow(
+		"SELECT observation_id, prompt_id, session_started_at, exported_at FROM export_watermark WHERE id = 1",
+	).Scan(&w.ObservationID, &w.PromptID, &w.SessionStartedAt, &w.ExportedAt)
+	if err == sql.ErrNoRows {
+		return ExportWatermark{}, nil
+	}
+	if err != nil {
+		return w, fmt.Errorf("read export watermark: %w", err)
+	}
+	return w, nil
+}
 
-	rows, err := s.db.Query(sql, args...)
+// SaveExportWatermark records w as the starting point of the next
+// incremental export. Call it only once the export has been written out.
+func (s *Store) SaveExportWatermark(w ExportWatermark) error {
+	_, err := s.exec(
+		`INSERT INTO export_watermark (id, observation_id, prompt_id, session_started_at, exported_at)
+		 VALUES (1, ?, ?, ?, ?)
+		 ON CONFLICT(id) DO UPDATE SET
+		   observation_id = excluded.observation_id,
+		   prompt_id = excluded.prompt_id,
+		   session_started_at = excluded.session_started_at,
+		   exported_at = excluded.exported_at`,
+		w.ObservationID, w.PromptID, w.SessionStartedAt, w.ExportedAt,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("search: %w", err)
+		return fmt.Errorf("save export watermark: %w", err)
 	}
-	defer rows.Close()
+	return nil
+}
 
-	var results []SearchResult
-	for rows.Next() {
-		var sr SearchResult
-		if err := rows.Scan(
-			&sr.ID, &sr.SessionID, &sr.Type, &sr.Title, &sr.Content,
-			&sr.ToolName, &sr.Project, &sr.CreatedAt, &sr.Rank,
-		); err != nil {
-			return nil, err
-		}
-		results = append(results, sr)
+// ExportObservations builds a re-importable ExportData holding only the given
+// observations plus the sessions they belong to. Used to save a curated
+// subset (e.g. search results) in the same format as a full export.
+func (s *Store) ExportObservations(observations []Observation) (*ExportData, error) {
+	data := &ExportData{
+		Version:    "0.1.0",
+		ExportedAt: Now(),
 	}
-	return results, rows.Err()
-}
 
-// ─── Stats ───────────────────────────────────────────────────────────────────
+	seen := make(map[string]bool)
+	for _, o := range observations {
+		data.Observations = append(data.Observations, o)
+		if seen[o.SessionID] {
+			continue
+		}
+		seen[o.SessionID] = true
 
-func (s *Store) Stats() (*Stats, error) {
-	stats := &Stats{}
+		sess, err := s.GetSession(o.SessionID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("export session %s: %w", o.SessionID, err)
+		}
+		data.Sessions = append(data.Sessions, *sess)
+	}
 
-	s.db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&stats.TotalSessions)
-	s.db.QueryRow("SELECT COUNT(*) FROM observations").Scan(&stats.TotalObservations)
-	s.db.QueryRow("SELECT COUNT(*) FROM user_prompts").Scan(&stats.TotalPrompts)
+	return data, nil
+}
 
-	rows, err := s.db.Query("SELECT DISTINCT project FROM observations WHERE project IS NOT NULL ORDER BY project")
+// ExportMarkdownDir writes one markdown file per session into dir, each
+// holding the session summary, its user prompts and its observations.
+// Files are named <date>_<project>_<session-id>.md so they sort
+// chronologically. Returns the number of files written.
+func (s *Store) ExportMarkdownDir(dir string) (int, error) {
+	data, err := s.Export()
 	if err != nil {
-		return stats, nil
+		return 0, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var p string
-		if err := rows.Scan(&p); err == nil {
-			stats.Projects = append(stats.Projects, p)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("export markdown: create dir: %w", err)
+	}
+
+	promptsBySession := make(map[string][]Prompt)
+	for _, p := range data.Prompts {
+		promptsBySession[p.SessionID] = append(promptsBySession[p.SessionID], p)
+	}
+	obsBySession := make(map[string][]Observation)
+	for _, o := range data.Observations {
+		obsBySession[o.SessionID] = append(obsBySession[o.SessionID], o)
+	}
+
+	written := 0
+	for _, sess := range data.Sessions {
+		md := SessionMarkdown(sess, promptsBySession[sess.ID], obsBySession[sess.ID])
+		path := filepath.Join(dir, sessionMarkdownFilename(sess))
+		if err := os.WriteFile(path, []byte(md), 0644); err != nil {
+			return written, fmt.Errorf("export markdown: write %s: %w", path, err)
 		}
+		written++
 	}
 
-	return stats, nil
+	return written, nil
 }
 
-// ─── Context Formatting ─────────────────────────────────────────────────────
-
-func (s *Store) FormatContext(project string) (string, error) {
-	sessions, err := s.RecentSessions(project, 5)
+// ExportMarkdown renders memory as a single markdown document: sessions in
+// chronological order, each with its summary and its observations as bullets
+// (bold title, type badge, timestamp, then the content). A non-empty project
+// keeps only that project's observations and the sessions holding them.
+func (s *Store) ExportMarkdown(project string) (string, error) {
+	project = s.canonicalProject(project)
+	data, err := s.Export()
 	if err != nil {
 		return "", err
 	}
 
-	observations, err := s.RecentObservations(project, s.cfg.MaxContextResults)
-	if err != nil {
-		return "", err
+	var groups []SessionGroup
+	total := 0
+	for _, g := range data.Grouped().Sessions {
+		if project != "" {
+			var kept []Observation
+			for _, o := range g.Observations {
+				if o.Project != nil && *o.Project == project {
+					kept = append(kept, o)
+				}
+			}
+			if len(kept) == 0 && g.Session.Project != project {
+				continue
+			}
+			g.Observations = kept
+		}
+		total += len(g.Observations)
+		groups = append(groups, g)
 	}
 
-	prompts, err := s.RecentPrompts(project, 10)
-	if err != nil {
-		return "", err
+	var b strings.Builder
+	if project != "" {
+		fmt.Fprintf(&b, "# Engram Memory — %s\n\n", project)
+	} else {
+		b.WriteString("# Engram Memory\n\n")
 	}
+	fmt.Fprintf(&b, "_Exported %s · %s · %s_\n", data.ExportedAt, plural(len(groups), "session"), plural(total, "observation"))
 
-	if len(sessions) == 0 && len(observations) == 0 && len(prompts) == 0 {
-		return "", nil
-	}
+	for _, g := range groups {
+		sess := g.Session
+		fmt.Fprintf(&b, "\n## Session %s", sess.ID)
+		if sess.Project != "" {
+			fmt.Fprintf(&b, " — %s", sess.Project)
+		}
+		b.WriteString("\n\n")
+		if sess.StartedAt != "" {
+			ended := "in progress"
+			if sess.EndedAt != nil {
+				ended = *sess.EndedAt
+			}
+			fmt.Fprintf(&b, "_%s → %s_\n\n", sess.StartedAt, ended)
+		}
+		if sess.Summary != nil && *sess.Summary != "" {
+			fmt.Fprintf(&b, "> %s\n\n", strings.ReplaceAll(strings.TrimSpace(*sess.Summary), "\n", "\n> "))
+		}
 
-	var b strings.Builder
-	b.WriteString("## Memory from Previous Sessions\n\n")
-
-	if len(sessions) > 0 {
-		b.WriteString("### Recent Sessions\n")
-		for _, sess := range sessions {
-			summary := ""
-			if sess.Summary != nil {
-				summary = fmt.Sprintf(": %s", truncate(*sess.Summary, 200))
+		if len(g.Observations) == 0 {
+			b.WriteString("_No observations._\n")
+			continue
+		}
+		for _, o := range g.Observations {
+			fmt.Fprintf(&b, "- **%s** `%s` — %s\n", o.Title, o.Type, o.OccurredAt)
+			// Indent content under the bullet so multi-line text stays in it
+			for _, line := range strings.Split(strings.TrimRight(o.Content, "\n"), "\n") {
+				if line == "" {
+					b.WriteString("\n")
+					continue
+				}
+				fmt.Fprintf(&b, "  %s\n", line)
 			}
-			fmt.Fprintf(&b, "- **%s** (%s)%s [%d observations]\n",
-				sess.Project, sess.StartedAt, summary, sess.ObservationCount)
 		}
-		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
+
+// SessionMarkdown renders a session with its prompts and observations as a
+// human-readable markdown document.
+func SessionMarkdown(sess Session, prompts []Prompt, observations []Observation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Session %s\n\n", sess.ID)
+	fmt.Fprintf(&b, "- **Project**: %s\n", sess.Project)
+	if sess.Directory != "" {
+		fmt.Fprintf(&b, "- **Directory**: %s\n", sess.Directory)
+	}
+	fmt.Fprintf(&b, "- **Started**: %s\n", sess.StartedAt)
+	if sess.EndedAt != nil {
+		fmt.Fprintf(&b, "- **Ended**: %s\n", *sess.EndedAt)
+	}
+	b.WriteString("\n")
+
+	if sess.Summary != nil && *sess.Summary != "" {
+		b.WriteString("## Summary\n\n")
+		b.WriteString(*sess.Summary)
+		b.WriteString("\n\n")
 	}
 
 	if len(prompts) > 0 {
-		b.WriteString("### Recent User Prompts\n")
+		b.WriteString("## User Prompts\n\n")
 		for _, p := range prompts {
-			fmt.Fprintf(&b, "- %s: %s\n", p.CreatedAt, truncate(p.Content, 200))
+			fmt.Fprintf(&b, "- %s: %s\n", p.CreatedAt, p.Content)
 		}
 		b.WriteString("\n")
 	}
 
 	if len(observations) > 0 {
-		b.WriteString("### Recent Observations\n")
-		for _, obs := range observations {
-			fmt.Fprintf(&b, "- [%s] **%s**: %s\n",
-				obs.Type, obs.Title, truncate(obs.Content, 300))
+		b.WriteString("## Observations\n\n")
+		for _, o := range observations {
+			fmt.Fprintf(&b, "### #%d [%s] %s\n\n", o.ID, o.Type, o.Title)
+			fmt.Fprintf(&b, "_%s_\n\n", o.OccurredAt)
+			b.WriteString(o.Content)
+			b.WriteString("\n\n")
 		}
-		b.WriteString("\n")
 	}
 
-	return b.String(), nil
+	return b.String()
 }
 
-// ─── Export / Import ─────────────────────────────────────────────────────────
-
-func (s *Store) Export() (*ExportData, error) {
-	data := &ExportData{
-		Version:    "0.1.0",
-		ExportedAt: Now(),
-	}
+// filenameUnsafe matches characters we don't want in exported file names.
+var filenameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
 
-	// Sessions
-	rows, err := s.db.Query(
-		"SELECT id, project, directory, started_at, ended_at, summary FROM sessions ORDER BY started_at",
-	)
-	if err != nil {
-		return nil, fmt.Errorf("export sessions: %w", err)
-	}
-	defer rows.Close()
-	for rows.Next() {
-		var sess Session
-		if err := rows.Scan(&sess.ID, &sess.Project, &sess.Directory, &sess.StartedAt, &sess.EndedAt, &sess.Summary); err != nil {
-			return nil, err
-		}
-		data.Sessions = append(data.Sessions, sess)
+func sessionMarkdownFilename(sess Session) string {
+	date := sess.StartedAt
+	if len(date) >= 10 {
+		date = date[:10]
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	project := filenameUnsafe.ReplaceAllString(sess.Project, "-")
+	if project == "" {
+		project = "unknown"
 	}
+	id := filenameUnsafe.ReplaceAllString(sess.ID, "-")
+	return fmt.Sprintf("%s_%s_%s.md", date, project, id)
+}
 
-	// Observations
-	obsRows, err := s.db.Query(
-		"SELECT id, session_id, type, title, content, tool_name, project, created_at FROM observations ORDER BY id",
-	)
-	if err != nil {
-		return nil, fmt.Errorf("export observations: %w", err)
-	}
-	defer obsRows.Close()
-	for obsRows.Next() {
-		var o Observation
-		if err := obsRows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Content, &o.ToolName, &o.Project, &o.CreatedAt); err != nil {
-			return nil, err
-		}
-		data.Observations = append(data.Observations, o)
-	}
-	if err := obsRows.Err(); err != nil {
-		return nil, err
-	}
+func (s *Store) Import(data *ExportData) (*ImportResult, error) {
+	return s.importData(data, false)
+}
 
-	// Prompts
-	promptRows, err := s.db.Query(
-		"SELECT id, session_id, content, project, created_at FROM user_prompts ORDER BY id",
-	)
+// RestoreFrom resets the database to an export: every session, observation
+// and prompt is deleted (observations with their tags, references, links,
+// embeddings and compressed content), data is imported in their place and
+// the full-text indexes are rebuilt, all in one transaction. Facts, project
+// aliases and sync records are kept. Observations get new IDs, as with
+// Import.
+func (s *Store) RestoreFrom(data *ExportData) (*ImportResult, error) {
+	return s.importData(data, true)
+}
+
+func (s *Store) importData(data *ExportData, replace bool) (*ImportResult, error) {
+	aliases, err := s.ProjectAliases()
 	if err != nil {
-		return nil, fmt.Errorf("export prompts: %w", err)
-	}
-	defer promptRows.Close()
-	for promptRows.Next() {
-		var p Prompt
-		if err := promptRows.Scan(&p.ID, &p.SessionID, &p.Content, &p.Project, &p.CreatedAt); err != nil {
-			return nil, err
-		}
-		data.Prompts = append(data.Prompts, p)
+		return nil, fmt.Errorf("import: %w", err)
 	}
-	if err := promptRows.Err(); err != nil {
-		return nil, err
+	canonical := make(map[string]string, len(aliases))
+	for _, a := range aliases {
+		canonical[a.Alias] = a.Canonical
 	}
 
-	return data, nil
+	var result *ImportResult
+	err = s.withRetry(func() error {
+		var err error
+		result, err = s.importTx(data, canonical, replace)
+		return err
+	})
+	return result, err
 }
 
-func (s *Store) Import(data *ExportData) (*ImportResult, error) {
+// importTx is one attempt at Import, filing rows under their canonical
+// project, or at RestoreFrom with replace. The whole transaction is retried
+// on SQLITE_BUSY, so it must not have side effects outside the tx.
+func (s *Store) importTx(data *ExportData, canonical map[string]string, replace bool) (*ImportResult, error) {
+	project := func(name string) string {
+		if c, ok := canonical[name]; ok {
+			return c
+		}
+		return name
+	}
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return nil, fmt.Errorf("import: begin tx: %w", err)
 	}
 	defer tx.Rollback()
 
+	if replace {
+		// Observations first, with their child rows: they reference
+		// prompts and sessions
+		if _, err := deleteObservationRows(tx, "1 = 1"); err != nil {
+			return nil, fmt.Errorf("restore: clear observations: %w", err)
+		}
+		for _, table := range []string{"user_prompts", "sessions"} {
+			if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+				return nil, fmt.Errorf("restore: clear %s: %w", table, err)
+			}
+		}
+	}
+
 	result := &ImportResult{}
 
 	// Import sessions (skip duplicates)
@@ -928,7 +4668,7 @@ func (s *Store) Import(data *ExportData) (*ImportResult, error) {
 		res, err := tx.Exec(
 			`INSERT OR IGNORE INTO sessions (id, project, directory, started_at, ended_at, summary)
 			 VALUES (?, ?, ?, ?, ?, ?)`,
-			sess.ID, sess.Project, sess.Directory, sess.StartedAt, sess.EndedAt, sess.Summary,
+			sess.ID, project(sess.Project), sess.Directory, sess.StartedAt, sess.EndedAt, sess.Summary,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("import session %s: %w", sess.ID, err)
@@ -937,30 +4677,112 @@ func (s *Store) Import(data *ExportData) (*ImportResult, error) {
 		result.SessionsImported += int(n)
 	}
 
-	// Import observations (use new IDs — AUTOINCREMENT)
+	// Import prompts first so observations can be re-pointed at their new
+	// IDs (AUTOINCREMENT, like observations)
+	promptIDs := make(map[int64]int64, len(data.Prompts))
+	for _, p := range data.Prompts {
+		res, err := tx.Exec(
+			`INSERT INTO user_prompts (session_id, content, project, created_at)
+			 VALUES (?, ?, ?, ?)`,
+			p.SessionID, p.Content, nullableString(project(p.Project)), p.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("import prompt %d: %w", p.ID, err)
+		}
+		if promptIDs[p.ID], err = res.LastInsertId(); err != nil {
+			return nil, fmt.Errorf("import prompt %d: %w", p.ID, err)
+		}
+		result.PromptsImported++
+	}
+
+	// Import observations (use new IDs — AUTOINCREMENT). Observations that
+	// carry a UID we already have are skipped, and so are ones whose content
+	// hash matches an existing row: two machines can hand out the same IDs,
+	// and exports from before UIDs existed have nothing else to match on.
 	for _, obs := range data.Observations {
-		_, err := tx.Exec(
-			`INSERT INTO observations (session_id, type, title, content, tool_name, project, created_at)
-			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
-			obs.SessionID, obs.Type, obs.Title, obs.Content, obs.ToolName, obs.Project, obs.CreatedAt,
+		// Imports aren't truncated, but with CompressContent long content
+		// is split the same way saves split it
+		content, fullContent := obs.Content, ""
+		if s.cfg.CompressContent {
+			content, fullContent = s.fitContent(obs.Content)
+		}
+		hash := contentHash(obs.SessionID, obs.Type, obs.Title, content, obs.CreatedAt)
+		var dup int
+		err := tx.QueryRow("SELECT COUNT(*) FROM observations WHERE content_hash = ?", hash).Scan(&dup)
+		if err != nil {
+			return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
+		}
+		if dup > 0 {
+			result.ObservationsSkipped++
+			continue
+		}
+
+		uid := obs.UID
+		if uid == "" {
+			uid = uuid.NewString()
+		}
+		// A prompt that wasn't part of this export (e.g. an earlier
+		// incremental one) can't be matched, so the link is dropped
+		var promptID *int64
+		if obs.PromptID != nil {
+			if id, ok := promptIDs[*obs.PromptID]; ok {
+				promptID = &id
+			}
+		}
+		obsProject := obs.Project
+		if obsProject != nil {
+			c := project(*obsProject)
+			obsProject = &c
+		}
+		res, err := tx.Exec(
+			`INSERT INTO observations (uid, session_id, type, title, content, tool_name, project, author, status, importance, content_format, prompt_id, archived, pinned, content_hash, created_at, updated_at, occurred_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 ON CONFLICT(uid) DO NOTHING`,
+			uid, obs.SessionID, obs.Type, obs.Title, content, obs.ToolName, obsProject, obs.Author, obs.Status, obs.Importance, obs.Format, promptID, obs.Archived, obs.Pinned, hash, obs.CreatedAt, cmp.Or(obs.UpdatedAt, obs.CreatedAt), cmp.Or(obs.OccurredAt, obs.CreatedAt),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
 		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			result.ObservationsSkipped++
+			continue
+		}
+		if len(obs.Tags) > 0 || len(obs.References) > 0 || fullContent != "" {
+			id, err := res.LastInsertId()
+			if err != nil {
+				return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
+			}
+			if err := insertTags(tx, id, normalizeTags(obs.Tags)); err != nil {
+				return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
+			}
+			if err := insertReferences(tx, id, normalizeReferences(obs.References)); err != nil {
+				return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
+			}
+			if fullContent != "" {
+				if err := writeBlob(tx, id, fullContent); err != nil {
+					return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
+				}
+			}
+		}
 		result.ObservationsImported++
 	}
 
-	// Import prompts
-	for _, p := range data.Prompts {
-		_, err := tx.Exec(
-			`INSERT INTO user_prompts (session_id, content, project, created_at)
-			 VALUES (?, ?, ?, ?)`,
-			p.SessionID, p.Content, p.Project, p.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("import prompt %d: %w", p.ID, err)
+	if replace {
+		for _, stmt := range rebuildFTSStatements {
+			if _, err := tx.Exec(stmt); err != nil {
+				return nil, fmt.Errorf("restore: rebuild index: %w", err)
+			}
 		}
-		result.PromptsImported++
+	}
+
+	operation := AuditImport
+	if replace {
+		operation = AuditRestore
+	}
+	detail := fmt.Sprintf("%d sessions, %d observations (%d skipped), %d prompts",
+		result.SessionsImported, result.ObservationsImported, result.ObservationsSkipped, result.PromptsImported)
+	if err := s.writeAudit(tx, operation, "", detail); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -973,9 +4795,121 @@ func (s *Store) Import(data *ExportData) (*ImportResult, error) {
 type ImportResult struct {
 	SessionsImported     int `json:"sessions_imported"`
 	ObservationsImported int `json:"observations_imported"`
+	ObservationsSkipped  int `json:"observations_skipped"` // already present (same UID or content hash)
 	PromptsImported      int `json:"prompts_imported"`
 }
 
+// ─── Fork ────────────────────────────────────────────────────────────────────
+
+// ForkParams selects which observations Fork copies.
+type ForkParams struct {
+	From  string // source project
+	To    string // destination project
+	Query string // optional FTS query; empty copies every observation
+	// KeepImportance carries importance over; otherwise copies start at 0
+	// so the new project doesn't inherit global-insight status.
+	KeepImportance bool
+}
+
+// Fork seeds project To with copies of From's observations (optionally only
+// those matching Query). Copies are new rows with the project rewritten,
+// attached to a "fork-<to>" session, and keep their original content and
+// timestamps. Everything happens in one transaction. Returns the number of
+// observations copied.
+func (s *Store) Fork(p ForkParams) (int, error) {
+	if p.From == "" || p.To == "" {
+		return 0, fmt.Errorf("fork: from and to projects are required")
+	}
+	p.From, p.To = s.canonicalProject(p.From), s.canonicalProject(p.To)
+	if p.From == p.To {
+		return 0, fmt.Errorf("fork: from and to must differ")
+	}
+
+	query := "SELECT " + observationColumns + " FROM observations o WHERE o.project = ? ORDER BY o.id"
+	args := []any{p.From}
+	if strings.TrimSpace(p.Query) != "" {
+		query = `
+			SELECT ` + observationColumns + `
+			FROM observations_fts fts
+			JOIN observations o ON o.id = fts.rowid
+			WHERE observations_fts MATCH ? AND o.project = ?
+			ORDER BY o.id`
+		ftsQuery, _ := s.sanitizeFTS(p.Query)
+		args = []any{ftsQuery, p.From}
+	}
+	source, err := s.queryObservations(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("fork: select: %w", err)
+	}
+	if len(source) == 0 {
+		return 0, nil
+	}
+
+	sessionID := "fork-" + p.To
+	var ids []int64
+	err = s.withRetry(func() error {
+		ids = ids[:0]
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("fork: begin tx: %w", err)
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO sessions (id, project, directory) VALUES (?, ?, '')`,
+			sessionID, p.To,
+		); err != nil {
+			return fmt.Errorf("fork: create session: %w", err)
+		}
+
+		for _, o := range source {
+			importance := 0
+			if p.KeepImportance {
+				importance = o.Importance
+			}
+			res, err := tx.Exec(
+				`INSERT INTO observations (uid, session_id, type, title, content, tool_name, project, author, status, importance, content_format, archived, content_hash, created_at, updated_at, occurred_at)
+				 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				uuid.NewString(), sessionID, o.Type, o.Title, o.Content, o.ToolName, p.To, o.Author, o.Status, importance, o.Format, o.Archived,
+				contentHash(sessionID, o.Type, o.Title, o.Content, o.CreatedAt), o.CreatedAt, o.UpdatedAt, o.OccurredAt,
+			)
+			if err != nil {
+				return fmt.Errorf("fork observation #%d: %w", o.ID, err)
+			}
+			id, _ := res.LastInsertId()
+			if _, err := tx.Exec(
+				"INSERT INTO observation_tags (observation_id, tag) SELECT ?, tag FROM observation_tags WHERE observation_id = ?",
+				id, o.ID,
+			); err != nil {
+				return fmt.Errorf("fork observation #%d tags: %w", o.ID, err)
+			}
+			if _, err := tx.Exec(
+				"INSERT INTO observation_references (observation_id, ref) SELECT ?, ref FROM observation_references WHERE observation_id = ? ORDER BY rowid",
+				id, o.ID,
+			); err != nil {
+				return fmt.Errorf("fork observation #%d references: %w", o.ID, err)
+			}
+			if _, err := tx.Exec(
+				"INSERT INTO observation_blobs (observation_id, content, size) SELECT ?, content, size FROM observation_blobs WHERE observation_id = ?",
+				id, o.ID,
+			); err != nil {
+				return fmt.Errorf("fork observation #%d content: %w", o.ID, err)
+			}
+			ids = append(ids, id)
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		s.emitObservation(EventObservationCreated, id)
+	}
+	return len(ids), nil
+}
+
 // ─── Sync Chunk Tracking ─────────────────────────────────────────────────────
 
 // GetSyncedChunks returns a set of chunk IDs that have been imported/exported.
@@ -999,7 +4933,7 @@ func (s *Store) GetSyncedChunks() (map[string]bool, error) {
 
 // RecordSyncedChunk marks a chunk as imported/exported so it won't be processed again.
 func (s *Store) RecordSyncedChunk(chunkID string) error {
-	_, err := s.db.Exec(
+	_, err := s.exec(
 		"INSERT OR IGNORE INTO sync_chunks (chunk_id) VALUES (?)",
 		chunkID,
 	)
@@ -1008,6 +4942,21 @@ func (s *Store) RecordSyncedChunk(chunkID string) error {
 
 // ─── Helpers ─────────────────────────────────────────────────────────────────
 
+// observationColumns is the canonical SELECT list for an Observation. Queries
+// must alias the observations table as "o" and scan with scanFields().
+const observationColumns = `o.id, o.uid, o.session_id, o.type, o.title, o.content, o.tool_name, o.project, o.author,
+	o.status, o.importance, o.content_format, o.prompt_id, o.archived, o.pinned, o.created_at, o.updated_at,
+	COALESCE(o.occurred_at, o.created_at), o.access_count, o.last_accessed_at`
+
+// scanFields returns scan destinations matching observationColumns.
+func (o *Observation) scanFields() []any {
+	return []any{
+		&o.ID, &o.UID, &o.SessionID, &o.Type, &o.Title, &o.Content, &o.ToolName, &o.Project, &o.Author,
+		&o.Status, &o.Importance, &o.Format, &o.PromptID, &o.Archived, &o.Pinned, &o.CreatedAt, &o.UpdatedAt,
+		&o.OccurredAt, &o.AccessCount, &o.LastAccessedAt,
+	}
+}
+
 func (s *Store) queryObservations(query string, args ...any) ([]Observation, error) {
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
@@ -1018,7 +4967,7 @@ func (s *Store) queryObservations(query string, args ...any) ([]Observation, err
 	var results []Observation
 	for rows.Next() {
 		var o Observation
-		if err := rows.Scan(&o.ID, &o.SessionID, &o.Type, &o.Title, &o.Content, &o.ToolName, &o.Project, &o.CreatedAt); err != nil {
+		if err := rows.Scan(o.scanFields()...); err != nil {
 			return nil, err
 		}
 		results = append(results, o)
@@ -1026,6 +4975,34 @@ func (s *Store) queryObservations(query string, args ...any) ([]Observation, err
 	return results, rows.Err()
 }
 
+func toTimelineEntries(observations []Observation) []TimelineEntry {
+	var entries []TimelineEntry
+	for _, o := range observations {
+		entries = append(entries, TimelineEntry{Observation: o})
+	}
+	return entries
+}
+
+// contentHash identifies an observation by what it says rather than its ID:
+// hex SHA-256 over session, type, title, content and creation time. Fields
+// are NUL-separated so shifting text between them changes the hash.
+func contentHash(sessionID, typ, title, content, createdAt string) string {
+	h := sha256.New()
+	for _, f := range []string{sessionID, typ, title, content, createdAt} {
+		h.Write([]byte(f))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// nullableID maps the zero ID to NULL.
+func nullableID(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
 func nullableString(s string) *string {
 	if s == "" {
 		return nil
@@ -1033,6 +5010,13 @@ func nullableString(s string) *string {
 	return &s
 }
 
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
@@ -1054,35 +5038,246 @@ func stripPrivateTags(s string) string {
 	return result
 }
 
-// sanitizeFTS wraps each word in quotes so FTS5 doesn't choke on special chars.
+// ErrSecretDetected is returned by SaveObservation in strict redaction mode.
+var ErrSecretDetected = errors.New("secret detected")
+
+// secretPatterns match common credential shapes. Each match is replaced by
+// its replacement template, so key names survive and only values are hidden.
+var secretPatterns = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	// PEM private key blocks
+	{regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`), "[REDACTED]"},
+	// AWS access key IDs
+	{regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), "[REDACTED]"},
+	// GitHub tokens
+	{regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`), "[REDACTED]"},
+	// Slack tokens
+	{regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`), "[REDACTED]"},
+	// OpenAI / Anthropic / Stripe style secret keys
+	{regexp.MustCompile(`\b(?:sk|rk)-[A-Za-z0-9_-]{20,}`), "[REDACTED]"},
+	// key = value assignments for password-ish names
+	{regexp.MustCompile(`(?i)\b((?:password|passwd|pwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token|client[_-]?secret)["']?\s*[:=]\s*)["']?[^\s"',;]{6,}["']?`), "${1}[REDACTED]"},
+}
+
+// redactSecrets replaces anything that looks like a credential with
+// [REDACTED] and returns how many replacements were made.
+func redactSecrets(s string) (string, int) {
+	count := 0
+	for _, p := range secretPatterns {
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			count++
+			return p.re.ReplaceAllString(match, p.replacement)
+		})
+	}
+	return s, count
+}
+
+// redact is redactSecrets plus Config.RedactionPatterns, whose matches are
+// replaced whole.
+func (s *Store) redact(text string) (string, int) {
+	text, count := redactSecrets(text)
+	for _, re := range s.redactions {
+		text = re.ReplaceAllStringFunc(text, func(string) string {
+			count++
+			return "[REDACTED]"
+		})
+	}
+	return text, count
+}
+
+// sanitizeFTS turns a user query into a safe FTS5 expression. Every token is
+// wrapped in quotes so FTS5 doesn't choke on special chars:
 // "fix auth bug" → `"fix" "auth" "bug"`
-func sanitizeFTS(query string) string {
-	words := strings.Fields(query)
-	for i, w := range words {
-		// Strip existing quotes to avoid double-quoting
-		w = strings.Trim(w, `"`)
-		words[i] = `"` + w + `"`
+//
+// Two bits of syntax survive: a double-quoted span is kept as one phrase
+// (`"connection refused"` matches those words in order), and a trailing `*`
+// makes a prefix query (`auth*` → `"auth"*`, matching "authentication").
+//
+// Stopwords and words shorter than Config.MinTermLength are dropped unless the
+// user quoted them or asked for a prefix. If that leaves nothing, every token
+// is kept.
+//
+// With Config.FTSPrefix set, plain words become prefix queries too. The bool
+// reports whether any token was a prefix query.
+func (s *Store) sanitizeFTS(query string) (string, bool) {
+	var all, kept []string
+	prefix := false
+	for _, t := range tokenizeFTS(query) {
+		phrase := `"` + t.text + `"`
+		if t.prefix || (!t.quoted && len(s.cfg.FTSPrefix) > 0) {
+			phrase += "*"
+		}
+		all = append(all, phrase)
+		if t.quoted || t.prefix || (utf8.RuneCountInString(t.text) >= s.cfg.MinTermLength && !s.stopwords[strings.ToLower(t.text)]) {
+			kept = append(kept, phrase)
+		}
+	}
+	if len(kept) == 0 {
+		kept = all
+	}
+	for _, p := range kept {
+		prefix = prefix || strings.HasSuffix(p, "*")
+	}
+	return strings.Join(kept, " "), prefix
+}
+
+// ftsToken is one word or quoted phrase of a search query.
+type ftsToken struct {
+	text   string
+	quoted bool // came from a "double-quoted span"
+	prefix bool // ended in *
+}
+
+// tokenizeFTS splits a query into words and double-quoted phrases. A quote
+// also ends the word before it, an unterminated quote runs to the end of the
+// query, and trailing stars on a word or closing quote mark a prefix. Stars
+// anywhere else are dropped.
+func tokenizeFTS(query string) []ftsToken {
+	var tokens []ftsToken
+	add := func(text string, quoted, prefix bool) {
+		text = strings.TrimSpace(strings.ReplaceAll(text, "*", ""))
+		if quoted {
+			text = strings.Join(strings.Fields(text), " ")
+		}
+		if text != "" {
+			tokens = append(tokens, ftsToken{text: text, quoted: quoted, prefix: prefix})
+		}
 	}
-	return strings.Join(words, " ")
+
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == '"':
+			end := strings.IndexByte(query[i+1:], '"')
+			if end < 0 {
+				add(query[i+1:], true, false)
+				return tokens
+			}
+			text := query[i+1 : i+1+end]
+			i += end + 2
+			stars := len(query[i:]) - len(strings.TrimLeft(query[i:], "*"))
+			i += stars
+			add(text, true, stars > 0)
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		default:
+			end := strings.IndexAny(query[i:], " \t\n\r\"")
+			if end < 0 {
+				end = len(query) - i
+			}
+			word := query[i : i+end]
+			i += end
+			add(word, false, strings.HasSuffix(word, "*"))
+		}
+	}
+	return tokens
+}
+
+// ftsPrefixOption renders Config.FTSPrefix as an FTS5 table option, e.g.
+// ", prefix='2 3'". Lengths outside 1-999 are ignored.
+func ftsPrefixOption(lengths []int) string {
+	var parts []string
+	for _, n := range lengths {
+		if n >= 1 && n <= 999 {
+			parts = append(parts, strconv.Itoa(n))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return ", prefix='" + strings.Join(parts, " ") + "'"
+}
+
+// excludeFTS builds the right-hand side of an FTS5 NOT from exclusion terms.
+// Each term becomes a quoted phrase and they're OR-ed together:
+// ["test", "mock data"] → `"test" OR "mock data"`. Empty terms are skipped.
+func excludeFTS(terms []string) string {
+	var phrases []string
+	for _, t := range terms {
+		t = strings.TrimSpace(strings.ReplaceAll(t, `"`, ""))
+		if t == "" {
+			continue
+		}
+		phrases = append(phrases, `"`+t+`"`)
+	}
+	return strings.Join(phrases, " OR ")
+}
+
+// ─── Observation Types ───────────────────────────────────────────────────────
+
+// toolTypes maps tool names to the observation type ClassifyTool gives them.
+// Its values, plus "tool_use" for everything else, are the tool types in
+// KnownTypes.
+var toolTypes = map[string]string{
+	"write": "file_change",
+	"edit":  "file_change",
+	"patch": "file_change",
+	"bash":  "command",
+	"read":  "file_read",
+	"view":  "file_read",
+	"grep":  "search",
+	"glob":  "search",
+	"ls":    "search",
+}
+
+// memoryTypes are the types for deliberately saved memories, as suggested
+// by mem_save and the docs.
+var memoryTypes = []string{
+	"manual", "decision", "architecture", "bugfix", "pattern", "convention",
+	"config", "discovery", "learning", "task", "todo", "session_summary",
 }
 
 // ClassifyTool returns the observation type for a given tool name.
 func ClassifyTool(toolName string) string {
-	switch toolName {
-	case "write", "edit", "patch":
-		return "file_change"
-	case "bash":
-		return "command"
-	case "read", "view":
-		return "file_read"
-	case "grep", "glob", "ls":
-		return "search"
-	default:
-		return "tool_use"
+	if typ, ok := toolTypes[toolName]; ok {
+		return typ
+	}
+	return "tool_use"
+}
+
+// KnownTypes returns the canonical observation types, sorted: what
+// ClassifyTool assigns plus the memory types. Config.StrictTypes only
+// accepts these.
+func KnownTypes() []string {
+	types := append([]string{"tool_use"}, memoryTypes...)
+	for _, typ := range toolTypes {
+		if !slices.Contains(types, typ) {
+			types = append(types, typ)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ErrUnknownType is returned by SaveObservation when Config.StrictTypes is
+// on and the type isn't one of KnownTypes.
+var ErrUnknownType = errors.New("unknown observation type")
+
+// checkType validates typ against KnownTypes, suggesting the known type it
+// most likely meant ("file-change", "FileChange" → "file_change").
+func checkType(typ string) error {
+	known := KnownTypes()
+	if slices.Contains(known, typ) {
+		return nil
+	}
+	fold := func(t string) string {
+		return strings.Map(func(r rune) rune {
+			if r == '_' || r == '-' || r == ' ' {
+				return -1
+			}
+			return unicode.ToLower(r)
+		}, t)
+	}
+	for _, k := range known {
+		if fold(k) == fold(typ) {
+			return fmt.Errorf("%w %q (did you mean %q?)", ErrUnknownType, typ, k)
+		}
 	}
+	return fmt.Errorf("%w %q (expected one of: %s)", ErrUnknownType, typ, strings.Join(known, ", "))
 }
 
 // Now returns the current time formatted for SQLite.
 func Now() string {
-	return time.Now().UTC().Format("2006-01-02 15:04:05")
+	return time.Now().UTC().Format(sqliteTimeLayout)
 }
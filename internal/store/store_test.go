@@ -0,0 +1,953 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testConfig is DefaultConfig with the database in a temp dir.
+func testConfig(tb testing.TB) Config {
+	tb.Helper()
+	cfg := DefaultConfig()
+	cfg.DataDir = tb.TempDir()
+	return cfg
+}
+
+func newTestStore(tb testing.TB, cfg Config) *Store {
+	tb.Helper()
+	s, err := New(cfg)
+	if err != nil {
+		tb.Fatalf("New: %v", err)
+	}
+	tb.Cleanup(func() { s.Close() })
+	return s
+}
+
+func mustAdd(tb testing.TB, s *Store, p AddObservationParams) int64 {
+	tb.Helper()
+	if p.SessionID == "" {
+		p.SessionID = "test"
+	}
+	if err := s.CreateSession(p.SessionID, p.Project, ""); err != nil {
+		tb.Fatalf("CreateSession: %v", err)
+	}
+	id, err := s.AddObservation(p)
+	if err != nil {
+		tb.Fatalf("AddObservation: %v", err)
+	}
+	return id
+}
+
+var benchWords = strings.Fields(`auth token session cache index query migration
+	schema deploy rollback timeout retry socket handler router middleware
+	config secret parser lexer render template worker queue lock mutex`)
+
+// seedObservations adds n observations of random benchWords in batches.
+func seedObservations(tb testing.TB, s *Store, n int) {
+	tb.Helper()
+	if err := s.CreateSession("bench", "bench", ""); err != nil {
+		tb.Fatalf("CreateSession: %v", err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	words := func(k int) string {
+		w := make([]string, k)
+		for i := range w {
+			w[i] = benchWords[rng.Intn(len(benchWords))]
+		}
+		return strings.Join(w, " ")
+	}
+	for added := 0; added < n; {
+		batch := make([]AddObservationParams, min(500, n-added))
+		for i := range batch {
+			batch[i] = AddObservationParams{
+				SessionID: "bench",
+				Type:      "learning",
+				Title:     words(4),
+				Content:   words(60),
+				Project:   "bench",
+			}
+		}
+		if _, err := s.AddObservations(batch); err != nil {
+			tb.Fatalf("AddObservations: %v", err)
+		}
+		added += len(batch)
+	}
+}
+
+// ─── Pragmas ─────────────────────────────────────────────────────────────────
+
+func TestPragmasApplyToEveryConnection(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.CacheSizeKB = 4096
+	s := newTestStore(t, cfg)
+
+	ctx := context.Background()
+	var conns []interface{ Close() error }
+	defer func() {
+		for _, c := range conns {
+			c.Close()
+		}
+	}()
+	// Hold several connections open at once so the pool can't hand back
+	// the same one
+	for i := range 3 {
+		c, err := s.db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("conn %d: %v", i, err)
+		}
+		conns = append(conns, c)
+
+		var fk, cache int
+		if err := c.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&fk); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.QueryRowContext(ctx, "PRAGMA cache_size").Scan(&cache); err != nil {
+			t.Fatal(err)
+		}
+		if fk != 1 || cache != -4096 {
+			t.Errorf("conn %d: foreign_keys = %d, cache_size = %d; want 1, -4096", i, fk, cache)
+		}
+	}
+}
+
+func TestDataDirWithURISyntax(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.DataDir = filepath.Join(cfg.DataDir, "a#b?c%d")
+	s := newTestStore(t, cfg)
+
+	if _, err := os.Stat(cfg.DBPath()); err != nil {
+		t.Fatalf("database not at %s: %v", cfg.DBPath(), err)
+	}
+	var fk int
+	if err := s.db.QueryRow("PRAGMA foreign_keys").Scan(&fk); err != nil {
+		t.Fatal(err)
+	}
+	if fk != 1 {
+		t.Errorf("foreign_keys = %d, want 1", fk)
+	}
+}
+
+// BenchmarkSearchCacheSettings compares SQLite's built-in cache and mmap
+// settings with engram's defaults on a database of 20,000 observations.
+func BenchmarkSearchCacheSettings(b *testing.B) {
+	for _, bc := range []struct {
+		name          string
+		cacheKB, mmap int
+	}{
+		{"sqlite-defaults", 0, 0},
+		{"engram-defaults", DefaultConfig().CacheSizeKB, DefaultConfig().MmapSizeMB},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			cfg := testConfig(b)
+			cfg.CacheSizeKB, cfg.MmapSizeMB = bc.cacheKB, bc.mmap
+			s := newTestStore(b, cfg)
+			seedObservations(b, s, 20000)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				q := benchWords[i%len(benchWords)] + " " + benchWords[(i*7)%len(benchWords)]
+				if _, err := s.Search(q, SearchOptions{Limit: 20}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkScanCacheSettings is BenchmarkSearchCacheSettings for a full
+// table scan, where the page cache matters most.
+func BenchmarkScanCacheSettings(b *testing.B) {
+	for _, bc := range []struct {
+		name          string
+		cacheKB, mmap int
+	}{
+		{"sqlite-defaults", 0, 0},
+		{"engram-defaults", DefaultConfig().CacheSizeKB, DefaultConfig().MmapSizeMB},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			cfg := testConfig(b)
+			cfg.CacheSizeKB, cfg.MmapSizeMB = bc.cacheKB, bc.mmap
+			s := newTestStore(b, cfg)
+			seedObservations(b, s, 20000)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var n int
+				err := s.db.QueryRow("SELECT COUNT(*) FROM observations WHERE content LIKE ?", fmt.Sprintf("%%%s%%", benchWords[i%len(benchWords)])).Scan(&n)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// ─── Importance ──────────────────────────────────────────────────────────────
+
+func TestSaveRejectsImportanceOutOfRange(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	s.CreateSession("test", "", "")
+	for _, importance := range []int{-1, 6} {
+		_, err := s.SaveObservation(AddObservationParams{SessionID: "test", Title: "t", Content: "c", Importance: &importance})
+		if !errors.Is(err, ErrInvalidObservation) {
+			t.Errorf("importance %d: err = %v, want ErrInvalidObservation", importance, err)
+		}
+	}
+}
+
+func TestEndSessionKeepsSessionOpenWhenSummaryFails(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	mustAdd(t, s, AddObservationParams{Type: "decision", Title: "t", Content: "c"})
+	// Summarizing reads observations; without the table it fails
+	if _, err := s.db.Exec("ALTER TABLE observations RENAME TO observations_gone"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.EndSession("test", ""); err == nil {
+		t.Fatal("EndSession succeeded without a summary")
+	}
+	sess, err := s.GetSession("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.EndedAt != nil {
+		t.Errorf("session ended at %s after its summary failed", *sess.EndedAt)
+	}
+}
+
+func TestSessionTimelineNotFound(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	if _, err := s.SessionTimeline("missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("err = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestStatsReportsDatabaseErrors(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	mustAdd(t, s, AddObservationParams{Type: "decision", Title: "t", Content: "c", Project: "engram"})
+	stats, err := s.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalObservations != 1 || !slices.Equal(stats.Projects, []string{"engram"}) {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	s.db.Close()
+	if stats, err := s.Stats(); err == nil {
+		t.Errorf("Stats on a closed database = %+v, want an error", stats)
+	}
+}
+
+func TestProjectNotFound(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	mustAdd(t, s, AddObservationParams{Type: "decision", Title: "t", Content: "c", Project: "engram"})
+
+	if _, err := s.ProjectStats("missing"); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("ProjectStats err = %v, want ErrProjectNotFound", err)
+	}
+	if _, err := s.ProjectSummary("missing"); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("ProjectSummary err = %v, want ErrProjectNotFound", err)
+	}
+	if _, err := s.ProjectStats("engram"); err != nil {
+		t.Errorf("ProjectStats(engram): %v", err)
+	}
+}
+
+func TestValidateObservationWritesNothing(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	bad := 9
+	err := s.ValidateObservation(AddObservationParams{SessionID: "new", Title: "t", Content: "c", Importance: &bad})
+	if !errors.Is(err, ErrInvalidObservation) {
+		t.Fatalf("err = %v, want ErrInvalidObservation", err)
+	}
+	if err := s.ValidateObservation(AddObservationParams{SessionID: "new", Title: "t", Content: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := s.db.QueryRow("SELECT (SELECT COUNT(*) FROM sessions) + (SELECT COUNT(*) FROM observations)").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("validation wrote %d rows", n)
+	}
+}
+
+func TestSaveKeepsExplicitZeroImportance(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	zero := 0
+	explicit := mustAdd(t, s, AddObservationParams{Type: "decision", Title: "t", Content: "explicit", Importance: &zero})
+	defaulted := mustAdd(t, s, AddObservationParams{Type: "decision", Title: "t", Content: "defaulted"})
+
+	for id, want := range map[int64]int{explicit: 0, defaulted: DefaultTypeImportance["decision"]} {
+		o, err := s.GetObservation(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if o.Importance != want {
+			t.Errorf("#%d importance = %d, want %d", id, o.Importance, want)
+		}
+	}
+}
+
+// ─── Search Cache ────────────────────────────────────────────────────────────
+
+func TestSearchCacheOffWithTrackAccess(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.SearchCache = 100
+	cfg.TrackAccess = true
+	s := newTestStore(t, cfg)
+	if s.SearchCacheStats() != nil {
+		t.Fatal("search cache enabled alongside TrackAccess")
+	}
+}
+
+func TestSearchCacheInvalidatedByWrites(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.SearchCache = 100
+	s := newTestStore(t, cfg)
+	mustAdd(t, s, AddObservationParams{Title: "deploy notes", Content: "rollback plan"})
+
+	search := func() int {
+		resp, err := s.SearchPage("rollback", SearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(resp.Results)
+	}
+	search()
+	if n := search(); n != 1 || s.SearchCacheStats().Hits != 1 {
+		t.Fatalf("results = %d, hits = %d; want 1, 1", n, s.SearchCacheStats().Hits)
+	}
+	mustAdd(t, s, AddObservationParams{Title: "second", Content: "another rollback"})
+	if n := search(); n != 2 {
+		t.Fatalf("results after write = %d, want 2", n)
+	}
+}
+
+// BenchmarkSearchCache repeats one query against 5,000 observations with the
+// cache off and on.
+func BenchmarkSearchCache(b *testing.B) {
+	for _, size := range []int{0, 500} {
+		b.Run(fmt.Sprintf("cache=%d", size), func(b *testing.B) {
+			cfg := testConfig(b)
+			cfg.SearchCache = size
+			s := newTestStore(b, cfg)
+			seedObservations(b, s, 5000)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.SearchPage("auth token", SearchOptions{Limit: 20}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestObservationsForPromptNotFound(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	if _, err := s.ObservationsForPrompt(42); !errors.Is(err, ErrPromptNotFound) {
+		t.Fatalf("err = %v, want ErrPromptNotFound", err)
+	}
+}
+
+// ─── Export ──────────────────────────────────────────────────────────────────
+
+func TestExportMatchesStreamedExport(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	check := func() {
+		t.Helper()
+		data, err := s.Export()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := s.ExportTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		var streamed ExportData
+		if err := json.Unmarshal(buf.Bytes(), &streamed); err != nil {
+			t.Fatal(err)
+		}
+		streamed.ExportedAt = data.ExportedAt
+		if !reflect.DeepEqual(data, &streamed) {
+			t.Errorf("Export and ExportTo differ:\n%+v\n%+v", data, &streamed)
+		}
+	}
+
+	// An empty export still has empty arrays, not null
+	check()
+	mustAdd(t, s, AddObservationParams{Title: "tagged", Content: "with #tags", Tags: []string{"one", "two"}})
+	mustAdd(t, s, AddObservationParams{SessionID: "other", Title: "second", Content: "plain"})
+	if _, err := s.AddPrompt(AddPromptParams{SessionID: "other", Content: "a prompt"}); err != nil {
+		t.Fatal(err)
+	}
+	check()
+}
+
+func TestRestoreLeavesNoOrphanedRows(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.CompressContent = true
+	cfg.MaxObservationLength = 20
+	s := newTestStore(t, cfg)
+	mustAdd(t, s, AddObservationParams{Title: "kept", Content: "in the backup"})
+	backup, err := s.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := mustAdd(t, s, AddObservationParams{Title: "later", Content: strings.Repeat("compressed ", 10), Tags: []string{"gone"}})
+	b := mustAdd(t, s, AddObservationParams{Title: "linked", Content: "also later"})
+	if err := s.LinkObservations(a, b, "relates_to"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RestoreFrom(backup); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []string{"observation_tags", "observation_blobs"} {
+		var orphans int
+		err := s.db.QueryRow(
+			"SELECT COUNT(*) FROM " + table + " WHERE observation_id NOT IN (SELECT id FROM observations)",
+		).Scan(&orphans)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if orphans != 0 {
+			t.Errorf("%s: %d rows left for observations the restore removed", table, orphans)
+		}
+	}
+	var links int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM observation_links").Scan(&links); err != nil {
+		t.Fatal(err)
+	}
+	if links != 0 {
+		t.Errorf("%d links left after restoring a backup without any", links)
+	}
+}
+
+func TestExportNDJSONMatchesExport(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	mustAdd(t, s, AddObservationParams{Title: "tagged", Content: "with #tags", Tags: []string{"one"}})
+	if _, err := s.AddPrompt(AddPromptParams{SessionID: "test", Content: "a prompt"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := s.ExportNDJSONTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	streamed := &ExportData{Version: data.Version, ExportedAt: data.ExportedAt, Sessions: []Session{}, Observations: []Observation{}, Prompts: []Prompt{}}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var line ExportLine
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case line.Session != nil:
+			streamed.Sessions = append(streamed.Sessions, *line.Session)
+		case line.Observation != nil:
+			streamed.Observations = append(streamed.Observations, *line.Observation)
+		case line.Prompt != nil:
+			streamed.Prompts = append(streamed.Prompts, *line.Prompt)
+		}
+	}
+	if !reflect.DeepEqual(data, streamed) {
+		t.Errorf("Export and ExportNDJSONTo differ:\n%+v\n%+v", data, streamed)
+	}
+}
+
+func TestMarkdownExportsShowWhenItHappened(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	id := mustAdd(t, s, AddObservationParams{Type: "decision", Title: "back-dated", Content: "c", OccurredAt: "2024-03-01 09:30:00"})
+	o, err := s.GetObservation(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md, err := s.ExportMarkdown("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := s.GetSession("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, out := range map[string]string{
+		"ExportMarkdown":  md,
+		"SessionMarkdown": SessionMarkdown(*sess, nil, []Observation{*o}),
+	} {
+		if !strings.Contains(out, "2024-03-01 09:30:00") {
+			t.Errorf("%s doesn't show when it happened, 2024-03-01 09:30:00:\n%s", name, out)
+		}
+	}
+}
+
+// ─── FTS Query Sanitization ──────────────────────────────────────────────────
+
+func TestSanitizeFTS(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	for _, tc := range []struct {
+		query, want string
+		prefix      bool
+	}{
+		{`error*`, `"error"*`, true},
+		{`"null pointer"`, `"null pointer"`, false},
+		{`"null pointer"*`, `"null pointer"*`, true},
+		{`"null pointer" error* in the handler`, `"null pointer" "error"* "handler"`, true},
+		{`fix auth bug`, `"fix" "auth" "bug"`, false},
+		// Operators are quoted like any word ("or" is also a stopword)
+		{`auth* OR NEAR(x) col:term`, `"auth"* "NEAR(x)" "col:term"`, true},
+		{`"unterminated phrase`, `"unterminated phrase"`, false},
+		{`err*or **`, `"error"`, false},
+		{`the`, `"the"`, false},
+	} {
+		got, prefix := s.sanitizeFTS(tc.query)
+		if got != tc.want || prefix != tc.prefix {
+			t.Errorf("sanitizeFTS(%q) = %q, %v; want %q, %v", tc.query, got, prefix, tc.want, tc.prefix)
+		}
+	}
+}
+
+func TestSearchPrefixAndPhrase(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	plural := mustAdd(t, s, AddObservationParams{Title: "Flaky build", Content: "errors in the parser"})
+	inOrder := mustAdd(t, s, AddObservationParams{Title: "Crash", Content: "null pointer dereference in handler"})
+	reversed := mustAdd(t, s, AddObservationParams{Title: "Other crash", Content: "pointer was null in handler"})
+
+	for _, tc := range []struct {
+		query string
+		raw   bool
+		want  []int64
+	}{
+		{`error*`, false, []int64{plural}},
+		{`"null pointer"`, false, []int64{inOrder}},
+		{`null pointer`, false, []int64{inOrder, reversed}},
+		{`"null pointer" handler*`, false, []int64{inOrder}},
+		{`pointer NOT dereference`, true, []int64{reversed}},
+	} {
+		results, err := s.Search(tc.query, SearchOptions{Raw: tc.raw})
+		if err != nil {
+			t.Errorf("Search(%q): %v", tc.query, err)
+			continue
+		}
+		var got []int64
+		for _, r := range results {
+			got = append(got, r.ID)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("Search(%q, raw=%v) = %v, want %v", tc.query, tc.raw, got, tc.want)
+		}
+	}
+}
+
+// ─── Recency Blending ────────────────────────────────────────────────────────
+
+func TestBlendRecency(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) string { return now.Add(-ago).Format(sqliteTimeLayout) }
+	results := func() []SearchResult {
+		return []SearchResult{
+			// Best FTS match, a year old
+			{Observation: Observation{ID: 1, OccurredAt: at(365 * 24 * time.Hour)}, Rank: -10},
+			// Weaker match from yesterday
+			{Observation: Observation{ID: 2, OccurredAt: at(24 * time.Hour)}, Rank: -6},
+		}
+	}
+	for _, tc := range []struct {
+		weight float64
+		first  int64
+	}{
+		{0, 1},
+		{0.2, 1},
+		{0.8, 2},
+		{5, 2}, // counts as 1
+	} {
+		r := results()
+		blendRecency(r, tc.weight, now)
+		if r[0].ID != tc.first {
+			t.Errorf("weight %v: first = #%d, want #%d", tc.weight, r[0].ID, tc.first)
+		}
+	}
+}
+
+func TestSearchRecencyWeight(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	old := mustAdd(t, s, AddObservationParams{
+		Title: "Deploy rollback", Content: "deploy rollback steps for the deploy",
+		OccurredAt: time.Now().AddDate(-1, 0, 0).UTC().Format(time.RFC3339),
+	})
+	recent := mustAdd(t, s, AddObservationParams{
+		Title: "Weekly notes", Content: "a deploy went out, among plenty of other unrelated things worth writing down",
+	})
+
+	first := func(weight float64) int64 {
+		t.Helper()
+		resp, err := s.SearchPage("deploy", SearchOptions{RecencyWeight: weight})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Results) != 2 {
+			t.Fatalf("weight %v: %d results, want 2", weight, len(resp.Results))
+		}
+		return resp.Results[0].ID
+	}
+	if got := first(0); got != old {
+		t.Errorf("without recency: first = #%d, want the stronger old match #%d", got, old)
+	}
+	if got := first(0.9); got != recent {
+		t.Errorf("with recency 0.9: first = #%d, want the recent match #%d", got, recent)
+	}
+}
+
+// ─── Deduplication ───────────────────────────────────────────────────────────
+
+func TestDedupComparesFullCompressedContent(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.DedupWindow = time.Hour
+	cfg.CompressContent = true
+	cfg.MaxObservationLength = 20
+	s := newTestStore(t, cfg)
+	s.CreateSession("test", "", "")
+
+	prefix := strings.Repeat("shared preview ", 3)
+	save := func(content string) *SaveResult {
+		t.Helper()
+		res, err := s.SaveObservation(AddObservationParams{SessionID: "test", Title: "long", Content: content})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	first := save(prefix + "first ending")
+	if res := save(prefix + "second ending"); res.Duplicate {
+		t.Errorf("different content sharing a truncated preview counted as a duplicate of #%d", first.ID)
+	}
+	if res := save(prefix + "first ending"); !res.Duplicate || res.ID != first.ID {
+		t.Errorf("identical content: duplicate = %v, id = %d; want true, %d", res.Duplicate, res.ID, first.ID)
+	}
+}
+
+func TestTagsAndReferencesFromCompressedTail(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.CompressContent = true
+	cfg.MaxObservationLength = 40
+	cfg.ExtractHashtags = true
+	cfg.ExtractReferences = true
+	s := newTestStore(t, cfg)
+
+	content := strings.Repeat("preview ", 10) + "#tail see https://example.com/tail"
+	o, err := s.GetObservation(mustAdd(t, s, AddObservationParams{Type: "decision", Title: "long", Content: content}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(o.Tags, "tail") || !slices.Contains(o.References, "https://example.com/tail") {
+		t.Errorf("tags = %v, references = %v; want the ones past the preview", o.Tags, o.References)
+	}
+}
+
+func TestDeleteRemovesCompressedContent(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.CompressContent = true
+	cfg.MaxObservationLength = 20
+	s := newTestStore(t, cfg)
+	id := mustAdd(t, s, AddObservationParams{Type: "decision", Title: "long", Content: strings.Repeat("compressed ", 10)})
+
+	// Without foreign keys nothing cascades, so the blob has to be deleted
+	// explicitly
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := deleteObservationRows(tx, "id = ?", id); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var blobs int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM observation_blobs WHERE observation_id = ?", id).Scan(&blobs); err != nil {
+		t.Fatal(err)
+	}
+	if blobs != 0 {
+		t.Errorf("%d compressed content rows left for deleted observation #%d", blobs, id)
+	}
+}
+
+// ─── Redaction ───────────────────────────────────────────────────────────────
+
+func TestRedactionOutsidePrivateTags(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.RedactionPatterns = []string{`ACME-[0-9]{8}`}
+	s := newTestStore(t, cfg)
+	s.CreateSession("test", "", "")
+
+	const key = "sk-proj-abcdefghijklmnopqrstuvwxyz012345"
+	res, err := s.SaveObservation(AddObservationParams{
+		SessionID: "test",
+		Title:     "Configured the client",
+		Content:   "Used " + key + " and ticket ACME-12345678, <private>hunter2</private>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := s.GetObservation(res.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{key, "ACME-12345678", "hunter2"} {
+		if strings.Contains(o.Content, secret) {
+			t.Errorf("content still contains %q: %q", secret, o.Content)
+		}
+	}
+	if want := "Used [REDACTED] and ticket [REDACTED], [REDACTED]"; o.Content != want {
+		t.Errorf("content = %q, want %q", o.Content, want)
+	}
+	if res.RedactionCount != 2 {
+		t.Errorf("RedactionCount = %d, want 2 (the key and the custom pattern)", res.RedactionCount)
+	}
+
+	id, err := s.AddPrompt(AddPromptParams{SessionID: "test", Content: "my key is " + key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompts, err := s.RecentPrompts("", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range prompts {
+		if p.ID == id && strings.Contains(p.Content, key) {
+			t.Errorf("prompt content still contains the key: %q", p.Content)
+		}
+	}
+}
+
+// ─── Bulk Inserts ────────────────────────────────────────────────────────────
+
+// BenchmarkAddObservations saves 100 observations per op, one AddObservation
+// (and transaction) each versus one AddObservations call.
+func BenchmarkAddObservations(b *testing.B) {
+	const perOp = 100
+	params := make([]AddObservationParams, perOp)
+	for i := range params {
+		params[i] = AddObservationParams{
+			SessionID: "bench",
+			Type:      "tool_use",
+			Title:     fmt.Sprintf("step %d", i),
+			Content:   strings.Repeat("transcript line ", 20),
+		}
+	}
+
+	b.Run("single", func(b *testing.B) {
+		s := newTestStore(b, testConfig(b))
+		s.CreateSession("bench", "", "")
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, p := range params {
+				if _, err := s.AddObservation(p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(b.N*perOp)/b.Elapsed().Seconds(), "obs/s")
+	})
+	b.Run("bulk", func(b *testing.B) {
+		s := newTestStore(b, testConfig(b))
+		s.CreateSession("bench", "", "")
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := s.AddObservations(params); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(b.N*perOp)/b.Elapsed().Seconds(), "obs/s")
+	})
+}
+
+// ─── Config File ─────────────────────────────────────────────────────────────
+
+func TestConfigFileExpandsHomeInDataDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	for in, want := range map[string]string{
+		"~":              home,
+		"~/notes/engram": home + "/notes/engram",
+		"/srv/engram":    "/srv/engram",
+		"~alice/engram":  "~alice/engram",
+	} {
+		dir := in
+		cfg := DefaultConfig()
+		(&FileConfig{DataDir: &dir}).Apply(&cfg)
+		if cfg.DataDir != want {
+			t.Errorf("data_dir %q: got %q, want %q", in, cfg.DataDir, want)
+		}
+	}
+}
+
+// ─── Fuzzy Search ────────────────────────────────────────────────────────────
+
+func TestFuzzyTotalCountsEveryPage(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	for _, title := range []string{"authentication flow", "authentication tokens", "database schema"} {
+		mustAdd(t, s, AddObservationParams{Type: "decision", Title: title, Content: title})
+	}
+
+	opts := SearchOptions{Fuzzy: true, Limit: 1}
+	resp, err := s.SearchPage("athentication", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Fuzzy || len(resp.Results) != 1 || !resp.HasMore || resp.FuzzyTotal != 2 {
+		t.Fatalf("fuzzy = %v, results = %d, has_more = %v, total = %d; want true, 1, true, 2",
+			resp.Fuzzy, len(resp.Results), resp.HasMore, resp.FuzzyTotal)
+	}
+	if _, total, err := s.SearchWithCount("athentication", opts); err != nil || total != 2 {
+		t.Errorf("SearchWithCount total = %d, %v; want 2", total, err)
+	}
+}
+
+// ─── Audit Log ───────────────────────────────────────────────────────────────
+
+func TestArchiveAliasAndFactDeleteAreAudited(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	id := mustAdd(t, s, AddObservationParams{Type: "decision", Title: "hidden", Content: "c", Project: "old-name"})
+	fact, err := s.RecordFact("staging db", "host x", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.ArchiveObservation(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UnarchiveObservation(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddProjectAlias("old-name", "engram"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteFact(fact.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.AuditLog(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Operation+" "+e.TargetID+": "+e.Detail)
+	}
+	want := []string{
+		fmt.Sprintf("%s %d: staging-db", AuditDeleteFact, fact.ID),
+		AuditProjectAlias + " old-name: moved to engram: 1 sessions, 1 observations, 0 prompts, 0 facts",
+		fmt.Sprintf("%s %d: hidden", AuditUnarchive, id),
+		fmt.Sprintf("%s %d: hidden", AuditArchive, id),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("audit log:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMergeSessionsIsAudited(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	mustAdd(t, s, AddObservationParams{SessionID: "into", Title: "t", Content: "kept"})
+	mustAdd(t, s, AddObservationParams{SessionID: "from", Title: "t", Content: "moved"})
+	if err := s.MergeSessions("into", "from"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.AuditLog(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := AuditEntry{Operation: AuditMergeSessions, TargetID: "into", Detail: "from merged in, 1 observations moved"}
+	if len(entries) != 1 || entries[0].Operation != want.Operation || entries[0].TargetID != want.TargetID || entries[0].Detail != want.Detail {
+		t.Errorf("audit log = %+v, want %+v", entries, want)
+	}
+}
+
+// ─── Batched Writes ──────────────────────────────────────────────────────────
+
+func TestBatchWriterFlushesOnCloseAndIsolatesFailingRows(t *testing.T) {
+	s := newTestStore(t, testConfig(t))
+	s.CreateSession("test", "", "")
+	// Fails a row after its observation is inserted, in its tags
+	if _, err := s.db.Exec(
+		`CREATE TRIGGER fail_tag BEFORE INSERT ON observation_tags WHEN NEW.tag = 'boom'
+		 BEGIN SELECT RAISE(ABORT, 'boom'); END`,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// A window and size this large never flush on their own, so only close
+	// does. Items go straight on the queue so they're all there before it.
+	w := newBatchWriter(s.db, time.Hour, 1000, s.withRetry)
+	titles := []string{"first", "failing", "last"}
+	items := make([]batchItem, len(titles))
+	for i, title := range titles {
+		p := AddObservationParams{SessionID: "test", Type: "decision", Title: title, Content: title}
+		if title == "failing" {
+			p.Tags = []string{"boom"}
+		}
+		p, _, err := s.prepareObservation(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items[i] = batchItem{params: p, result: make(chan batchResult, 1)}
+		w.queue <- items[i]
+	}
+	w.close()
+
+	results := make([]batchResult, len(items))
+	for i, item := range items {
+		results[i] = <-item.result
+	}
+	for i, title := range titles {
+		res := results[i]
+		if (res.err != nil) != (title == "failing") {
+			t.Errorf("%s: err = %v", title, res.err)
+			continue
+		}
+		if res.err == nil {
+			if o, err := s.GetObservation(res.id); err != nil || o.Title != title {
+				t.Errorf("%s: saved as #%d, got %v, %v", title, res.id, o, err)
+			}
+		}
+	}
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM observations WHERE title = 'failing'").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("failing row was committed without its tags")
+	}
+}
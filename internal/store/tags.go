@@ -0,0 +1,193 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ─── Tags ────────────────────────────────────────────────────────────────────
+//
+// Tags live in observation_tags, one row per (observation, tag). They're
+// normalized to lowercase without the leading '#', so "#Decision" in content
+// and "decision" passed explicitly are the same tag.
+
+// hashtagRegex matches #word hashtags at the start of the text or after
+// whitespace/punctuation, so URLs fragments ("page#section") and issue refs
+// glued to other words aren't picked up. Pure numbers ("#123") are skipped
+// later — those are issue references, not tags.
+var hashtagRegex = regexp.MustCompile(`(?:^|[\s(\[{,;])#([A-Za-z0-9][\w-]*)`)
+
+// extractHashtags returns the hashtags in content, without the '#'.
+func extractHashtags(content string) []string {
+	var tags []string
+	for _, m := range hashtagRegex.FindAllStringSubmatch(content, -1) {
+		if strings.Trim(m[1], "0123456789") == "" {
+			continue
+		}
+		tags = append(tags, m[1])
+	}
+	return tags
+}
+
+// normalizeTags lowercases, strips '#', trims trailing punctuation, and
+// dedupes tags, keeping first-seen order.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var out []string
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimRight(strings.TrimLeft(strings.TrimSpace(t), "#"), "-_"))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// insertTags records tags for an observation; duplicates are ignored.
+func insertTags(x execer, obsID int64, tags []string) error {
+	for _, t := range tags {
+		if _, err := x.Exec(
+			"INSERT OR IGNORE INTO observation_tags (observation_id, tag) VALUES (?, ?)", obsID, t,
+		); err != nil {
+			return fmt.Errorf("insert tag %q: %w", t, err)
+		}
+	}
+	return nil
+}
+
+// AddTags tags an existing observation. Tags are normalized like those given
+// at save time; ones the observation already has are ignored.
+func (s *Store) AddTags(obsID int64, tags []string) error {
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return errors.New("no tags given")
+	}
+
+	var exists int
+	err := s.db.QueryRow("SELECT 1 FROM observations WHERE id = ?", obsID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("observation #%d not found", obsID)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.withRetry(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := insertTags(tx, obsID, tags); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return err
+	}
+	s.emitObservation(EventObservationUpdated, obsID)
+	return nil
+}
+
+// TagCount is a tag and how many observations carry it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TagCounts lists every tag in use, most used first. A non-empty project
+// only counts that project's observations.
+func (s *Store) TagCounts(project string) ([]TagCount, error) {
+	project = s.canonicalProject(project)
+	query := "SELECT t.tag, COUNT(*) FROM observation_tags t"
+	var args []any
+	if project != "" {
+		query += " JOIN observations o ON o.id = t.observation_id WHERE o.project = ?"
+		args = append(args, project)
+	}
+	query += " GROUP BY t.tag ORDER BY COUNT(*) DESC, t.tag"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []TagCount
+	for rows.Next() {
+		var c TagCount
+		if err := rows.Scan(&c.Tag, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// ObservationTags returns an observation's tags in alphabetical order.
+func (s *Store) ObservationTags(id int64) ([]string, error) {
+	rows, err := s.db.Query(
+		"SELECT tag FROM observation_tags WHERE observation_id = ? ORDER BY tag", id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// attachTags fills in Tags for a batch of observations with one query. The
+// query is bounded by the batch's ID range so paged callers don't rescan the
+// whole table.
+func (s *Store) attachTags(obs []Observation) error {
+	if len(obs) == 0 {
+		return nil
+	}
+	index, lo, hi := indexObservations(obs)
+
+	rows, err := s.db.Query(
+		"SELECT observation_id, tag FROM observation_tags WHERE observation_id BETWEEN ? AND ? ORDER BY tag", lo, hi,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var t string
+		if err := rows.Scan(&id, &t); err != nil {
+			return err
+		}
+		if i, ok := index[id]; ok {
+			obs[i].Tags = append(obs[i].Tags, t)
+		}
+	}
+	return rows.Err()
+}
+
+// indexObservations maps each observation ID to its position in obs and
+// returns the lowest and highest ID.
+func indexObservations(obs []Observation) (index map[int64]int, lo, hi int64) {
+	index = make(map[int64]int, len(obs))
+	lo, hi = obs[0].ID, obs[0].ID
+	for i := range obs {
+		index[obs[i].ID] = i
+		lo, hi = min(lo, obs[i].ID), max(hi, obs[i].ID)
+	}
+	return index, lo, hi
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ─── Timestamp Display ───────────────────────────────────────────────────────
+//
+// Timestamps are stored as SQLite datetime('now') strings — UTC, in
+// sqliteTimeLayout. FormatTimestamp renders them for people; everything that
+// stores or compares timestamps keeps using the raw strings.
+
+// Time display formats for Config.TimeFormat. Any other non-empty value is
+// used as a Go time layout (e.g. "Jan 2 15:04") in the local time zone.
+const (
+	TimeFormatAbsolute = "absolute" // raw stored value, UTC (default)
+	TimeFormatRelative = "relative" // "3 hours ago"
+)
+
+// sqliteTimeLayout is the layout of datetime('now').
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+// FormatTimestamp renders a stored timestamp in the given display format.
+// Values that don't parse are returned unchanged.
+func FormatTimestamp(ts, format string) string {
+	if format == "" || format == TimeFormatAbsolute {
+		return ts
+	}
+	t, err := time.ParseInLocation(sqliteTimeLayout, ts, time.UTC)
+	if err != nil {
+		return ts
+	}
+	if format == TimeFormatRelative {
+		return relativeTime(t, time.Now())
+	}
+	return t.Local().Format(format)
+}
+
+// timestampLayouts are the layouts ParseTimestamp accepts. Layouts without
+// a zone are read as UTC, like stored timestamps.
+var timestampLayouts = []string{
+	sqliteTimeLayout,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTimestamp reads a timestamp given by a caller — RFC 3339, a stored
+// "2006-01-02 15:04:05" value, or just a date — and returns it in the
+// stored form, in UTC, so it sorts and compares with stored ones.
+func ParseTimestamp(ts string) (string, error) {
+	ts = strings.TrimSpace(ts)
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, ts, time.UTC); err == nil {
+			return t.UTC().Format(sqliteTimeLayout), nil
+		}
+	}
+	return "", fmt.Errorf("invalid timestamp %q (expected RFC 3339, \"2006-01-02 15:04:05\" or a date)", ts)
+}
+
+// FormatTime renders a stored timestamp using Config.TimeFormat.
+func (s *Store) FormatTime(ts string) string {
+	return FormatTimestamp(ts, s.cfg.TimeFormat)
+}
+
+// relativeTime describes t relative to now ("5 minutes ago"). Past a month
+// the exact age stops being useful, so it falls back to the date.
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		// Includes small negative offsets from clock skew
+		if d > -time.Minute {
+			return "just now"
+		}
+		return t.Local().Format("2006-01-02 15:04")
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour") + " ago"
+	case d < 48*time.Hour:
+		return "yesterday"
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day") + " ago"
+	}
+	return t.Local().Format("2006-01-02")
+}
+
+// plural formats a count with a unit, adding "s" when needed.
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// validTimeFormat reports whether format is a known mode or a layout that
+// actually formats something (a layout without any time fields would print
+// the same text for every timestamp).
+func validTimeFormat(format string) bool {
+	switch format {
+	case "", TimeFormatAbsolute, TimeFormatRelative:
+		return true
+	}
+	ref := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	return !strings.Contains(format, "%") && ref.Format(format) != format
+}
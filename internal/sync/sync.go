@@ -17,14 +17,26 @@
 //	│   ├── b7d2e4f1.jsonl.gz ← chunk 2
 //	│   └── ...
 //	└── engram.db              ← local working DB (gitignored)
+//
+// With a passphrase set (see WithKey), chunk files are AES-256-GCM encrypted
+// after compression. The manifest always stays plaintext, so Status works
+// without the key.
 package sync
 
 import (
+	"bytes"
+	"cmp"
 	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -45,12 +57,13 @@ type Manifest struct {
 
 // ChunkEntry describes a single chunk in the manifest.
 type ChunkEntry struct {
-	ID        string `json:"id"`         // SHA-256 hash prefix (8 chars) of content
-	CreatedBy string `json:"created_by"` // Username or machine identifier
-	CreatedAt string `json:"created_at"` // ISO timestamp
-	Sessions  int    `json:"sessions"`   // Number of sessions in chunk
-	Memories  int    `json:"memories"`   // Number of observations in chunk
-	Prompts   int    `json:"prompts"`    // Number of prompts in chunk
+	ID        string `json:"id"`                  // SHA-256 hash prefix (8 chars) of content
+	CreatedBy string `json:"created_by"`          // Username or machine identifier
+	CreatedAt string `json:"created_at"`          // ISO timestamp
+	Sessions  int    `json:"sessions"`            // Number of sessions in chunk
+	Memories  int    `json:"memories"`            // Number of observations in chunk
+	Prompts   int    `json:"prompts"`             // Number of prompts in chunk
+	Encrypted bool   `json:"encrypted,omitempty"` // Chunk file needs the sync key
 }
 
 // ChunkData is the content of a single chunk file (JSONL entries).
@@ -75,6 +88,7 @@ type ImportResult struct {
 	ChunksSkipped        int `json:"chunks_skipped"` // Already imported
 	SessionsImported     int `json:"sessions_imported"`
 	ObservationsImported int `json:"observations_imported"`
+	ObservationsSkipped  int `json:"observations_skipped"` // already present (same UID or content hash)
 	PromptsImported      int `json:"prompts_imported"`
 }
 
@@ -82,8 +96,9 @@ type ImportResult struct {
 
 // Syncer handles exporting and importing memory chunks.
 type Syncer struct {
-	store   *store.Store
-	syncDir string // Path to .engram/ in the project repo
+	store      *store.Store
+	syncDir    string // Path to .engram/ in the project repo
+	passphrase string // Encrypts new chunks and decrypts existing ones; empty = plaintext
 }
 
 // New creates a Syncer. syncDir is the .engram/ directory in the project.
@@ -91,28 +106,117 @@ func New(s *store.Store, syncDir string) *Syncer {
 	return &Syncer{store: s, syncDir: syncDir}
 }
 
+// WithKey sets the passphrase used to encrypt exported chunks and decrypt
+// imported ones. An empty passphrase exports plaintext chunks, and
+// plaintext chunks are always readable whether or not a key is set.
+func (sy *Syncer) WithKey(passphrase string) *Syncer {
+	sy.passphrase = passphrase
+	return sy
+}
+
 // ─── Export (DB → chunks) ────────────────────────────────────────────────────
 
 // Export creates a new chunk with memories not yet in any chunk.
 // It reads the manifest to know what's already exported, then creates
 // a new chunk with only the new data.
 func (sy *Syncer) Export(createdBy string, project string) (*SyncResult, error) {
+	manifest, chunk, chunkID, err := sy.nextChunk(project)
+	if err != nil {
+		return nil, err
+	}
+	if chunk == nil {
+		return &SyncResult{IsEmpty: true}, nil
+	}
+	chunkJSON, err := json.Marshal(chunk)
+	if err != nil {
+		return nil, fmt.Errorf("marshal chunk: %w", err)
+	}
+
 	// Ensure directories exist
 	chunksDir := filepath.Join(sy.syncDir, "chunks")
 	if err := os.MkdirAll(chunksDir, 0755); err != nil {
 		return nil, fmt.Errorf("create chunks dir: %w", err)
 	}
 
-	// Read current manifest (or create empty one)
-	manifest, err := sy.readManifest()
+	// Compress, encrypt if a key is set, and write the chunk
+	raw, err := gzipBytes(chunkJSON)
+	if err != nil {
+		return nil, fmt.Errorf("compress chunk: %w", err)
+	}
+	if sy.passphrase != "" {
+		if raw, err = sealChunk(raw, sy.passphrase); err != nil {
+			return nil, fmt.Errorf("encrypt chunk: %w", err)
+		}
+	}
+	chunkPath := filepath.Join(chunksDir, chunkID+".jsonl.gz")
+	if err := os.WriteFile(chunkPath, raw, 0644); err != nil {
+		return nil, fmt.Errorf("write chunk: %w", err)
+	}
+
+	// Update manifest
+	entry := ChunkEntry{
+		ID:        chunkID,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Sessions:  len(chunk.Sessions),
+		Memories:  len(chunk.Observations),
+		Prompts:   len(chunk.Prompts),
+		Encrypted: sy.passphrase != "",
+	}
+	manifest.Chunks = append(manifest.Chunks, entry)
+
+	if err := sy.writeManifest(manifest); err != nil {
+		return nil, fmt.Errorf("write manifest: %w", err)
+	}
+
+	// Record this chunk as synced in the local DB
+	if err := sy.store.RecordSyncedChunk(chunkID); err != nil {
+		return nil, fmt.Errorf("record synced chunk: %w", err)
+	}
+
+	return &SyncResult{
+		ChunkID:              chunkID,
+		SessionsExported:     len(chunk.Sessions),
+		ObservationsExported: len(chunk.Observations),
+		PromptsExported:      len(chunk.Prompts),
+	}, nil
+}
+
+// PreviewExport reports what Export would put in the next chunk for
+// project, with the same counts and sample titles Preview shows for pending
+// chunks, without writing the chunk or the manifest. ID is the chunk ID
+// Export would use. A preview with no sessions, observations or prompts
+// means there's nothing new to export.
+func (sy *Syncer) PreviewExport(createdBy string, project string) (*ChunkPreview, error) {
+	_, chunk, chunkID, err := sy.nextChunk(project)
 	if err != nil {
 		return nil, err
 	}
+	p := &ChunkPreview{
+		ID:        chunkID,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if chunk != nil {
+		summarizeChunk(p, chunk)
+	}
+	return p, nil
+}
+
+// nextChunk builds the chunk Export would write next: the memories for
+// project (all of them if "") created since the last chunk, and the ID
+// derived from its contents. chunk is nil when there's nothing new.
+func (sy *Syncer) nextChunk(project string) (manifest *Manifest, chunk *ChunkData, chunkID string, err error) {
+	// Read current manifest (or create empty one)
+	manifest, err = sy.readManifest()
+	if err != nil {
+		return nil, nil, "", err
+	}
 
 	// Get known chunk IDs from the store's sync tracking
 	knownChunks, err := sy.store.GetSyncedChunks()
 	if err != nil {
-		return nil, fmt.Errorf("get synced chunks: %w", err)
+		return nil, nil, "", fmt.Errorf("get synced chunks: %w", err)
 	}
 
 	// Also consider chunks in the manifest as known
@@ -123,7 +227,7 @@ func (sy *Syncer) Export(createdBy string, project string) (*SyncResult, error)
 	// Export all data from DB
 	data, err := sy.store.Export()
 	if err != nil {
-		return nil, fmt.Errorf("export data: %w", err)
+		return nil, nil, "", fmt.Errorf("export data: %w", err)
 	}
 
 	// Filter by project if specified
@@ -135,60 +239,26 @@ func (sy *Syncer) Export(createdBy string, project string) (*SyncResult, error)
 	lastChunkTime := sy.lastChunkTime(manifest)
 
 	// Filter to only new data (created after last chunk)
-	chunk := sy.filterNewData(data, lastChunkTime)
+	chunk = sy.filterNewData(data, lastChunkTime)
 
 	// Nothing new to export
 	if len(chunk.Sessions) == 0 && len(chunk.Observations) == 0 && len(chunk.Prompts) == 0 {
-		return &SyncResult{IsEmpty: true}, nil
+		return manifest, nil, "", nil
 	}
 
-	// Serialize and compress the chunk
+	// Generate chunk ID from content hash
 	chunkJSON, err := json.Marshal(chunk)
 	if err != nil {
-		return nil, fmt.Errorf("marshal chunk: %w", err)
+		return nil, nil, "", fmt.Errorf("marshal chunk: %w", err)
 	}
-
-	// Generate chunk ID from content hash
 	hash := sha256.Sum256(chunkJSON)
-	chunkID := hex.EncodeToString(hash[:])[:8]
+	chunkID = hex.EncodeToString(hash[:])[:8]
 
 	// Check if this exact chunk already exists
 	if _, exists := knownChunks[chunkID]; exists {
-		return &SyncResult{IsEmpty: true}, nil
+		return manifest, nil, "", nil
 	}
-
-	// Write compressed chunk
-	chunkPath := filepath.Join(chunksDir, chunkID+".jsonl.gz")
-	if err := writeGzip(chunkPath, chunkJSON); err != nil {
-		return nil, fmt.Errorf("write chunk: %w", err)
-	}
-
-	// Update manifest
-	entry := ChunkEntry{
-		ID:        chunkID,
-		CreatedBy: createdBy,
-		CreatedAt: time.Now().UTC().Format(time.RFC3339),
-		Sessions:  len(chunk.Sessions),
-		Memories:  len(chunk.Observations),
-		Prompts:   len(chunk.Prompts),
-	}
-	manifest.Chunks = append(manifest.Chunks, entry)
-
-	if err := sy.writeManifest(manifest); err != nil {
-		return nil, fmt.Errorf("write manifest: %w", err)
-	}
-
-	// Record this chunk as synced in the local DB
-	if err := sy.store.RecordSyncedChunk(chunkID); err != nil {
-		return nil, fmt.Errorf("record synced chunk: %w", err)
-	}
-
-	return &SyncResult{
-		ChunkID:              chunkID,
-		SessionsExported:     len(chunk.Sessions),
-		ObservationsExported: len(chunk.Observations),
-		PromptsExported:      len(chunk.Prompts),
-	}, nil
+	return manifest, chunk, chunkID, nil
 }
 
 // ─── Import (chunks → DB) ────────────────────────────────────────────────────
@@ -211,7 +281,6 @@ func (sy *Syncer) Import() (*ImportResult, error) {
 	}
 
 	result := &ImportResult{}
-	chunksDir := filepath.Join(sy.syncDir, "chunks")
 
 	for _, entry := range manifest.Chunks {
 		// Skip already-imported chunks
@@ -221,47 +290,53 @@ func (sy *Syncer) Import() (*ImportResult, error) {
 		}
 
 		// Read and decompress the chunk
-		chunkPath := filepath.Join(chunksDir, entry.ID+".jsonl.gz")
-		chunkJSON, err := readGzip(chunkPath)
-		if err != nil {
+		chunk, err := sy.readChunk(entry.ID)
+		if os.IsNotExist(err) {
 			// Chunk file missing — skip (maybe deleted or not yet pulled)
 			result.ChunksSkipped++
 			continue
 		}
-
-		var chunk ChunkData
-		if err := json.Unmarshal(chunkJSON, &chunk); err != nil {
-			return nil, fmt.Errorf("parse chunk %s: %w", entry.ID, err)
-		}
-
-		// Import into DB
-		exportData := &store.ExportData{
-			Version:      "0.1.0",
-			ExportedAt:   entry.CreatedAt,
-			Sessions:     chunk.Sessions,
-			Observations: chunk.Observations,
-			Prompts:      chunk.Prompts,
-		}
-
-		importResult, err := sy.store.Import(exportData)
 		if err != nil {
-			return nil, fmt.Errorf("import chunk %s: %w", entry.ID, err)
+			return nil, err
 		}
 
-		// Record this chunk as imported
-		if err := sy.store.RecordSyncedChunk(entry.ID); err != nil {
-			return nil, fmt.Errorf("record chunk %s: %w", entry.ID, err)
+		importResult, err := sy.importChunk(entry, chunk)
+		if err != nil {
+			return nil, err
 		}
 
 		result.ChunksImported++
 		result.SessionsImported += importResult.SessionsImported
 		result.ObservationsImported += importResult.ObservationsImported
+		result.ObservationsSkipped += importResult.ObservationsSkipped
 		result.PromptsImported += importResult.PromptsImported
 	}
 
 	return result, nil
 }
 
+// importChunk imports a decoded chunk into the DB and records it as synced.
+func (sy *Syncer) importChunk(entry ChunkEntry, chunk *ChunkData) (*store.ImportResult, error) {
+	exportData := &store.ExportData{
+		Version:      "0.1.0",
+		ExportedAt:   entry.CreatedAt,
+		Sessions:     chunk.Sessions,
+		Observations: chunk.Observations,
+		Prompts:      chunk.Prompts,
+	}
+
+	importResult, err := sy.store.Import(exportData)
+	if err != nil {
+		return nil, fmt.Errorf("import chunk %s: %w", entry.ID, err)
+	}
+
+	// Record this chunk as imported
+	if err := sy.store.RecordSyncedChunk(entry.ID); err != nil {
+		return nil, fmt.Errorf("record chunk %s: %w", entry.ID, err)
+	}
+	return importResult, nil
+}
+
 // Status returns information about what would be synced.
 func (sy *Syncer) Status() (localChunks int, remoteChunks int, pendingImport int, err error) {
 	manifest, err := sy.readManifest()
@@ -286,6 +361,292 @@ func (sy *Syncer) Status() (localChunks int, remoteChunks int, pendingImport int
 	return localChunks, remoteChunks, pendingImport, nil
 }
 
+// ChunkPreview summarizes a chunk's contents without importing it.
+type ChunkPreview struct {
+	ID           string   `json:"id"`
+	CreatedBy    string   `json:"created_by"`
+	CreatedAt    string   `json:"created_at"`
+	Missing      bool     `json:"missing,omitempty"` // listed in manifest but file not present
+	Locked       bool     `json:"locked,omitempty"`  // encrypted and no sync key set
+	Projects     []string `json:"projects"`
+	FirstAt      string   `json:"first_at"` // oldest session/observation/prompt timestamp
+	LastAt       string   `json:"last_at"`  // newest
+	Sessions     int      `json:"sessions"`
+	Observations int      `json:"observations"`
+	Prompts      int      `json:"prompts"`
+	SampleTitles []string `json:"sample_titles"`
+}
+
+// previewSampleTitles is how many observation titles Preview shows per chunk.
+const previewSampleTitles = 5
+
+// Preview decompresses every chunk that hasn't been imported yet and
+// summarizes it, so you can decide what to pull. Nothing is written to the DB.
+func (sy *Syncer) Preview() ([]ChunkPreview, error) {
+	manifest, err := sy.readManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	known, err := sy.store.GetSyncedChunks()
+	if err != nil {
+		return nil, fmt.Errorf("get synced chunks: %w", err)
+	}
+
+	var previews []ChunkPreview
+	for _, entry := range manifest.Chunks {
+		if known[entry.ID] {
+			continue
+		}
+
+		p := ChunkPreview{ID: entry.ID, CreatedBy: entry.CreatedBy, CreatedAt: entry.CreatedAt}
+		chunk, err := sy.readChunk(entry.ID)
+		if os.IsNotExist(err) {
+			p.Missing = true
+			previews = append(previews, p)
+			continue
+		}
+		if errors.Is(err, ErrNoKey) {
+			p.Locked = true
+			previews = append(previews, p)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		summarizeChunk(&p, chunk)
+		previews = append(previews, p)
+	}
+
+	return previews, nil
+}
+
+// summarizeChunk fills in a preview's counts, projects, date range and
+// sample titles from the chunk's contents.
+func summarizeChunk(p *ChunkPreview, chunk *ChunkData) {
+	p.Sessions = len(chunk.Sessions)
+	p.Observations = len(chunk.Observations)
+	p.Prompts = len(chunk.Prompts)
+
+	projects := make(map[string]bool)
+	span := func(t string) {
+		if t == "" {
+			return
+		}
+		if p.FirstAt == "" || t < p.FirstAt {
+			p.FirstAt = t
+		}
+		if t > p.LastAt {
+			p.LastAt = t
+		}
+	}
+	for _, s := range chunk.Sessions {
+		projects[s.Project] = true
+		span(s.StartedAt)
+	}
+	for _, o := range chunk.Observations {
+		if o.Project != nil {
+			projects[*o.Project] = true
+		}
+		// Chunks from before occurred_at existed only have created_at
+		span(cmp.Or(o.OccurredAt, o.CreatedAt))
+		if len(p.SampleTitles) < previewSampleTitles {
+			p.SampleTitles = append(p.SampleTitles, o.Title)
+		}
+	}
+	for _, pr := range chunk.Prompts {
+		span(pr.CreatedAt)
+	}
+	for name := range projects {
+		if name != "" {
+			p.Projects = append(p.Projects, name)
+		}
+	}
+	sort.Strings(p.Projects)
+}
+
+// readChunk decrypts (when needed), decompresses and parses a chunk file. A
+// missing file is reported with an error satisfying os.IsNotExist; an
+// encrypted chunk with no key set wraps ErrNoKey, and one the key can't open
+// wraps ErrWrongKey.
+func (sy *Syncer) readChunk(id string) (*ChunkData, error) {
+	chunkPath := filepath.Join(sy.syncDir, "chunks", id+".jsonl.gz")
+	raw, err := os.ReadFile(chunkPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSealed(raw) {
+		if sy.passphrase == "" {
+			return nil, fmt.Errorf("chunk %s: %w", id, ErrNoKey)
+		}
+		if raw, err = openChunk(raw, sy.passphrase); err != nil {
+			return nil, fmt.Errorf("chunk %s: %w", id, err)
+		}
+	}
+
+	chunkJSON, err := gunzipBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decompress chunk %s: %w", id, err)
+	}
+
+	var chunk ChunkData
+	if err := json.Unmarshal(chunkJSON, &chunk); err != nil {
+		return nil, fmt.Errorf("parse chunk %s: %w", id, err)
+	}
+	return &chunk, nil
+}
+
+// ─── Review (per-chunk accept/reject) ────────────────────────────────────────
+
+// ChangeKind classifies an incoming observation against local data.
+type ChangeKind string
+
+const (
+	ChangeNew       ChangeKind = "new"       // not in the local DB; will be imported
+	ChangeUnchanged ChangeKind = "unchanged" // same UID and content locally; skipped
+	ChangeConflict  ChangeKind = "conflict"  // same UID, different content; the local copy wins
+)
+
+// ObservationDiff pairs an incoming observation with its local counterpart.
+type ObservationDiff struct {
+	Kind     ChangeKind         `json:"kind"`
+	Incoming store.Observation  `json:"incoming"`
+	Local    *store.Observation `json:"local,omitempty"` // nil for ChangeNew
+}
+
+// ChunkReview is a pending chunk's preview plus how each of its
+// observations compares to what's already in the local DB.
+type ChunkReview struct {
+	ChunkPreview
+	Diffs     []ObservationDiff `json:"diffs"`
+	New       int               `json:"new"`
+	Unchanged int               `json:"unchanged"`
+	Conflicts int               `json:"conflicts"`
+}
+
+// Review is Preview with a per-observation comparison against local data,
+// for deciding chunk by chunk what to import. Nothing is written to the DB.
+// Observations are matched by UID; chunks from before UIDs existed show
+// everything as new.
+func (sy *Syncer) Review() ([]ChunkReview, error) {
+	manifest, err := sy.readManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	known, err := sy.store.GetSyncedChunks()
+	if err != nil {
+		return nil, fmt.Errorf("get synced chunks: %w", err)
+	}
+
+	var reviews []ChunkReview
+	for _, entry := range manifest.Chunks {
+		if known[entry.ID] {
+			continue
+		}
+
+		r := ChunkReview{ChunkPreview: ChunkPreview{ID: entry.ID, CreatedBy: entry.CreatedBy, CreatedAt: entry.CreatedAt}}
+		chunk, err := sy.readChunk(entry.ID)
+		if os.IsNotExist(err) {
+			r.Missing = true
+			reviews = append(reviews, r)
+			continue
+		}
+		if errors.Is(err, ErrNoKey) {
+			r.Locked = true
+			reviews = append(reviews, r)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		summarizeChunk(&r.ChunkPreview, chunk)
+
+		uids := make([]string, len(chunk.Observations))
+		for i, o := range chunk.Observations {
+			uids[i] = o.UID
+		}
+		local, err := sy.store.ObservationsByUID(uids)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range chunk.Observations {
+			d := ObservationDiff{Kind: ChangeNew, Incoming: o}
+			if l, ok := local[o.UID]; ok && o.UID != "" {
+				d.Local = &l
+				if l.Type == o.Type && l.Title == o.Title && l.Content == o.Content {
+					d.Kind = ChangeUnchanged
+				} else {
+					d.Kind = ChangeConflict
+				}
+			}
+			switch d.Kind {
+			case ChangeNew:
+				r.New++
+			case ChangeUnchanged:
+				r.Unchanged++
+			case ChangeConflict:
+				r.Conflicts++
+			}
+			r.Diffs = append(r.Diffs, d)
+		}
+
+		reviews = append(reviews, r)
+	}
+
+	return reviews, nil
+}
+
+// ImportChunk imports a single pending chunk and records it as synced.
+func (sy *Syncer) ImportChunk(id string) (*store.ImportResult, error) {
+	entry, err := sy.pendingEntry(id)
+	if err != nil {
+		return nil, err
+	}
+	chunk, err := sy.readChunk(id)
+	if err != nil {
+		return nil, fmt.Errorf("read chunk %s: %w", id, err)
+	}
+	return sy.importChunk(*entry, chunk)
+}
+
+// RejectChunk records a pending chunk as synced without importing it, so
+// later imports skip it. Its file stays in the sync directory.
+func (sy *Syncer) RejectChunk(id string) error {
+	if _, err := sy.pendingEntry(id); err != nil {
+		return err
+	}
+	if err := sy.store.RecordSyncedChunk(id); err != nil {
+		return fmt.Errorf("record chunk %s: %w", id, err)
+	}
+	return nil
+}
+
+// pendingEntry returns the manifest entry for a chunk that hasn't been
+// imported or rejected yet.
+func (sy *Syncer) pendingEntry(id string) (*ChunkEntry, error) {
+	manifest, err := sy.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	known, err := sy.store.GetSyncedChunks()
+	if err != nil {
+		return nil, fmt.Errorf("get synced chunks: %w", err)
+	}
+	if known[id] {
+		return nil, fmt.Errorf("chunk %s was already synced", id)
+	}
+	for i := range manifest.Chunks {
+		if manifest.Chunks[i].ID == id {
+			return &manifest.Chunks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("chunk %s is not in the manifest", id)
+}
+
 // ─── Manifest I/O ────────────────────────────────────────────────────────────
 
 func (sy *Syncer) readManifest() (*Manifest, error) {
@@ -405,47 +766,112 @@ func normalizeTime(t string) string {
 	return strings.TrimSpace(t)
 }
 
-// ─── Gzip I/O ────────────────────────────────────────────────────────────────
+// ─── Encryption ──────────────────────────────────────────────────────────────
+
+// ErrNoKey is returned when reading an encrypted chunk without a sync key.
+var ErrNoKey = errors.New("chunk is encrypted and no sync key is set (set ENGRAM_SYNC_KEY or pass --key)")
+
+// ErrWrongKey is returned when the sync key can't decrypt a chunk.
+var ErrWrongKey = errors.New("wrong sync key: chunk could not be decrypted")
+
+// sealedMagic starts every encrypted chunk file. Plaintext chunks start with
+// the gzip header instead, so the two can't be confused.
+var sealedMagic = []byte("ENGRAMENC1")
+
+const (
+	sealSaltSize = 16
+	sealKeySize  = 32 // AES-256
+
+	// pbkdf2Iterations makes each passphrase guess cost real work while
+	// keeping a chunk import in the tens of milliseconds.
+	pbkdf2Iterations = 200_000
+)
+
+// sealChunk encrypts data with AES-256-GCM under a key derived from the
+// passphrase. Layout: magic | salt | nonce | ciphertext+tag. Each chunk gets
+// its own random salt and nonce; the magic header is authenticated too.
+func sealChunk(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, sealSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := chunkCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(sealedMagic)+len(salt)+len(nonce)+len(data)+gcm.Overhead())
+	out = append(out, sealedMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, sealedMagic), nil
+}
 
-func writeGzip(path string, data []byte) error {
-	f, err := os.Create(path)
+// openChunk reverses sealChunk. Any authentication failure — a different
+// passphrase or a tampered file — is reported as ErrWrongKey.
+func openChunk(sealed []byte, passphrase string) ([]byte, error) {
+	rest := sealed[len(sealedMagic):]
+	if len(rest) < sealSaltSize {
+		return nil, errors.New("encrypted chunk is truncated")
+	}
+	salt, rest := rest[:sealSaltSize], rest[sealSaltSize:]
+	gcm, err := chunkCipher(passphrase, salt)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if len(rest) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, errors.New("encrypted chunk is truncated")
 	}
-	defer f.Close()
+	nonce, ciphertext := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
 
-	gz := gzip.NewWriter(f)
-	if _, err := gz.Write(data); err != nil {
-		return err
+	data, err := gcm.Open(nil, nonce, ciphertext, sealedMagic)
+	if err != nil {
+		return nil, ErrWrongKey
 	}
-	return gz.Close()
+	return data, nil
 }
 
-func readGzip(path string) ([]byte, error) {
-	f, err := os.Open(path)
+func chunkCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, sealKeySize)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-
-	gz, err := gzip.NewReader(f)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
-	defer gz.Close()
+	return cipher.NewGCM(block)
+}
 
-	var buf strings.Builder
-	data := make([]byte, 4096)
-	for {
-		n, err := gz.Read(data)
-		if n > 0 {
-			buf.Write(data[:n])
-		}
-		if err != nil {
-			break
-		}
+func isSealed(raw []byte) bool {
+	return bytes.HasPrefix(raw, sealedMagic)
+}
+
+// ─── Gzip I/O ────────────────────────────────────────────────────────────────
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
 	}
-	return []byte(buf.String()), nil
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipBytes(raw []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return io.ReadAll(gz)
 }
 
 // ─── Helpers ─────────────────────────────────────────────────────────────────
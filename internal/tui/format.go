@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/alanbuscaglia/engram/internal/store"
+)
+
+// ─── Content Rendering ───────────────────────────────────────────────────────
+
+// contentFormat returns the stored format hint, detecting it for
+// observations saved before formats were recorded.
+func contentFormat(obs *store.Observation) string {
+	if obs.Format != nil && *obs.Format != "" {
+		return *obs.Format
+	}
+	return store.DetectContentFormat(obs.Content)
+}
+
+// renderContent splits content into display lines, pretty-printing and
+// highlighting it according to format.
+func renderContent(content, format string) []string {
+	switch format {
+	case store.FormatJSON:
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, []byte(strings.TrimSpace(content)), "", "  "); err != nil {
+			break
+		}
+		lines := strings.Split(buf.String(), "\n")
+		for i, l := range lines {
+			lines[i] = highlightJSONLine(l)
+		}
+		return lines
+
+	case store.FormatDiff:
+		lines := strings.Split(content, "\n")
+		for i, l := range lines {
+			switch {
+			case strings.HasPrefix(l, "+++ "), strings.HasPrefix(l, "--- "), strings.HasPrefix(l, "diff "):
+				lines[i] = diffFileStyle.Render(l)
+			case strings.HasPrefix(l, "@@"):
+				lines[i] = diffHunkStyle.Render(l)
+			case strings.HasPrefix(l, "+"):
+				lines[i] = diffAddStyle.Render(l)
+			case strings.HasPrefix(l, "-"):
+				lines[i] = diffRemoveStyle.Render(l)
+			}
+		}
+		return lines
+
+	case store.FormatCode:
+		lines := strings.Split(content, "\n")
+		for i, l := range lines {
+			lines[i] = codeStyle.Render(strings.ReplaceAll(l, "\t", "    "))
+		}
+		return lines
+	}
+
+	return strings.Split(content, "\n")
+}
+
+// highlightJSONLine colors one line of indented JSON: keys, string values,
+// numbers, and true/false/null literals.
+func highlightJSONLine(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(line) && line[j] != '"' {
+				if line[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(line) {
+				j++
+			}
+			tok := line[i:j]
+			if strings.HasPrefix(strings.TrimSpace(line[j:]), ":") {
+				b.WriteString(jsonKeyStyle.Render(tok))
+			} else {
+				b.WriteString(jsonStringStyle.Render(tok))
+			}
+			i = j
+
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(line) && strings.IndexByte("0123456789.eE+-", line[j]) >= 0 {
+				j++
+			}
+			b.WriteString(jsonNumberStyle.Render(line[i:j]))
+			i = j
+
+		case strings.HasPrefix(line[i:], "true"), strings.HasPrefix(line[i:], "false"), strings.HasPrefix(line[i:], "null"):
+			j := i + strings.IndexAny(line[i:]+",", ",}] ")
+			b.WriteString(jsonLiteralStyle.Render(line[i:j]))
+			i = j
+
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// syncDetailViewport sizes the detail viewport to the window and fills it
+// with the selected observation's content, pretty-printed by format and
+// wrapped to fit. The scroll position is kept (and clamped) across resizes.
+func (m *Model) syncDetailViewport() {
+	// Leave room for the header, metadata rows, and footer. References
+	// get a row each, so long lists shrink the content pane.
+	height := m.Height - 16
+	if n := len(m.SelectedObservation.References); n > 1 {
+		height -= n - 1
+	}
+	if height < 5 {
+		height = 5
+	}
+	width := m.Width - 4 // appStyle horizontal padding
+	if width < 20 {
+		width = 20
+	}
+	m.DetailViewport.Width = width
+	m.DetailViewport.Height = height
+
+	obs := m.SelectedObservation
+	lines := renderContent(obs.Content, contentFormat(obs))
+	for i, l := range lines {
+		lines[i] = detailContentStyle.Width(width).Render(l)
+	}
+	m.DetailViewport.SetContent(strings.Join(lines, "\n"))
+}
@@ -12,9 +12,11 @@ package tui
 import (
 	"github.com/alanbuscaglia/engram/internal/setup"
 	"github.com/alanbuscaglia/engram/internal/store"
+	engramsync "github.com/alanbuscaglia/engram/internal/sync"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -33,6 +35,8 @@ const (
 	ScreenSessions
 	ScreenSessionDetail
 	ScreenSetup
+	ScreenSyncReview
+	ScreenSyncChunk
 )
 
 // ─── Custom Messages ─────────────────────────────────────────────────────────
@@ -44,6 +48,7 @@ type statsLoadedMsg struct {
 
 type searchResultsMsg struct {
 	results []store.SearchResult
+	hasMore bool
 	query   string
 	err     error
 }
@@ -73,6 +78,18 @@ type sessionObservationsMsg struct {
 	err          error
 }
 
+type syncReviewMsg struct {
+	reviews []engramsync.ChunkReview
+	err     error
+}
+
+type syncAppliedMsg struct {
+	imported int
+	rejected int
+	result   store.ImportResult // summed over imported chunks
+	err      error
+}
+
 type setupInstallMsg struct {
 	result *setup.Result
 	err    error
@@ -99,13 +116,14 @@ type Model struct {
 	SearchInput   textinput.Model
 	SearchQuery   string
 	SearchResults []store.SearchResult
+	SearchHasMore bool
 
 	// Recent observations
 	RecentObservations []store.Observation
 
 	// Observation detail
 	SelectedObservation *store.Observation
-	DetailScroll        int
+	DetailViewport      viewport.Model // scrolls the rendered content
 
 	// Timeline
 	Timeline *store.TimelineResult
@@ -116,6 +134,14 @@ type Model struct {
 	SessionObservations []store.Observation
 	SessionDetailScroll int
 
+	// Sync review
+	syncer           *engramsync.Syncer // nil when no sync directory is configured
+	SyncReviews      []engramsync.ChunkReview
+	SyncDecisions    map[string]syncDecision // chunk ID → decision, applied with "c"
+	SelectedChunkIdx int
+	ChunkDiffScroll  int
+	SyncStatus       string // outcome of the last apply
+
 	// Setup
 	SetupAgents         []setup.Agent
 	SetupResult         *setup.Result
@@ -126,8 +152,19 @@ type Model struct {
 	SetupSpinner        spinner.Model
 }
 
-// New creates a new TUI model connected to the given store.
-func New(s *store.Store) Model {
+// syncDecision is what the user picked for a pending sync chunk.
+type syncDecision int
+
+const (
+	syncUndecided syncDecision = iota
+	syncAccept
+	syncReject
+)
+
+// New creates a new TUI model connected to the given store. syncDir is the
+// project's .engram/ directory for sync review; empty disables it. syncKey
+// decrypts encrypted chunks during review.
+func New(s *store.Store, syncDir, syncKey string) Model {
 	ti := textinput.New()
 	ti.Placeholder = "Search memories..."
 	ti.CharLimit = 256
@@ -137,11 +174,18 @@ func New(s *store.Store) Model {
 	sp.Spinner = spinner.Dot
 	sp.Style = lipgloss.NewStyle().Foreground(colorLavender)
 
+	var syncer *engramsync.Syncer
+	if syncDir != "" {
+		syncer = engramsync.New(s, syncDir).WithKey(syncKey)
+	}
+
 	return Model{
-		store:        s,
-		Screen:       ScreenDashboard,
-		SearchInput:  ti,
-		SetupSpinner: sp,
+		store:          s,
+		syncer:         syncer,
+		Screen:         ScreenDashboard,
+		SearchInput:    ti,
+		DetailViewport: viewport.New(0, 0),
+		SetupSpinner:   sp,
 	}
 }
 
@@ -164,14 +208,17 @@ func loadStats(s *store.Store) tea.Cmd {
 
 func searchMemories(s *store.Store, query string) tea.Cmd {
 	return func() tea.Msg {
-		results, err := s.Search(query, store.SearchOptions{Limit: 50})
-		return searchResultsMsg{results: results, query: query, err: err}
+		resp, err := s.SearchPage(query, store.SearchOptions{Limit: 50})
+		if err != nil {
+			return searchResultsMsg{query: query, err: err}
+		}
+		return searchResultsMsg{results: resp.Results, hasMore: resp.HasMore, query: query}
 	}
 }
 
 func loadRecentObservations(s *store.Store) tea.Cmd {
 	return func() tea.Msg {
-		obs, err := s.AllObservations("", 50)
+		obs, err := s.AllObservations("", 50, 0)
 		return recentObservationsMsg{observations: obs, err: err}
 	}
 }
@@ -204,6 +251,43 @@ func loadSessionObservations(s *store.Store, sessionID string) tea.Cmd {
 	}
 }
 
+func loadSyncReview(sy *engramsync.Syncer) tea.Cmd {
+	return func() tea.Msg {
+		reviews, err := sy.Review()
+		return syncReviewMsg{reviews: reviews, err: err}
+	}
+}
+
+// applySyncDecisions imports accepted chunks and records rejected ones, in
+// review order. It stops at the first error, reporting what was done so far.
+func applySyncDecisions(sy *engramsync.Syncer, reviews []engramsync.ChunkReview, decisions map[string]syncDecision) tea.Cmd {
+	return func() tea.Msg {
+		var msg syncAppliedMsg
+		for _, r := range reviews {
+			switch decisions[r.ID] {
+			case syncAccept:
+				res, err := sy.ImportChunk(r.ID)
+				if err != nil {
+					msg.err = err
+					return msg
+				}
+				msg.imported++
+				msg.result.SessionsImported += res.SessionsImported
+				msg.result.ObservationsImported += res.ObservationsImported
+				msg.result.ObservationsSkipped += res.ObservationsSkipped
+				msg.result.PromptsImported += res.PromptsImported
+			case syncReject:
+				if err := sy.RejectChunk(r.ID); err != nil {
+					msg.err = err
+					return msg
+				}
+				msg.rejected++
+			}
+		}
+		return msg
+	}
+}
+
 func installAgent(agentName string) tea.Cmd {
 	return func() tea.Msg {
 		result, err := setup.Install(agentName)
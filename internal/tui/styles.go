@@ -152,6 +152,38 @@ var (
 	// Detail value
 	detailValueStyle = lipgloss.NewStyle().
 				Foreground(colorText)
+
+	// External reference (URL / issue ID)
+	referenceStyle = lipgloss.NewStyle().
+			Foreground(colorBlue).
+			Underline(true)
+)
+
+// ─── Content Format Styles ───────────────────────────────────────────────────
+
+var (
+	// JSON
+	jsonKeyStyle     = lipgloss.NewStyle().Foreground(colorBlue)
+	jsonStringStyle  = lipgloss.NewStyle().Foreground(colorGreen)
+	jsonNumberStyle  = lipgloss.NewStyle().Foreground(colorPeach)
+	jsonLiteralStyle = lipgloss.NewStyle().Foreground(colorMauve)
+
+	// Diff
+	diffAddStyle    = lipgloss.NewStyle().Foreground(colorGreen)
+	diffRemoveStyle = lipgloss.NewStyle().Foreground(colorRed)
+	diffHunkStyle   = lipgloss.NewStyle().Foreground(colorBlue)
+	diffFileStyle   = lipgloss.NewStyle().Bold(true).Foreground(colorText)
+
+	// Code
+	codeStyle = lipgloss.NewStyle().Foreground(colorTeal)
+)
+
+// ─── Sync Review Styles ──────────────────────────────────────────────────────
+
+var (
+	syncAcceptStyle   = lipgloss.NewStyle().Foreground(colorGreen).Bold(true)
+	syncRejectStyle   = lipgloss.NewStyle().Foreground(colorRed).Bold(true)
+	syncConflictStyle = lipgloss.NewStyle().Foreground(colorPeach)
 )
 
 // ─── Timeline Styles ─────────────────────────────────────────────────────────
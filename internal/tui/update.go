@@ -1,6 +1,8 @@
 package tui
 
 import (
+	"fmt"
+
 	"github.com/alanbuscaglia/engram/internal/setup"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,6 +16,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.Width = msg.Width
 		m.Height = msg.Height
+		// Content is wrapped to the viewport width, so re-render it
+		if m.SelectedObservation != nil {
+			m.syncDetailViewport()
+		}
 		return m, nil
 
 	case tea.KeyMsg:
@@ -42,6 +48,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.SearchResults = msg.results
+		m.SearchHasMore = msg.hasMore
 		m.SearchQuery = msg.query
 		m.Screen = ScreenSearchResults
 		m.Cursor = 0
@@ -63,7 +70,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.SelectedObservation = msg.observation
 		m.Screen = ScreenObservationDetail
-		m.DetailScroll = 0
+		m.syncDetailViewport()
+		m.DetailViewport.GotoTop()
 		return m, nil
 
 	case timelineMsg:
@@ -95,6 +103,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.SessionDetailScroll = 0
 		return m, nil
 
+	case syncReviewMsg:
+		if msg.err != nil {
+			m.ErrorMsg = msg.err.Error()
+			return m, nil
+		}
+		m.SyncReviews = msg.reviews
+		// Drop decisions for chunks that are no longer pending
+		pending := make(map[string]syncDecision, len(msg.reviews))
+		for _, r := range msg.reviews {
+			if d := m.SyncDecisions[r.ID]; d != syncUndecided {
+				pending[r.ID] = d
+			}
+		}
+		m.SyncDecisions = pending
+		if m.Cursor >= len(m.SyncReviews) {
+			m.Cursor = 0
+			m.Scroll = 0
+		}
+		return m, nil
+
+	case syncAppliedMsg:
+		m.SyncStatus = fmt.Sprintf("Imported %d chunk(s) (%d observations, %d already present), rejected %d.",
+			msg.imported, msg.result.ObservationsImported, msg.result.ObservationsSkipped, msg.rejected)
+		if msg.err != nil {
+			m.ErrorMsg = msg.err.Error()
+		}
+		return m, loadSyncReview(m.syncer)
+
 	case setupInstallMsg:
 		m.SetupInstalling = false
 		m.SetupDone = true
@@ -144,6 +180,10 @@ func (m Model) handleKeyPress(key string) (tea.Model, tea.Cmd) {
 		return m.handleSessionDetailKeys(key)
 	case ScreenSetup:
 		return m.handleSetupKeys(key)
+	case ScreenSyncReview:
+		return m.handleSyncReviewKeys(key)
+	case ScreenSyncChunk:
+		return m.handleSyncChunkKeys(key)
 	}
 	return m, nil
 }
@@ -154,6 +194,7 @@ var dashboardMenuItems = []string{
 	"Search memories",
 	"Recent observations",
 	"Browse sessions",
+	"Review sync chunks",
 	"Setup agent plugin",
 	"Quit",
 }
@@ -202,7 +243,20 @@ func (m Model) handleDashboardSelection() (tea.Model, tea.Cmd) {
 		m.Cursor = 0
 		m.Scroll = 0
 		return m, loadRecentSessions(m.store)
-	case 3: // Setup
+	case 3: // Sync review
+		if m.syncer == nil {
+			m.ErrorMsg = "sync review is not available"
+			return m, nil
+		}
+		m.PrevScreen = ScreenDashboard
+		m.Screen = ScreenSyncReview
+		m.Cursor = 0
+		m.Scroll = 0
+		m.SyncReviews = nil
+		m.SyncDecisions = nil
+		m.SyncStatus = ""
+		return m, loadSyncReview(m.syncer)
+	case 4: // Setup
 		m.PrevScreen = ScreenDashboard
 		m.Screen = ScreenSetup
 		m.Cursor = 0
@@ -213,7 +267,7 @@ func (m Model) handleDashboardSelection() (tea.Model, tea.Cmd) {
 		m.SetupInstalling = false
 		m.SetupInstallingName = ""
 		return m, nil
-	case 4: // Quit
+	case 5: // Quit
 		return m, tea.Quit
 	}
 	return m, nil
@@ -358,11 +412,21 @@ func (m Model) handleRecentKeys(key string) (tea.Model, tea.Cmd) {
 func (m Model) handleObservationDetailKeys(key string) (tea.Model, tea.Cmd) {
 	switch key {
 	case "up", "k":
-		if m.DetailScroll > 0 {
-			m.DetailScroll--
-		}
+		m.DetailViewport.ScrollUp(1)
 	case "down", "j":
-		m.DetailScroll++
+		m.DetailViewport.ScrollDown(1)
+	case "pgup", "b":
+		m.DetailViewport.PageUp()
+	case "pgdown", "f", " ":
+		m.DetailViewport.PageDown()
+	case "ctrl+u":
+		m.DetailViewport.HalfPageUp()
+	case "ctrl+d":
+		m.DetailViewport.HalfPageDown()
+	case "home", "g":
+		m.DetailViewport.GotoTop()
+	case "end", "G":
+		m.DetailViewport.GotoBottom()
 	case "t":
 		// View timeline for this observation
 		if m.SelectedObservation != nil {
@@ -371,7 +435,6 @@ func (m Model) handleObservationDetailKeys(key string) (tea.Model, tea.Cmd) {
 	case "esc", "q":
 		m.Screen = m.PrevScreen
 		m.Cursor = 0
-		m.DetailScroll = 0
 		return m, m.refreshScreen(m.PrevScreen)
 	}
 	return m, nil
@@ -479,6 +542,101 @@ func (m Model) handleSessionDetailKeys(key string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// ─── Sync Review ─────────────────────────────────────────────────────────────
+
+func (m Model) handleSyncReviewKeys(key string) (tea.Model, tea.Cmd) {
+	visibleItems := (m.Height - 10) / 2 // 2 lines per chunk
+	if visibleItems < 3 {
+		visibleItems = 3
+	}
+
+	switch key {
+	case "up", "k":
+		if m.Cursor > 0 {
+			m.Cursor--
+			if m.Cursor < m.Scroll {
+				m.Scroll = m.Cursor
+			}
+		}
+	case "down", "j":
+		if m.Cursor < len(m.SyncReviews)-1 {
+			m.Cursor++
+			if m.Cursor >= m.Scroll+visibleItems {
+				m.Scroll = m.Cursor - visibleItems + 1
+			}
+		}
+	case "enter":
+		if m.Cursor < len(m.SyncReviews) {
+			m.SelectedChunkIdx = m.Cursor
+			m.ChunkDiffScroll = 0
+			m.Screen = ScreenSyncChunk
+		}
+	case "a", "x", "u":
+		if m.Cursor < len(m.SyncReviews) {
+			m.decideSyncChunk(m.Cursor, key)
+		}
+	case "c":
+		if len(m.SyncDecisions) > 0 {
+			return m, applySyncDecisions(m.syncer, m.SyncReviews, m.SyncDecisions)
+		}
+	case "r":
+		return m, loadSyncReview(m.syncer)
+	case "esc", "q":
+		m.Screen = ScreenDashboard
+		m.Cursor = 0
+		m.Scroll = 0
+		return m, loadStats(m.store)
+	}
+	return m, nil
+}
+
+func (m Model) handleSyncChunkKeys(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up", "k":
+		if m.ChunkDiffScroll > 0 {
+			m.ChunkDiffScroll--
+		}
+	case "down", "j":
+		if m.ChunkDiffScroll < len(m.chunkDiffLines())-1 {
+			m.ChunkDiffScroll++
+		}
+	case "a", "x", "u":
+		m.decideSyncChunk(m.SelectedChunkIdx, key)
+		m.Screen = ScreenSyncReview
+	case "esc", "q":
+		m.Screen = ScreenSyncReview
+	}
+	return m, nil
+}
+
+// decideSyncChunk records a (a)ccept, re(x)ject or (u)ndo for a chunk.
+// Chunks whose file hasn't been pulled can only be rejected.
+func (m *Model) decideSyncChunk(idx int, key string) {
+	if idx >= len(m.SyncReviews) {
+		return
+	}
+	r := m.SyncReviews[idx]
+	if m.SyncDecisions == nil {
+		m.SyncDecisions = make(map[string]syncDecision)
+	}
+	switch key {
+	case "a":
+		if r.Missing {
+			m.ErrorMsg = fmt.Sprintf("chunk %s has no file to import", r.ID)
+			return
+		}
+		if r.Locked {
+			m.ErrorMsg = fmt.Sprintf("chunk %s is encrypted — restart with ENGRAM_SYNC_KEY set to import it", r.ID)
+			return
+		}
+		m.SyncDecisions[r.ID] = syncAccept
+	case "x":
+		m.SyncDecisions[r.ID] = syncReject
+	case "u":
+		delete(m.SyncDecisions, r.ID)
+	}
+}
+
 // ─── Setup ───────────────────────────────────────────────────────────────────
 
 func (m Model) handleSetupKeys(key string) (tea.Model, tea.Cmd) {
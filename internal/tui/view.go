@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/alanbuscaglia/engram/internal/store"
+	engramsync "github.com/alanbuscaglia/engram/internal/sync"
+
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -31,6 +34,10 @@ func (m Model) View() string {
 		content = m.viewSessionDetail()
 	case ScreenSetup:
 		content = m.viewSetup()
+	case ScreenSyncReview:
+		content = m.viewSyncReview()
+	case ScreenSyncChunk:
+		content = m.viewSyncChunk()
 	default:
 		content = "Unknown screen"
 	}
@@ -122,6 +129,9 @@ func (m Model) viewSearchResults() string {
 	if resultCount != 1 {
 		header += "s"
 	}
+	if m.SearchHasMore {
+		header += ", more available"
+	}
 	b.WriteString(headerStyle.Render(header))
 	b.WriteString("\n")
 
@@ -144,7 +154,7 @@ func (m Model) viewSearchResults() string {
 
 	for i := m.Scroll; i < end; i++ {
 		r := m.SearchResults[i]
-		b.WriteString(m.renderObservationListItem(i, r.ID, r.Type, r.Title, r.Content, r.CreatedAt, r.Project))
+		b.WriteString(m.renderObservationListItem(i, r.ID, r.Type, r.Title, r.Content, r.OccurredAt, r.Project))
 	}
 
 	// Scroll indicator
@@ -187,7 +197,7 @@ func (m Model) viewRecent() string {
 
 	for i := m.Scroll; i < end; i++ {
 		o := m.RecentObservations[i]
-		b.WriteString(m.renderObservationListItem(i, o.ID, o.Type, o.Title, o.Content, o.CreatedAt, o.Project))
+		b.WriteString(m.renderObservationListItem(i, o.ID, o.Type, o.Title, o.Content, o.OccurredAt, o.Project))
 	}
 
 	if count > visibleItems {
@@ -233,7 +243,13 @@ func (m Model) viewObservationDetail() string {
 
 	b.WriteString(fmt.Sprintf("%s %s\n",
 		detailLabelStyle.Render("Created:"),
-		timestampStyle.Render(obs.CreatedAt)))
+		timestampStyle.Render(m.store.FormatTime(obs.CreatedAt))))
+
+	if obs.OccurredAt != obs.CreatedAt {
+		b.WriteString(fmt.Sprintf("%s %s\n",
+			detailLabelStyle.Render("Occurred:"),
+			timestampStyle.Render(m.store.FormatTime(obs.OccurredAt))))
+	}
 
 	if obs.ToolName != nil {
 		b.WriteString(fmt.Sprintf("%s %s\n",
@@ -247,43 +263,47 @@ func (m Model) viewObservationDetail() string {
 			projectStyle.Render(*obs.Project)))
 	}
 
-	// Content section
-	b.WriteString("\n")
-	b.WriteString(sectionHeadingStyle.Render("  Content"))
-	b.WriteString("\n")
-
-	// Split content into lines and apply scroll
-	contentLines := strings.Split(obs.Content, "\n")
-	maxLines := m.Height - 16
-	if maxLines < 5 {
-		maxLines = 5
+	if len(obs.Tags) > 0 {
+		b.WriteString(fmt.Sprintf("%s %s\n",
+			detailLabelStyle.Render("Tags:"),
+			detailValueStyle.Render("#"+strings.Join(obs.Tags, " #"))))
 	}
 
-	// Clamp scroll
-	maxScroll := len(contentLines) - maxLines
-	if maxScroll < 0 {
-		maxScroll = 0
-	}
-	if m.DetailScroll > maxScroll {
-		m.DetailScroll = maxScroll
+	for i, ref := range obs.References {
+		label := ""
+		if i == 0 {
+			label = "References:"
+		}
+		b.WriteString(fmt.Sprintf("%s %s\n",
+			detailLabelStyle.Render(label),
+			referenceStyle.Render(ref)))
 	}
 
-	end := m.DetailScroll + maxLines
-	if end > len(contentLines) {
-		end = len(contentLines)
+	format := contentFormat(obs)
+	if format != store.FormatText {
+		b.WriteString(fmt.Sprintf("%s %s\n",
+			detailLabelStyle.Render("Format:"),
+			detailValueStyle.Render(format)))
 	}
 
-	for i := m.DetailScroll; i < end; i++ {
-		b.WriteString(detailContentStyle.Render(contentLines[i]))
-		b.WriteString("\n")
-	}
+	// Content section
+	b.WriteString("\n")
+	b.WriteString(sectionHeadingStyle.Render("  Content"))
+	b.WriteString("\n")
 
-	if len(contentLines) > maxLines {
+	// Content is rendered into the viewport by syncDetailViewport
+	vp := m.DetailViewport
+	b.WriteString(vp.View())
+	b.WriteString("\n")
+
+	if total := vp.TotalLineCount(); total > vp.Height {
+		first := vp.YOffset + 1
+		last := min(vp.YOffset+vp.Height, total)
 		b.WriteString(fmt.Sprintf("\n  %s",
-			timestampStyle.Render(fmt.Sprintf("line %d-%d of %d", m.DetailScroll+1, end, len(contentLines)))))
+			timestampStyle.Render(fmt.Sprintf("line %d-%d of %d (%d%%)", first, last, total, int(vp.ScrollPercent()*100)))))
 	}
 
-	b.WriteString(helpStyle.Render("\n  j/k scroll • t timeline • esc back"))
+	b.WriteString(helpStyle.Render("\n  j/k scroll • pgup/pgdn page • g/G top/bottom • t timeline • esc back"))
 
 	return b.String()
 }
@@ -351,6 +371,26 @@ func (m Model) viewTimeline() string {
 		}
 	}
 
+	// Links, across sessions, with arrows pointing the way the relation reads
+	if len(tl.Links) > 0 {
+		b.WriteString("\n")
+		b.WriteString(sectionHeadingStyle.Render("  Links"))
+		b.WriteString("\n")
+		for _, l := range tl.Links {
+			if l.FromID == tl.Focus.ID {
+				b.WriteString(fmt.Sprintf("  this —%s→ %s  %s\n",
+					typeBadgeStyle.Render(l.Relation),
+					idStyle.Render(fmt.Sprintf("#%d", l.ToID)),
+					timelineItemStyle.Render(truncateStr(l.ToTitle, 60))))
+			} else {
+				b.WriteString(fmt.Sprintf("  %s  %s —%s→ this\n",
+					idStyle.Render(fmt.Sprintf("#%d", l.FromID)),
+					timelineItemStyle.Render(truncateStr(l.FromTitle, 60)),
+					typeBadgeStyle.Render(l.Relation)))
+			}
+		}
+	}
+
 	b.WriteString(helpStyle.Render("\n  j/k scroll • esc back"))
 
 	return b.String()
@@ -400,7 +440,7 @@ func (m Model) viewSessions() string {
 		line := fmt.Sprintf("%s%s  %s  %s obs  %s",
 			cursor,
 			projectStyle.Render(fmt.Sprintf("%-20s", s.Project)),
-			timestampStyle.Render(s.StartedAt),
+			timestampStyle.Render(m.store.FormatTime(s.StartedAt)),
 			statNumberStyle.Render(fmt.Sprintf("%d", s.ObservationCount)),
 			style.Render(summary))
 
@@ -431,7 +471,7 @@ func (m Model) viewSessionDetail() string {
 	}
 
 	sess := m.Sessions[m.SelectedSessionIdx]
-	header := fmt.Sprintf("  Session: %s — %s", sess.Project, sess.StartedAt)
+	header := fmt.Sprintf("  Session: %s — %s", sess.Project, m.store.FormatTime(sess.StartedAt))
 	b.WriteString(headerStyle.Render(header))
 	b.WriteString("\n")
 
@@ -465,7 +505,7 @@ func (m Model) viewSessionDetail() string {
 
 	for i := m.SessionDetailScroll; i < end; i++ {
 		o := m.SessionObservations[i]
-		b.WriteString(m.renderObservationListItem(i, o.ID, o.Type, o.Title, o.Content, o.CreatedAt, o.Project))
+		b.WriteString(m.renderObservationListItem(i, o.ID, o.Type, o.Title, o.Content, o.OccurredAt, o.Project))
 	}
 
 	if count > visibleItems {
@@ -478,6 +518,196 @@ func (m Model) viewSessionDetail() string {
 	return b.String()
 }
 
+// ─── Sync Review ─────────────────────────────────────────────────────────────
+
+func (m Model) viewSyncReview() string {
+	var b strings.Builder
+
+	b.WriteString(headerStyle.Render("  Sync Review"))
+	b.WriteString("\n")
+
+	if m.SyncStatus != "" {
+		b.WriteString(fmt.Sprintf("  %s\n", statLabelStyle.Render(m.SyncStatus)))
+	}
+
+	count := len(m.SyncReviews)
+	if count == 0 {
+		b.WriteString(noResultsStyle.Render("Nothing pending — all chunks in .engram/ are already imported."))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render("  r reload • esc back"))
+		return b.String()
+	}
+
+	b.WriteString(sectionHeadingStyle.Render(fmt.Sprintf("  Pending chunks (%d)", count)))
+	b.WriteString("\n")
+
+	visibleItems := (m.Height - 10) / 2 // 2 lines per chunk
+	if visibleItems < 3 {
+		visibleItems = 3
+	}
+
+	end := m.Scroll + visibleItems
+	if end > count {
+		end = count
+	}
+
+	for i := m.Scroll; i < end; i++ {
+		r := m.SyncReviews[i]
+		cursor := "  "
+		style := listItemStyle
+		if i == m.Cursor {
+			cursor = "▸ "
+			style = listSelectedStyle
+		}
+
+		mark := "[ ]"
+		switch m.SyncDecisions[r.ID] {
+		case syncAccept:
+			mark = syncAcceptStyle.Render("[✓]")
+		case syncReject:
+			mark = syncRejectStyle.Render("[✗]")
+		}
+
+		b.WriteString(fmt.Sprintf("%s%s %s  %s  %s\n",
+			cursor, mark,
+			style.Render(r.ID),
+			statLabelStyle.Render("by "+r.CreatedBy),
+			timestampStyle.Render(r.CreatedAt)))
+
+		var summary string
+		if r.Missing {
+			summary = syncConflictStyle.Render("chunk file missing — not pulled yet?")
+		} else if r.Locked {
+			summary = syncConflictStyle.Render("encrypted — set ENGRAM_SYNC_KEY to review")
+		} else {
+			summary = fmt.Sprintf("%s new • %s unchanged • %s • %s",
+				statNumberStyle.Render(fmt.Sprintf("%d", r.New)),
+				fmt.Sprintf("%d", r.Unchanged),
+				conflictCount(r.Conflicts),
+				projectStyle.Render(strings.Join(r.Projects, ", ")))
+		}
+		b.WriteString(fmt.Sprintf("         %s\n", summary))
+	}
+
+	if count > visibleItems {
+		b.WriteString(fmt.Sprintf("\n  %s",
+			timestampStyle.Render(fmt.Sprintf("showing %d-%d of %d", m.Scroll+1, end, count))))
+	}
+
+	b.WriteString(helpStyle.Render("\n  j/k navigate • enter diff • a accept • x reject • u undo • c apply • r reload • esc back"))
+
+	return b.String()
+}
+
+// conflictCount renders a chunk's conflict count, highlighted when nonzero.
+func conflictCount(n int) string {
+	text := fmt.Sprintf("%d conflicts", n)
+	if n == 1 {
+		text = "1 conflict"
+	}
+	if n > 0 {
+		return syncConflictStyle.Render(text)
+	}
+	return text
+}
+
+func (m Model) viewSyncChunk() string {
+	var b strings.Builder
+
+	if m.SelectedChunkIdx >= len(m.SyncReviews) {
+		b.WriteString(headerStyle.Render("  Sync Chunk"))
+		b.WriteString("\n")
+		b.WriteString(noResultsStyle.Render("Chunk not found."))
+		return b.String()
+	}
+
+	r := m.SyncReviews[m.SelectedChunkIdx]
+	b.WriteString(headerStyle.Render(fmt.Sprintf("  Chunk %s — by %s", r.ID, r.CreatedBy)))
+	b.WriteString("\n")
+	if !r.Missing && !r.Locked {
+		b.WriteString(fmt.Sprintf("%s %s → %s\n",
+			detailLabelStyle.Render("Date range:"),
+			timestampStyle.Render(m.store.FormatTime(r.FirstAt)),
+			timestampStyle.Render(m.store.FormatTime(r.LastAt))))
+		b.WriteString(fmt.Sprintf("%s %d sessions, %d observations, %d prompts\n",
+			detailLabelStyle.Render("Contents:"),
+			r.Sessions, r.Observations, r.Prompts))
+		if r.Conflicts > 0 {
+			b.WriteString(fmt.Sprintf("%s %s\n",
+				detailLabelStyle.Render(""),
+				syncConflictStyle.Render("Conflicting observations keep the local copy on import.")))
+		}
+	}
+	b.WriteString("\n")
+
+	lines := m.chunkDiffLines()
+	visible := m.Height - 12
+	if visible < 5 {
+		visible = 5
+	}
+	end := m.ChunkDiffScroll + visible
+	if end > len(lines) {
+		end = len(lines)
+	}
+	for _, l := range lines[m.ChunkDiffScroll:end] {
+		b.WriteString(l)
+		b.WriteString("\n")
+	}
+
+	if len(lines) > visible {
+		b.WriteString(fmt.Sprintf("\n  %s",
+			timestampStyle.Render(fmt.Sprintf("line %d-%d of %d", m.ChunkDiffScroll+1, end, len(lines)))))
+	}
+
+	b.WriteString(helpStyle.Render("\n  j/k scroll • a accept • x reject • u undo • esc back"))
+
+	return b.String()
+}
+
+// chunkDiffLines renders the selected chunk's observations against local
+// data: + new, = unchanged, ! conflict followed by local/incoming values.
+func (m Model) chunkDiffLines() []string {
+	if m.SelectedChunkIdx >= len(m.SyncReviews) {
+		return nil
+	}
+	r := m.SyncReviews[m.SelectedChunkIdx]
+	if r.Missing {
+		return []string{noResultsStyle.Render("The chunk file is missing — pull it first, or reject the chunk to skip it.")}
+	}
+	if r.Locked {
+		return []string{noResultsStyle.Render("The chunk is encrypted — restart with ENGRAM_SYNC_KEY set to see its contents.")}
+	}
+	if len(r.Diffs) == 0 {
+		return []string{noResultsStyle.Render("No observations in this chunk.")}
+	}
+
+	var lines []string
+	for _, d := range r.Diffs {
+		o := d.Incoming
+		title := fmt.Sprintf("[%s] %s", o.Type, truncateStr(o.Title, 70))
+		switch d.Kind {
+		case engramsync.ChangeNew:
+			lines = append(lines, diffAddStyle.Render("  + "+title))
+		case engramsync.ChangeUnchanged:
+			lines = append(lines, timestampStyle.Render("  = "+title))
+		case engramsync.ChangeConflict:
+			l := d.Local
+			lines = append(lines, syncConflictStyle.Render(fmt.Sprintf("  ! %s (local #%d)", title, l.ID)))
+			if l.Type != o.Type || l.Title != o.Title {
+				lines = append(lines,
+					diffRemoveStyle.Render(fmt.Sprintf("      - [%s] %s", l.Type, truncateStr(l.Title, 70))),
+					diffAddStyle.Render(fmt.Sprintf("      + [%s] %s", o.Type, truncateStr(o.Title, 70))))
+			}
+			if l.Content != o.Content {
+				lines = append(lines,
+					diffRemoveStyle.Render("      - "+truncateStr(l.Content, 100)),
+					diffAddStyle.Render("      + "+truncateStr(o.Content, 100)))
+			}
+		}
+	}
+	return lines
+}
+
 // ─── Setup ───────────────────────────────────────────────────────────────────
 
 func (m Model) viewSetup() string {
@@ -590,7 +820,7 @@ func (m Model) renderObservationListItem(index int, id int64, obsType, title, co
 		typeBadgeStyle.Render(fmt.Sprintf("[%-12s]", obsType)),
 		style.Render(truncateStr(title, 50)),
 		proj,
-		timestampStyle.Render(createdAt))
+		timestampStyle.Render(m.store.FormatTime(createdAt)))
 
 	// Content preview on second line
 	preview := truncateStr(content, 80)
@@ -27,13 +27,18 @@ const ENGRAM_TOOLS = new Set([
   "mem_search",
   "mem_save",
   "mem_save_prompt",
+  "mem_search_prompts",
+  "mem_recall",
   "mem_session_summary",
   "mem_context",
   "mem_stats",
   "mem_timeline",
   "mem_get_observation",
+  "mem_get_links",
   "mem_session_start",
   "mem_session_end",
+  "mem_task_update",
+  "mem_fact",
 ])
 
 // ─── Memory Instructions ─────────────────────────────────────────────────────